@@ -0,0 +1,294 @@
+// Package agent provides a tool-calling agent loop for agar applications.
+//
+// An Agent sends the conversation to an llm.Provider, executes any tools the
+// model asks for through a tools.ToolRegistry, feeds the results back and
+// repeats until the model stops requesting tools.
+//
+// # Basic Usage
+//
+//	registry := tools.NewToolRegistry()
+//	registry.Register(tools.NewReadTool())
+//
+//	a := agent.New(agent.Config{
+//	    Provider:     llm.NewAnthropicProvider(llm.AnthropicConfig{}),
+//	    Tools:        registry,
+//	    SystemPrompt: "You are a helpful coding assistant.",
+//	})
+//
+//	result, err := a.Run(ctx, "Summarize README.md")
+//	fmt.Println(result.Text)
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/geoffjay/agar/llm"
+	"github.com/geoffjay/agar/tools"
+)
+
+// DefaultMaxTurns is the default limit on model calls per Run
+const DefaultMaxTurns = 25
+
+// Config holds configuration for creating a new Agent
+type Config struct {
+	Provider     llm.Provider        // Model backend (required)
+	Model        string              // Optional model name, overriding the provider default
+	Tools        *tools.ToolRegistry // Optional tool registry exposed to the model
+	SystemPrompt string              // Optional system prompt
+	MaxTurns     int                 // Maximum model calls per Run (default: 25)
+	MaxTokens    int                 // Maximum output tokens per model call (default: 4096)
+	Context      *ContextManager     // Optional context manager fitting the history into a token budget
+	OnEvent      func(Event)         // Optional callback for progress events
+}
+
+// EventType identifies the kind of an agent event
+type EventType string
+
+const (
+	EventMessage       EventType = "message" // A message was appended to the history
+	EventModelResponse EventType = "model_response"
+	EventToolCall      EventType = "tool_call"
+	EventToolResult    EventType = "tool_result"
+	EventDelta         EventType = "delta" // A piece of a streamed model response
+)
+
+// Event reports progress during a Run. Events are delivered synchronously
+// from the goroutine calling Run.
+type Event struct {
+	Type     EventType
+	Turn     int
+	Message  *llm.Message      // Set for EventMessage and EventModelResponse
+	ToolCall *llm.ContentBlock // Set for EventToolCall and EventToolResult
+	Result   *tools.ToolResult // Set for EventToolResult
+	Delta    *llm.Delta        // Set for EventDelta
+}
+
+// Result represents the outcome of a Run
+type Result struct {
+	Text       string         `json:"text"`
+	StopReason llm.StopReason `json:"stop_reason"`
+	Turns      int            `json:"turns"`
+	ToolCalls  int            `json:"tool_calls"`
+	Usage      llm.Usage      `json:"usage"`    // Token usage summed over all model calls
+	Messages   []llm.Message  `json:"messages"` // Messages appended to the history by this run
+}
+
+// Agent runs a conversation against a model, executing tool calls as requested
+type Agent struct {
+	config  Config
+	history []llm.Message
+	mu      sync.Mutex
+}
+
+// New creates a new agent with the specified configuration
+func New(config Config) *Agent {
+	if config.MaxTurns <= 0 {
+		config.MaxTurns = DefaultMaxTurns
+	}
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = llm.DefaultMaxTokens
+	}
+
+	return &Agent{
+		config:  config,
+		history: make([]llm.Message, 0),
+	}
+}
+
+// Run sends the user input to the model and loops over tool calls until the
+// model finishes its turn. The conversation history is kept between runs.
+func (a *Agent) Run(ctx context.Context, input string) (*Result, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.config.Provider == nil {
+		return nil, fmt.Errorf("agent has no provider configured")
+	}
+
+	start := len(a.history)
+	a.appendMessage(0, llm.NewTextMessage(llm.RoleUser, input))
+
+	result := &Result{}
+	toolDefs := a.toolDefinitions()
+
+	for result.Turns < a.config.MaxTurns {
+		if err := ctx.Err(); err != nil {
+			return a.finish(result, start), err
+		}
+
+		messages := a.history
+		if a.config.Context != nil {
+			fitted, err := a.config.Context.Fit(ctx, a.config.SystemPrompt, a.history)
+			if err != nil {
+				return a.finish(result, start), fmt.Errorf("failed to fit context: %w", err)
+			}
+			messages = fitted
+		}
+
+		result.Turns++
+		resp, err := a.complete(ctx, result.Turns, &llm.Request{
+			Model:     a.config.Model,
+			System:    a.config.SystemPrompt,
+			Messages:  messages,
+			Tools:     toolDefs,
+			MaxTokens: a.config.MaxTokens,
+		})
+		if err != nil {
+			return a.finish(result, start), fmt.Errorf("model call failed: %w", err)
+		}
+
+		msg := resp.Message
+		msg.Role = llm.RoleAssistant
+		a.appendMessage(result.Turns, msg)
+		result.StopReason = resp.StopReason
+		result.Usage.Add(resp.Usage)
+		a.emit(Event{Type: EventModelResponse, Turn: result.Turns, Message: &msg})
+
+		uses := msg.ToolUses()
+		if len(uses) == 0 {
+			result.Text = msg.Text()
+			return a.finish(result, start), nil
+		}
+
+		// Execute every requested tool and return all results in one message
+		results := make([]llm.ContentBlock, 0, len(uses))
+		for i := range uses {
+			use := uses[i]
+
+			// Once cancelled, answer the remaining calls without running them so
+			// the history stays valid for the next run
+			if err := ctx.Err(); err != nil {
+				results = append(results, cancelledToolResult(use, err))
+				continue
+			}
+
+			a.emit(Event{Type: EventToolCall, Turn: result.Turns, ToolCall: &use})
+
+			block, toolResult := a.executeTool(ctx, use)
+			results = append(results, block)
+			result.ToolCalls++
+
+			a.emit(Event{Type: EventToolResult, Turn: result.Turns, ToolCall: &use, Result: toolResult})
+		}
+		a.appendMessage(result.Turns, llm.Message{Role: llm.RoleUser, Content: results})
+	}
+
+	return a.finish(result, start), fmt.Errorf("agent stopped after reaching max turns (%d)", a.config.MaxTurns)
+}
+
+// History returns a copy of the conversation history
+func (a *Agent) History() []llm.Message {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	history := make([]llm.Message, len(a.history))
+	copy(history, a.history)
+	return history
+}
+
+// SetHistory replaces the conversation history
+func (a *Agent) SetHistory(messages []llm.Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.history = make([]llm.Message, len(messages))
+	copy(a.history, messages)
+
+	if a.config.Context != nil {
+		a.config.Context.Reset()
+	}
+}
+
+// Reset clears the conversation history
+func (a *Agent) Reset() {
+	a.SetHistory(nil)
+}
+
+// complete calls the model. When the provider implements llm.Streamer and
+// OnEvent is set, the reply is streamed and reported as EventDelta events.
+func (a *Agent) complete(ctx context.Context, turn int, req *llm.Request) (*llm.Response, error) {
+	streamer, ok := a.config.Provider.(llm.Streamer)
+	if !ok || a.config.OnEvent == nil {
+		return a.config.Provider.Complete(ctx, req)
+	}
+
+	return streamer.Stream(ctx, req, func(delta llm.Delta) {
+		a.emit(Event{Type: EventDelta, Turn: turn, Delta: &delta})
+	})
+}
+
+// appendMessage adds a message to the history and reports it
+func (a *Agent) appendMessage(turn int, msg llm.Message) {
+	a.history = append(a.history, msg)
+	a.emit(Event{Type: EventMessage, Turn: turn, Message: &msg})
+}
+
+// executeTool runs a single tool use block and returns the tool result block
+func (a *Agent) executeTool(ctx context.Context, use llm.ContentBlock) (llm.ContentBlock, *tools.ToolResult) {
+	toolResult := a.runTool(ctx, use)
+
+	content, err := json.Marshal(toolResult)
+	if err != nil {
+		toolResult = &tools.ToolResult{Success: false, Error: fmt.Sprintf("failed to encode result: %v", err)}
+		content, _ = json.Marshal(toolResult)
+	}
+
+	return llm.NewToolResultBlock(use.ID, string(content), !toolResult.Success), toolResult
+}
+
+// cancelledToolResult builds the error result for a tool call skipped because
+// the run was cancelled
+func cancelledToolResult(use llm.ContentBlock, err error) llm.ContentBlock {
+	content, _ := json.Marshal(&tools.ToolResult{Success: false, Error: fmt.Sprintf("not executed: %v", err)})
+	return llm.NewToolResultBlock(use.ID, string(content), true)
+}
+
+// runTool validates and executes the requested tool through the registry
+func (a *Agent) runTool(ctx context.Context, use llm.ContentBlock) *tools.ToolResult {
+	if a.config.Tools == nil {
+		return &tools.ToolResult{Success: false, Error: fmt.Sprintf("tool %s not found", use.Name)}
+	}
+
+	return a.config.Tools.Execute(ctx, use.Name, use.Input)
+}
+
+// toolDefinitions builds the tool definitions sent to the model, sorted by name
+func (a *Agent) toolDefinitions() []llm.ToolDefinition {
+	if a.config.Tools == nil {
+		return nil
+	}
+
+	registered := a.config.Tools.ListTools()
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i].Name() < registered[j].Name()
+	})
+
+	defs := make([]llm.ToolDefinition, 0, len(registered))
+	for _, tool := range registered {
+		defs = append(defs, llm.ToolDefinition{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			InputSchema: tool.Schema(),
+		})
+	}
+
+	return defs
+}
+
+// finish fills in the messages produced since start
+func (a *Agent) finish(result *Result, start int) *Result {
+	result.Messages = make([]llm.Message, len(a.history)-start)
+	copy(result.Messages, a.history[start:])
+	return result
+}
+
+// emit delivers an event to the configured callback
+func (a *Agent) emit(event Event) {
+	if a.config.OnEvent != nil {
+		a.config.OnEvent(event)
+	}
+}
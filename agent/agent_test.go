@@ -0,0 +1,388 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/geoffjay/agar/llm"
+	"github.com/geoffjay/agar/tools"
+)
+
+// echoTool returns its "text" parameter
+type echoTool struct {
+	calls     int
+	onExecute func() // Optional hook run before returning
+}
+
+func (e *echoTool) Name() string        { return "echo" }
+func (e *echoTool) Description() string { return "Echo the given text" }
+
+func (e *echoTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"text": map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"text"},
+	}
+}
+
+func (e *echoTool) Validate(params json.RawMessage) error {
+	var p struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return err
+	}
+	if p.Text == "" {
+		return errors.New("text is required")
+	}
+	return nil
+}
+
+func (e *echoTool) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	e.calls++
+	if e.onExecute != nil {
+		e.onExecute()
+	}
+	var p struct {
+		Text string `json:"text"`
+	}
+	_ = json.Unmarshal(params, &p)
+	return p.Text, nil
+}
+
+// scriptedModel is a provider that replays a fixed list of responses and records requests
+type scriptedModel struct {
+	responses []*llm.Response
+	requests  []*llm.Request
+}
+
+func (s *scriptedModel) Complete(ctx context.Context, req *llm.Request) (*llm.Response, error) {
+	// Copy the messages since the agent keeps appending to its history
+	snapshot := *req
+	snapshot.Messages = append([]llm.Message(nil), req.Messages...)
+	s.requests = append(s.requests, &snapshot)
+
+	if len(s.requests) > len(s.responses) {
+		return nil, errors.New("no more scripted responses")
+	}
+	return s.responses[len(s.requests)-1], nil
+}
+
+func toolUse(id, name, input string) llm.ContentBlock {
+	return llm.ContentBlock{Type: llm.ContentToolUse, ID: id, Name: name, Input: json.RawMessage(input)}
+}
+
+func newTestRegistry(tool tools.Tool) *tools.ToolRegistry {
+	registry := tools.NewToolRegistry()
+	_ = registry.Register(tool)
+	return registry
+}
+
+func TestAgent_RunWithoutTools(t *testing.T) {
+	model := &scriptedModel{responses: []*llm.Response{
+		{Message: llm.NewTextMessage(llm.RoleAssistant, "Hello!"), StopReason: llm.StopEndTurn},
+	}}
+
+	a := New(Config{Provider: model, SystemPrompt: "be nice"})
+	result, err := a.Run(context.Background(), "Hi")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if result.Text != "Hello!" {
+		t.Errorf("Expected text 'Hello!', got '%s'", result.Text)
+	}
+	if result.Turns != 1 {
+		t.Errorf("Expected 1 turn, got %d", result.Turns)
+	}
+	if len(result.Messages) != 2 {
+		t.Errorf("Expected 2 new messages, got %d", len(result.Messages))
+	}
+	if model.requests[0].System != "be nice" {
+		t.Errorf("Expected system prompt to be sent, got '%s'", model.requests[0].System)
+	}
+	if len(model.requests[0].Tools) != 0 {
+		t.Errorf("Expected no tool definitions, got %d", len(model.requests[0].Tools))
+	}
+}
+
+func TestAgent_RunToolLoop(t *testing.T) {
+	echo := &echoTool{}
+	model := &scriptedModel{responses: []*llm.Response{
+		{
+			Message: llm.Message{Role: llm.RoleAssistant, Content: []llm.ContentBlock{
+				{Type: llm.ContentText, Text: "Let me echo that."},
+				toolUse("call_1", "echo", `{"text": "ping"}`),
+			}},
+			StopReason: llm.StopToolUse,
+		},
+		{Message: llm.NewTextMessage(llm.RoleAssistant, "The tool said ping."), StopReason: llm.StopEndTurn},
+	}}
+
+	var events []EventType
+	a := New(Config{
+		Provider: model,
+		Tools:    newTestRegistry(echo),
+		OnEvent: func(e Event) {
+			events = append(events, e.Type)
+		},
+	})
+
+	result, err := a.Run(context.Background(), "echo ping")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if echo.calls != 1 {
+		t.Errorf("Expected tool to be called once, got %d", echo.calls)
+	}
+	if result.ToolCalls != 1 || result.Turns != 2 {
+		t.Errorf("Expected 1 tool call over 2 turns, got %d calls over %d turns", result.ToolCalls, result.Turns)
+	}
+	if result.Text != "The tool said ping." {
+		t.Errorf("Unexpected final text: %s", result.Text)
+	}
+
+	// The tool definition must be advertised to the model
+	if len(model.requests[0].Tools) != 1 || model.requests[0].Tools[0].Name != "echo" {
+		t.Errorf("Expected echo tool definition, got %+v", model.requests[0].Tools)
+	}
+
+	// The second request must carry the tool result
+	second := model.requests[1].Messages
+	last := second[len(second)-1]
+	if last.Role != llm.RoleUser || len(last.Content) != 1 || last.Content[0].Type != llm.ContentToolResult {
+		t.Fatalf("Expected tool result message, got %+v", last)
+	}
+	block := last.Content[0]
+	if block.ToolUseID != "call_1" || block.IsError {
+		t.Errorf("Unexpected tool result block: %+v", block)
+	}
+
+	var toolResult tools.ToolResult
+	if err := json.Unmarshal([]byte(block.Content), &toolResult); err != nil {
+		t.Fatalf("Tool result is not valid JSON: %v", err)
+	}
+	if !toolResult.Success || toolResult.Data != "ping" {
+		t.Errorf("Unexpected tool result: %+v", toolResult)
+	}
+
+	expected := []EventType{
+		EventMessage,                     // user input
+		EventMessage, EventModelResponse, // tool use
+		EventToolCall, EventToolResult,
+		EventMessage,                     // tool results
+		EventMessage, EventModelResponse, // final reply
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected events %v, got %v", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Event %d: expected %s, got %s", i, expected[i], events[i])
+		}
+	}
+}
+
+// streamingModel is a scriptedModel that also implements llm.Streamer,
+// sending each reply's text one word at a time
+type streamingModel struct {
+	scriptedModel
+	streamed int
+}
+
+func (s *streamingModel) Stream(ctx context.Context, req *llm.Request, handler llm.StreamHandler) (*llm.Response, error) {
+	s.streamed++
+	resp, err := s.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range strings.SplitAfter(resp.Message.Text(), " ") {
+		handler(llm.Delta{Type: llm.DeltaText, Text: word})
+	}
+	return resp, nil
+}
+
+func TestAgent_Stream(t *testing.T) {
+	model := &streamingModel{scriptedModel: scriptedModel{responses: []*llm.Response{
+		{Message: llm.NewTextMessage(llm.RoleAssistant, "Hello there, friend."), StopReason: llm.StopEndTurn},
+		{Message: llm.NewTextMessage(llm.RoleAssistant, "Again."), StopReason: llm.StopEndTurn},
+	}}}
+
+	var text strings.Builder
+	a := New(Config{
+		Provider: model,
+		OnEvent: func(e Event) {
+			if e.Type == EventDelta {
+				text.WriteString(e.Delta.Text)
+			}
+		},
+	})
+
+	result, err := a.Run(context.Background(), "hi")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if model.streamed != 1 || text.String() != "Hello there, friend." || result.Text != "Hello there, friend." {
+		t.Errorf("Expected the reply to be streamed as deltas, got %q from %d streams", text.String(), model.streamed)
+	}
+
+	// Without a listener there is nothing to stream to
+	quiet := New(Config{Provider: model})
+	if _, err := quiet.Run(context.Background(), "hi"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if model.streamed != 1 {
+		t.Errorf("Expected Complete without OnEvent, got %d streams", model.streamed)
+	}
+}
+
+func TestAgent_ToolErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		use     llm.ContentBlock
+		wantErr string
+	}{
+		{
+			name:    "unknown tool",
+			use:     toolUse("call_1", "missing", `{}`),
+			wantErr: "not found",
+		},
+		{
+			name:    "schema violation",
+			use:     toolUse("call_1", "echo", `{"text": 1}`),
+			wantErr: "text: expected string, got integer",
+		},
+		{
+			name:    "validation failure",
+			use:     toolUse("call_1", "echo", `{"text": ""}`),
+			wantErr: "text is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			echo := &echoTool{}
+			model := &scriptedModel{responses: []*llm.Response{
+				{Message: llm.Message{Role: llm.RoleAssistant, Content: []llm.ContentBlock{tt.use}}, StopReason: llm.StopToolUse},
+				{Message: llm.NewTextMessage(llm.RoleAssistant, "done"), StopReason: llm.StopEndTurn},
+			}}
+
+			a := New(Config{Provider: model, Tools: newTestRegistry(echo)})
+			if _, err := a.Run(context.Background(), "go"); err != nil {
+				t.Fatalf("Run failed: %v", err)
+			}
+
+			if echo.calls != 0 {
+				t.Errorf("Expected tool not to be executed")
+			}
+
+			msgs := model.requests[1].Messages
+			block := msgs[len(msgs)-1].Content[0]
+			if !block.IsError {
+				t.Error("Expected tool result to be marked as error")
+			}
+			if !strings.Contains(block.Content, tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %s", tt.wantErr, block.Content)
+			}
+		})
+	}
+}
+
+func TestAgent_MaxTurns(t *testing.T) {
+	loop := &llm.Response{
+		Message:    llm.Message{Role: llm.RoleAssistant, Content: []llm.ContentBlock{toolUse("call", "echo", `{"text": "again"}`)}},
+		StopReason: llm.StopToolUse,
+	}
+	model := &scriptedModel{responses: []*llm.Response{loop, loop, loop}}
+
+	a := New(Config{Provider: model, Tools: newTestRegistry(&echoTool{}), MaxTurns: 2})
+	result, err := a.Run(context.Background(), "loop forever")
+	if err == nil {
+		t.Fatal("Expected error when max turns is reached")
+	}
+	if result.Turns != 2 {
+		t.Errorf("Expected 2 turns, got %d", result.Turns)
+	}
+}
+
+func TestAgent_ModelError(t *testing.T) {
+	model := llm.ProviderFunc(func(ctx context.Context, req *llm.Request) (*llm.Response, error) {
+		return nil, errors.New("boom")
+	})
+
+	a := New(Config{Provider: model})
+	if _, err := a.Run(context.Background(), "hi"); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected model error, got %v", err)
+	}
+}
+
+func TestAgent_HistoryPersistsBetweenRuns(t *testing.T) {
+	model := &scriptedModel{responses: []*llm.Response{
+		{Message: llm.NewTextMessage(llm.RoleAssistant, "one"), StopReason: llm.StopEndTurn},
+		{Message: llm.NewTextMessage(llm.RoleAssistant, "two"), StopReason: llm.StopEndTurn},
+	}}
+
+	a := New(Config{Provider: model})
+	_, _ = a.Run(context.Background(), "first")
+	_, _ = a.Run(context.Background(), "second")
+
+	if len(model.requests[1].Messages) != 3 {
+		t.Errorf("Expected 3 messages in second request, got %d", len(model.requests[1].Messages))
+	}
+	if len(a.History()) != 4 {
+		t.Errorf("Expected 4 messages in history, got %d", len(a.History()))
+	}
+
+	a.Reset()
+	if len(a.History()) != 0 {
+		t.Errorf("Expected empty history after reset")
+	}
+}
+
+func TestAgent_CancelDuringTools(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	echo := &echoTool{onExecute: cancel}
+	model := &scriptedModel{responses: []*llm.Response{
+		{
+			Message: llm.Message{Role: llm.RoleAssistant, Content: []llm.ContentBlock{
+				toolUse("call_1", "echo", `{"text": "one"}`),
+				toolUse("call_2", "echo", `{"text": "two"}`),
+			}},
+			StopReason: llm.StopToolUse,
+		},
+	}}
+
+	a := New(Config{Provider: model, Tools: newTestRegistry(echo)})
+	_, err := a.Run(ctx, "echo twice")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	if echo.calls != 1 {
+		t.Errorf("Expected the second tool call to be skipped, got %d calls", echo.calls)
+	}
+	if len(model.requests) != 1 {
+		t.Errorf("Expected no further model calls, got %d", len(model.requests))
+	}
+
+	// Every tool use must still have a matching result
+	history := a.History()
+	last := history[len(history)-1]
+	if last.Role != llm.RoleUser || len(last.Content) != 2 {
+		t.Fatalf("Expected a user message with 2 tool results, got %+v", last)
+	}
+	if last.Content[0].IsError {
+		t.Error("Expected the first tool result to succeed")
+	}
+	if !last.Content[1].IsError || last.Content[1].ToolUseID != "call_2" {
+		t.Errorf("Expected a cancelled result for call_2, got %+v", last.Content[1])
+	}
+}
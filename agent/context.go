@@ -0,0 +1,345 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/geoffjay/agar/llm"
+)
+
+// Context window defaults
+const (
+	DefaultContextTokens    = 100000 // Budget for the system prompt and messages
+	DefaultKeepRecent       = 10     // Most recent messages kept verbatim
+	DefaultToolResultTokens = 250    // Size older tool results are elided to
+)
+
+// summaryTokens is the room left for a generated summary
+const summaryTokens = 1024
+
+// TokenEstimator returns the approximate number of tokens in a string
+type TokenEstimator func(text string) int
+
+// EstimateTokens approximates the token count of text at four characters per
+// token, which is close enough for budgeting English text and code
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + 3) / 4
+}
+
+// Summarizer condenses older messages into a short summary. previous is the
+// summary produced for earlier messages, if any, and should be folded in.
+type Summarizer interface {
+	Summarize(ctx context.Context, previous string, messages []llm.Message) (string, error)
+}
+
+// SummarizerFunc adapts a plain function to the Summarizer interface
+type SummarizerFunc func(ctx context.Context, previous string, messages []llm.Message) (string, error)
+
+// Summarize calls f(ctx, previous, messages)
+func (f SummarizerFunc) Summarize(ctx context.Context, previous string, messages []llm.Message) (string, error) {
+	return f(ctx, previous, messages)
+}
+
+// ContextConfig holds configuration for creating a new ContextManager
+type ContextConfig struct {
+	MaxTokens        int            // Token budget for the system prompt and messages (default: 100000)
+	KeepRecent       int            // Number of recent messages kept verbatim (default: 10)
+	ToolResultTokens int            // Older tool results are elided to this size (default: 250)
+	Summarizer       Summarizer     // Optional summarizer for messages that no longer fit
+	Estimator        TokenEstimator // Optional token estimator (default: EstimateTokens)
+}
+
+// ContextManager fits a conversation into a token budget before it is sent to
+// the model. The system prompt and the first user message are always kept, so
+// the model does not lose the original goal. Older tool output is elided
+// first; if that is not enough, older messages are replaced by a summary (or
+// dropped when no Summarizer is configured) and the recent window is shrunk.
+//
+// The full history is never modified; Fit returns a new slice.
+type ContextManager struct {
+	config ContextConfig
+
+	mu         sync.Mutex
+	summary    string // Summary of messages[1:summarized]
+	summarized int    // Index up to which the summary covers the history
+}
+
+// NewContextManager creates a new context manager with the specified configuration
+func NewContextManager(config ContextConfig) *ContextManager {
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = DefaultContextTokens
+	}
+	if config.KeepRecent <= 0 {
+		config.KeepRecent = DefaultKeepRecent
+	}
+	if config.ToolResultTokens <= 0 {
+		config.ToolResultTokens = DefaultToolResultTokens
+	}
+	if config.Estimator == nil {
+		config.Estimator = EstimateTokens
+	}
+
+	return &ContextManager{config: config}
+}
+
+// MessageTokens estimates the tokens used by a single message
+func (m *ContextManager) MessageTokens(msg llm.Message) int {
+	// Small per-message and per-block overhead for roles and framing
+	total := 4
+	for _, block := range msg.Content {
+		total += 2
+		total += m.config.Estimator(block.Text)
+		total += m.config.Estimator(block.Name)
+		total += m.config.Estimator(string(block.Input))
+		total += m.config.Estimator(block.Content)
+	}
+	return total
+}
+
+// Tokens estimates the tokens used by a system prompt and messages
+func (m *ContextManager) Tokens(system string, messages []llm.Message) int {
+	total := m.config.Estimator(system)
+	for _, msg := range messages {
+		total += m.MessageTokens(msg)
+	}
+	return total
+}
+
+// Reset forgets any cached summary, for example when the history is replaced
+func (m *ContextManager) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.summary = ""
+	m.summarized = 0
+}
+
+// Fit returns the messages to send so that the system prompt and messages fit
+// within the token budget. It returns the history unchanged when it already fits.
+func (m *ContextManager) Fit(ctx context.Context, system string, history []llm.Message) ([]llm.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(history) == 0 || m.Tokens(system, history) <= m.config.MaxTokens {
+		return history, nil
+	}
+
+	// A shorter history than the summary covers means it was replaced
+	if m.summarized > len(history) {
+		m.summary = ""
+		m.summarized = 0
+	}
+
+	// Stage 1: elide large tool results outside the recent window
+	start := m.recentStart(history, m.config.KeepRecent)
+	fitted := make([]llm.Message, len(history))
+	for i, msg := range history {
+		if i > 0 && i < start {
+			msg = m.elideToolResults(msg)
+		}
+		fitted[i] = msg
+	}
+	if m.Tokens(system, fitted) <= m.config.MaxTokens {
+		return fitted, nil
+	}
+
+	// Stage 2: replace everything between the first message and the recent
+	// window with a summary, shrinking the window until the result fits.
+	// Room is left for the summary so the model is only asked once.
+	reserve := 0
+	if m.config.Summarizer != nil {
+		reserve = summaryTokens
+	}
+
+	keep := m.config.KeepRecent
+	for {
+		fitted = m.assemble(history, start)
+		if m.Tokens(system, fitted)+reserve <= m.config.MaxTokens || keep <= 1 {
+			break
+		}
+
+		keep--
+		start = m.recentStart(history, keep)
+	}
+
+	if start > 1 && m.config.Summarizer != nil {
+		if err := m.summarize(ctx, history, start); err != nil {
+			return nil, err
+		}
+		fitted = m.assemble(history, start)
+	}
+
+	// Stage 3: as a last resort, elide tool results in what is left
+	if m.Tokens(system, fitted) > m.config.MaxTokens {
+		for i := 1; i < len(fitted)-1; i++ {
+			fitted[i] = m.elideToolResults(fitted[i])
+		}
+	}
+
+	return fitted, nil
+}
+
+// recentStart returns the index where the recent window of about keep
+// messages begins. The window starts at an assistant message so that tool
+// results are never separated from the tool calls they answer.
+func (m *ContextManager) recentStart(history []llm.Message, keep int) int {
+	start := len(history) - keep
+	if start < 1 {
+		return 1
+	}
+
+	for i := start; i < len(history); i++ {
+		if history[i].Role == llm.RoleAssistant {
+			return i
+		}
+	}
+
+	// No assistant message in the window; keep only the final message unless
+	// it answers tool calls
+	last := len(history) - 1
+	if last > 0 && len(history[last].ToolUses()) == 0 && !hasToolResults(history[last]) {
+		return last
+	}
+	return start
+}
+
+// summarize extends the cached summary to cover history[1:end]
+func (m *ContextManager) summarize(ctx context.Context, history []llm.Message, end int) error {
+	if m.config.Summarizer == nil || end <= m.summarized {
+		return nil
+	}
+
+	from := m.summarized
+	if from < 1 {
+		from = 1
+	}
+
+	summary, err := m.config.Summarizer.Summarize(ctx, m.summary, history[from:end])
+	if err != nil {
+		return fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+
+	m.summary = strings.TrimSpace(summary)
+	m.summarized = end
+	return nil
+}
+
+// assemble builds the pinned first message, with the summary attached, followed
+// by the recent window starting at start
+func (m *ContextManager) assemble(history []llm.Message, start int) []llm.Message {
+	first := history[0]
+	if start > 1 {
+		first.Content = append([]llm.ContentBlock(nil), first.Content...)
+		first.Content = append(first.Content, llm.ContentBlock{
+			Type: llm.ContentText,
+			Text: m.summaryText(start - 1),
+		})
+	}
+
+	fitted := make([]llm.Message, 0, len(history)-start+1)
+	fitted = append(fitted, first)
+	return append(fitted, history[start:]...)
+}
+
+// summaryText describes the omitted messages for the model
+func (m *ContextManager) summaryText(omitted int) string {
+	// Only use the summary when it covers everything that was omitted
+	if m.summary != "" && m.summarized >= omitted+1 {
+		return fmt.Sprintf("<conversation_summary>\n%s\n</conversation_summary>", m.summary)
+	}
+	return fmt.Sprintf("[%d earlier messages omitted to fit the context window]", omitted)
+}
+
+// elideToolResults shortens tool result content beyond the configured size
+func (m *ContextManager) elideToolResults(msg llm.Message) llm.Message {
+	if !hasToolResults(msg) {
+		return msg
+	}
+
+	limit := m.config.ToolResultTokens
+	content := make([]llm.ContentBlock, len(msg.Content))
+	for i, block := range msg.Content {
+		if block.Type == llm.ContentToolResult {
+			if tokens := m.config.Estimator(block.Content); tokens > limit {
+				block.Content = elide(block.Content, limit*4, tokens-limit)
+			}
+		}
+		content[i] = block
+	}
+	msg.Content = content
+	return msg
+}
+
+// elide keeps the first size bytes of text, cut at a line break where
+// possible, and notes how much was removed
+func elide(text string, size, removed int) string {
+	if size > len(text) {
+		size = len(text)
+	}
+	head := text[:size]
+	if i := strings.LastIndexByte(head, '\n'); i > size/2 {
+		head = head[:i]
+	}
+	return fmt.Sprintf("%s\n[... about %d tokens of tool output elided ...]", head, removed)
+}
+
+// hasToolResults reports whether the message carries any tool result blocks
+func hasToolResults(msg llm.Message) bool {
+	for _, block := range msg.Content {
+		if block.Type == llm.ContentToolResult {
+			return true
+		}
+	}
+	return false
+}
+
+// NewProviderSummarizer creates a Summarizer that asks a model to summarize
+// older messages. model may be empty to use the provider's default.
+func NewProviderSummarizer(provider llm.Provider, model string) Summarizer {
+	return SummarizerFunc(func(ctx context.Context, previous string, messages []llm.Message) (string, error) {
+		var transcript strings.Builder
+		if previous != "" {
+			fmt.Fprintf(&transcript, "Summary so far:\n%s\n\nNew messages:\n", previous)
+		}
+		for _, msg := range messages {
+			writeTranscript(&transcript, msg)
+		}
+
+		resp, err := provider.Complete(ctx, &llm.Request{
+			Model: model,
+			System: "You summarize conversations between a user and an AI assistant so the assistant can continue " +
+				"the work. Keep the user's goals, decisions made, files and commands involved, important results " +
+				"and any open questions. Be concise and factual. Reply with the summary only.",
+			Messages:  []llm.Message{llm.NewTextMessage(llm.RoleUser, transcript.String())},
+			MaxTokens: summaryTokens,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		return resp.Message.Text(), nil
+	})
+}
+
+// writeTranscript renders a message as plain text for summarization
+func writeTranscript(b *strings.Builder, msg llm.Message) {
+	for _, block := range msg.Content {
+		switch block.Type {
+		case llm.ContentText:
+			fmt.Fprintf(b, "%s: %s\n", msg.Role, block.Text)
+		case llm.ContentToolUse:
+			fmt.Fprintf(b, "%s called tool %s with %s\n", msg.Role, block.Name, string(block.Input))
+		case llm.ContentToolResult:
+			content := block.Content
+			if len(content) > 2000 {
+				content = content[:2000] + "..."
+			}
+			fmt.Fprintf(b, "tool result: %s\n", content)
+		}
+	}
+}
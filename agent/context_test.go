@@ -0,0 +1,245 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/geoffjay/agar/llm"
+)
+
+// longConversation builds a goal message followed by turns of assistant tool
+// calls with large results and short replies
+func longConversation(turns int) []llm.Message {
+	history := []llm.Message{llm.NewTextMessage(llm.RoleUser, "GOAL: refactor the parser")}
+	for i := 0; i < turns; i++ {
+		id := fmt.Sprintf("call_%d", i)
+		history = append(history,
+			llm.Message{Role: llm.RoleAssistant, Content: []llm.ContentBlock{
+				toolUse(id, "read", `{"path": "parser.go"}`),
+			}},
+			llm.Message{Role: llm.RoleUser, Content: []llm.ContentBlock{
+				llm.NewToolResultBlock(id, strings.Repeat(fmt.Sprintf("line %d\n", i), 400), false),
+			}},
+			llm.NewTextMessage(llm.RoleAssistant, fmt.Sprintf("Step %d done.", i)),
+			llm.NewTextMessage(llm.RoleUser, fmt.Sprintf("Continue with step %d", i+1)),
+		)
+	}
+	return history
+}
+
+// checkToolPairs verifies every tool result follows the tool use it answers
+func checkToolPairs(t *testing.T, messages []llm.Message) {
+	t.Helper()
+	seen := map[string]bool{}
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			switch block.Type {
+			case llm.ContentToolUse:
+				seen[block.ID] = true
+			case llm.ContentToolResult:
+				if !seen[block.ToolUseID] {
+					t.Errorf("Tool result %s has no matching tool use", block.ToolUseID)
+				}
+			}
+		}
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if EstimateTokens("") != 0 {
+		t.Error("Expected 0 tokens for empty text")
+	}
+	if got := EstimateTokens("abcdefgh"); got != 2 {
+		t.Errorf("Expected 2 tokens, got %d", got)
+	}
+	if got := EstimateTokens("abcde"); got != 2 {
+		t.Errorf("Expected partial tokens to round up, got %d", got)
+	}
+}
+
+func TestContextManager_FitsUnchanged(t *testing.T) {
+	m := NewContextManager(ContextConfig{})
+	history := longConversation(2)
+
+	fitted, err := m.Fit(context.Background(), "system", history)
+	if err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	if len(fitted) != len(history) {
+		t.Errorf("Expected history under budget to be unchanged, got %d of %d messages", len(fitted), len(history))
+	}
+}
+
+func TestContextManager_ElidesOldToolResults(t *testing.T) {
+	history := longConversation(6)
+	m := NewContextManager(ContextConfig{KeepRecent: 4})
+
+	// Budget that only fits once the old tool output is elided
+	full := m.Tokens("", history)
+	budget := full * 6 / 10
+	m = NewContextManager(ContextConfig{KeepRecent: 4, MaxTokens: budget})
+
+	fitted, err := m.Fit(context.Background(), "", history)
+	if err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+
+	if len(fitted) != len(history) {
+		t.Fatalf("Expected all messages to be kept, got %d of %d", len(fitted), len(history))
+	}
+	if m.Tokens("", fitted) > budget {
+		t.Errorf("Expected fitted history within budget")
+	}
+
+	old := fitted[2].Content[0].Content
+	if !strings.Contains(old, "elided") {
+		t.Errorf("Expected old tool result to be elided, got %d bytes", len(old))
+	}
+	if history[2].Content[0].Content == old {
+		t.Error("Expected the original history to be left untouched")
+	}
+	checkToolPairs(t, fitted)
+}
+
+func TestContextManager_SummarizesAndPinsGoal(t *testing.T) {
+	history := longConversation(20)
+
+	var calls int
+	summarizer := SummarizerFunc(func(ctx context.Context, previous string, messages []llm.Message) (string, error) {
+		calls++
+		return fmt.Sprintf("summary of %d messages", len(messages)), nil
+	})
+
+	m := NewContextManager(ContextConfig{MaxTokens: 3000, KeepRecent: 8, Summarizer: summarizer})
+	fitted, err := m.Fit(context.Background(), "system prompt", history)
+	if err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected one summarizer call, got %d", calls)
+	}
+	if got := m.Tokens("system prompt", fitted); got > 3000 {
+		t.Errorf("Expected fitted history within budget, got %d tokens", got)
+	}
+
+	// The goal stays first, with the summary attached
+	first := fitted[0]
+	if first.Content[0].Text != "GOAL: refactor the parser" {
+		t.Errorf("Expected first user message to be pinned, got %q", first.Content[0].Text)
+	}
+	if len(first.Content) != 2 || !strings.Contains(first.Content[1].Text, "<conversation_summary>") {
+		t.Errorf("Expected summary block on the pinned message, got %+v", first.Content)
+	}
+	if fitted[1].Role != llm.RoleAssistant {
+		t.Errorf("Expected the recent window to start with an assistant message, got %s", fitted[1].Role)
+	}
+	if fitted[len(fitted)-1].Text() != history[len(history)-1].Text() {
+		t.Error("Expected the latest message to be kept")
+	}
+	checkToolPairs(t, fitted)
+
+	// A second fit with one more turn extends the cached summary
+	history = append(history,
+		llm.NewTextMessage(llm.RoleAssistant, "Step 20 done."),
+		llm.NewTextMessage(llm.RoleUser, "Continue with step 21"),
+	)
+	if _, err := m.Fit(context.Background(), "system prompt", history); err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the summary to be extended, got %d calls", calls)
+	}
+}
+
+func TestContextManager_DropsWithoutSummarizer(t *testing.T) {
+	history := longConversation(20)
+
+	m := NewContextManager(ContextConfig{MaxTokens: 2000, KeepRecent: 6})
+	fitted, err := m.Fit(context.Background(), "", history)
+	if err != nil {
+		t.Fatalf("Fit failed: %v", err)
+	}
+
+	if len(fitted) >= len(history) {
+		t.Fatalf("Expected messages to be dropped, got %d", len(fitted))
+	}
+	if fitted[0].Content[0].Text != "GOAL: refactor the parser" {
+		t.Error("Expected the goal to be pinned")
+	}
+	if !strings.Contains(fitted[0].Content[1].Text, "earlier messages omitted") {
+		t.Errorf("Expected omission note, got %q", fitted[0].Content[1].Text)
+	}
+	checkToolPairs(t, fitted)
+}
+
+func TestContextManager_SummarizerError(t *testing.T) {
+	summarizer := SummarizerFunc(func(ctx context.Context, previous string, messages []llm.Message) (string, error) {
+		return "", errors.New("model unavailable")
+	})
+
+	m := NewContextManager(ContextConfig{MaxTokens: 3000, Summarizer: summarizer})
+	if _, err := m.Fit(context.Background(), "", longConversation(20)); err == nil {
+		t.Error("Expected summarizer error to be returned")
+	}
+}
+
+func TestProviderSummarizer(t *testing.T) {
+	var req *llm.Request
+	provider := llm.ProviderFunc(func(ctx context.Context, r *llm.Request) (*llm.Response, error) {
+		req = r
+		return &llm.Response{Message: llm.NewTextMessage(llm.RoleAssistant, "the summary")}, nil
+	})
+
+	summary, err := NewProviderSummarizer(provider, "small-model").Summarize(context.Background(), "earlier", longConversation(1))
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if summary != "the summary" {
+		t.Errorf("Expected 'the summary', got '%s'", summary)
+	}
+	if req.Model != "small-model" {
+		t.Errorf("Expected model 'small-model', got '%s'", req.Model)
+	}
+
+	transcript := req.Messages[0].Text()
+	if !strings.Contains(transcript, "Summary so far:\nearlier") {
+		t.Error("Expected the previous summary in the transcript")
+	}
+	if !strings.Contains(transcript, "called tool read") {
+		t.Error("Expected tool calls in the transcript")
+	}
+}
+
+func TestAgent_RunWithContextManager(t *testing.T) {
+	model := &scriptedModel{responses: []*llm.Response{
+		{Message: llm.NewTextMessage(llm.RoleAssistant, "ok"), StopReason: llm.StopEndTurn},
+	}}
+
+	a := New(Config{
+		Provider: model,
+		Context:  NewContextManager(ContextConfig{MaxTokens: 2000, KeepRecent: 4}),
+	})
+	a.SetHistory(longConversation(20))
+
+	if _, err := a.Run(context.Background(), "next"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	sent := model.requests[0].Messages
+	if len(sent) >= len(a.History()) {
+		t.Errorf("Expected a trimmed request, sent %d messages", len(sent))
+	}
+	if sent[0].Content[0].Text != "GOAL: refactor the parser" {
+		t.Error("Expected the goal to be sent first")
+	}
+	if sent[len(sent)-1].Text() != "next" {
+		t.Error("Expected the new input to be sent last")
+	}
+	if len(a.History()) != len(longConversation(20))+2 {
+		t.Error("Expected the full history to be kept")
+	}
+}
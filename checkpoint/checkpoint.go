@@ -0,0 +1,504 @@
+// Package checkpoint saves files before agent tools change them, so any turn
+// of a conversation can be undone.
+//
+// A checkpoint store lives in ~/.agar/checkpoints/<session>/. Each turn
+// records the state of every path a tool changed during it, as it was before
+// the first change: file contents, directories, symlinks, or the fact that
+// the path did not exist. Contents are stored once per SHA-256 under
+// objects/, and the list of turns is kept in checkpoints.json.
+//
+// # Basic Usage
+//
+//	dir, _ := checkpoint.SessionDir("", sess.ID())
+//	store, _ := checkpoint.Open(dir)
+//	registry.Use(store.Middleware())
+//
+//	// Before each user prompt
+//	store.Begin(input)
+//
+//	// Later: put back every file changed in the last turn
+//	turn, restored, err := store.Undo()
+package checkpoint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geoffjay/agar/tools"
+)
+
+// Kind describes what was at a path when it was saved
+type Kind string
+
+const (
+	KindFile    Kind = "file"    // A regular file; its content is in the object store
+	KindDir     Kind = "dir"     // A directory
+	KindSymlink Kind = "symlink" // A symbolic link
+	KindMissing Kind = "missing" // Nothing; restoring removes the path
+)
+
+// File is the saved state of one path
+type File struct {
+	Path   string      `json:"path"`
+	Kind   Kind        `json:"kind"`
+	Tool   string      `json:"tool,omitempty"`   // Tool whose call saved the path
+	Mode   os.FileMode `json:"mode,omitempty"`   // Permission bits of files and directories
+	Object string      `json:"object,omitempty"` // SHA-256 of a file's content
+	Target string      `json:"target,omitempty"` // Target of a symlink
+}
+
+// Turn holds the files changed during one turn of a conversation, as they
+// were before the turn changed them
+type Turn struct {
+	Number int       `json:"number"`
+	Label  string    `json:"label,omitempty"` // Usually the user's prompt
+	Time   time.Time `json:"time"`
+	Files  []File    `json:"files"`
+}
+
+// manifestName is the file in a store directory that lists the turns
+const manifestName = "checkpoints.json"
+
+// manifest is the content of checkpoints.json
+type manifest struct {
+	Turns []Turn `json:"turns"`
+}
+
+// DefaultDir returns the default checkpoint directory, ~/.agar/checkpoints
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".agar", "checkpoints"), nil
+}
+
+// SessionDir returns the checkpoint directory for a session, <dir>/<id>. An
+// empty dir uses DefaultDir.
+func SessionDir(dir, id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid session id: %q", id)
+	}
+
+	if dir == "" {
+		defaultDir, err := DefaultDir()
+		if err != nil {
+			return "", err
+		}
+		dir = defaultDir
+	}
+	return filepath.Join(dir, id), nil
+}
+
+// Store records checkpoints in a directory
+type Store struct {
+	dir       string
+	workspace *tools.Workspace
+	mu        sync.Mutex
+	turns     []Turn // Turns with saved files, oldest first
+	current   *Turn  // The turn in progress; added to turns on its first save
+	next      int    // Number of the next turn
+}
+
+// Open opens the checkpoint store in dir, loading the turns saved there. The
+// directory is created on the first save.
+func Open(dir string) (*Store, error) {
+	s := &Store{dir: dir, next: 1}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	switch {
+	case os.IsNotExist(err):
+		return s, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read checkpoints: %w", err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid checkpoints in %s: %w", dir, err)
+	}
+	s.turns = m.Turns
+	if len(s.turns) > 0 {
+		s.next = s.turns[len(s.turns)-1].Number + 1
+	}
+	return s, nil
+}
+
+// WithWorkspace limits saving to paths inside ws. Calls on other paths are
+// rejected by the workspace-confined tools, so there is nothing to save.
+func (s *Store) WithWorkspace(ws *tools.Workspace) *Store {
+	s.workspace = ws
+	return s
+}
+
+// Dir returns the store directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Begin starts a new turn and returns its number. Files saved from now on
+// belong to it; a turn in which nothing is saved is not recorded.
+func (s *Store) Begin(label string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.begin(label)
+}
+
+// begin starts a new turn; the caller must hold s.mu
+func (s *Store) begin(label string) int {
+	s.current = &Turn{Number: s.next, Label: label}
+	s.next++
+	return s.current.Number
+}
+
+// Turns returns the recorded turns, oldest first
+func (s *Store) Turns() []Turn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	turns := make([]Turn, len(s.turns))
+	for i, turn := range s.turns {
+		turn.Files = append([]File(nil), turn.Files...)
+		turns[i] = turn
+	}
+	return turns
+}
+
+// Save records the current state of paths in the turn in progress, starting
+// one if needed. Paths already saved in this turn keep their earlier state,
+// and directories are saved with everything in them.
+func (s *Store) Save(tool string, paths ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current == nil {
+		s.begin("")
+	}
+
+	saved := make(map[string]bool, len(s.current.Files))
+	for _, file := range s.current.Files {
+		saved[file.Path] = true
+	}
+
+	before := len(s.current.Files)
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("cannot resolve path %s: %w", path, err)
+		}
+		if saved[abs] || !s.workspace.Allows(abs) {
+			continue
+		}
+		if err := s.save(tool, abs, saved); err != nil {
+			s.current.Files = s.current.Files[:before]
+			return err
+		}
+	}
+	if len(s.current.Files) == before {
+		return nil
+	}
+
+	if len(s.turns) == 0 || s.turns[len(s.turns)-1].Number != s.current.Number {
+		s.turns = append(s.turns, Turn{Number: s.current.Number, Label: s.current.Label, Time: time.Now().UTC()})
+	}
+	s.turns[len(s.turns)-1].Files = s.current.Files
+
+	return s.writeManifest()
+}
+
+// save records path and, for a directory, everything under it
+func (s *Store) save(tool, path string, saved map[string]bool) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		saved[path] = true
+		s.current.Files = append(s.current.Files, File{Path: path, Kind: KindMissing, Tool: tool})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", path, err)
+	}
+
+	if !info.IsDir() {
+		return s.saveEntry(tool, path, info, saved)
+	}
+
+	return filepath.WalkDir(path, func(walkPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to save %s: %w", walkPath, err)
+		}
+		if saved[walkPath] || (walkPath != path && !s.workspace.Allows(walkPath)) {
+			if entry.IsDir() && walkPath != path {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("failed to save %s: %w", walkPath, err)
+		}
+		return s.saveEntry(tool, walkPath, info, saved)
+	})
+}
+
+// saveEntry records a single file, directory or symlink. Other kinds of
+// files, such as sockets, are skipped.
+func (s *Store) saveEntry(tool, path string, info os.FileInfo, saved map[string]bool) error {
+	file := File{Path: path, Tool: tool, Mode: info.Mode().Perm()}
+
+	switch {
+	case info.IsDir():
+		file.Kind = KindDir
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return fmt.Errorf("failed to save %s: %w", path, err)
+		}
+		file.Kind, file.Mode, file.Target = KindSymlink, 0, target
+	case info.Mode().IsRegular():
+		object, err := s.storeObject(path)
+		if err != nil {
+			return fmt.Errorf("failed to save %s: %w", path, err)
+		}
+		file.Kind, file.Object = KindFile, object
+	default:
+		return nil
+	}
+
+	saved[path] = true
+	s.current.Files = append(s.current.Files, file)
+	return nil
+}
+
+// storeObject copies a file's content into the object store and returns its
+// hash. Content that is already stored is not written again.
+func (s *Store) storeObject(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	object := hex.EncodeToString(sum[:])
+
+	objectPath := s.objectPath(object)
+	if _, err := os.Stat(objectPath); err == nil {
+		return object, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(objectPath), 0700); err != nil {
+		return "", err
+	}
+	if err := writeFile(objectPath, data, 0600); err != nil {
+		return "", err
+	}
+	return object, nil
+}
+
+// objectPath returns where the content with the given hash is stored
+func (s *Store) objectPath(object string) string {
+	return filepath.Join(s.dir, "objects", object)
+}
+
+// Restore puts back the files changed in the given turn and every later one,
+// returning the tree to its state before that turn. The restored turns are
+// removed from the store. It returns the restored paths, sorted.
+func (s *Store) Restore(number int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.restore(number)
+}
+
+// restore implements Restore; the caller must hold s.mu
+func (s *Store) restore(number int) ([]string, error) {
+	index := -1
+	for i, turn := range s.turns {
+		if turn.Number == number {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("no checkpoint for turn %d", number)
+	}
+
+	// Undo the newest changes first, so each path ends in its oldest state
+	restored := map[string]bool{}
+	for i := len(s.turns) - 1; i >= index; i-- {
+		files := s.turns[i].Files
+		for j := len(files) - 1; j >= 0; j-- {
+			if err := s.restoreFile(files[j]); err != nil {
+				return nil, fmt.Errorf("failed to restore %s: %w", files[j].Path, err)
+			}
+			restored[files[j].Path] = true
+		}
+	}
+
+	s.turns = s.turns[:index]
+	s.current = nil
+	s.next = number
+	if err := s.writeManifest(); err != nil {
+		return nil, err
+	}
+	s.pruneObjects()
+
+	paths := make([]string, 0, len(restored))
+	for path := range restored {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Undo restores the files changed in the most recent turn that changed any,
+// returning its number and the restored paths
+func (s *Store) Undo() (int, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.turns) == 0 {
+		return 0, nil, fmt.Errorf("nothing to undo")
+	}
+	number := s.turns[len(s.turns)-1].Number
+	paths, err := s.restore(number)
+	return number, paths, err
+}
+
+// restoreFile puts one saved path back in place
+func (s *Store) restoreFile(file File) error {
+	current, err := os.Lstat(file.Path)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	switch file.Kind {
+	case KindMissing:
+		return os.RemoveAll(file.Path)
+
+	case KindDir:
+		if exists && !current.IsDir() {
+			if err := os.Remove(file.Path); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(file.Path, 0755); err != nil {
+			return err
+		}
+		return os.Chmod(file.Path, file.Mode)
+
+	case KindSymlink:
+		if exists {
+			if err := os.RemoveAll(file.Path); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return err
+		}
+		return os.Symlink(file.Target, file.Path)
+
+	case KindFile:
+		data, err := os.ReadFile(s.objectPath(file.Object))
+		if err != nil {
+			return fmt.Errorf("saved content is missing: %w", err)
+		}
+		if exists && !current.Mode().IsRegular() {
+			if err := os.RemoveAll(file.Path); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return err
+		}
+		return writeFile(file.Path, data, file.Mode)
+	}
+
+	return fmt.Errorf("unknown checkpoint kind %q", file.Kind)
+}
+
+// writeManifest saves the list of turns; the caller must hold s.mu
+func (s *Store) writeManifest() error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(manifest{Turns: s.turns}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoints: %w", err)
+	}
+	if err := writeFile(filepath.Join(s.dir, manifestName), data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoints: %w", err)
+	}
+	return nil
+}
+
+// pruneObjects removes stored content no remaining turn refers to; the
+// caller must hold s.mu
+func (s *Store) pruneObjects() {
+	used := map[string]bool{}
+	for _, turn := range s.turns {
+		for _, file := range turn.Files {
+			used[file.Object] = true
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(s.dir, "objects"))
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !used[entry.Name()] {
+			os.Remove(s.objectPath(entry.Name()))
+		}
+	}
+}
+
+// Middleware saves the paths a call may change, as reported by
+// tools.ChangedPaths, before running it. A call whose files cannot be saved
+// is not run.
+func (s *Store) Middleware() tools.Middleware {
+	return func(next tools.Executor) tools.Executor {
+		return func(ctx context.Context, tool tools.Tool, params json.RawMessage) *tools.ToolResult {
+			if paths := tools.ChangedPaths(tool, params); len(paths) > 0 {
+				if err := s.Save(tool.Name(), paths...); err != nil {
+					return &tools.ToolResult{Success: false, Error: fmt.Sprintf("tool %s was not run: %v", tool.Name(), err)}
+				}
+			}
+			return next(ctx, tool, params)
+		}
+	}
+}
+
+// writeFile writes data through a temporary file in the same directory, so
+// the path never holds partial content
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/geoffjay/agar/tools"
+)
+
+// newTestStore creates a store and a directory of files to change
+func newTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+
+	base := t.TempDir()
+	root := filepath.Join(base, "project")
+	writeFiles(t, root, map[string]string{
+		"main.go":         "package main\n",
+		"docs/readme.md":  "# Readme\n",
+		"docs/guide/a.md": "guide\n",
+	})
+
+	store, err := Open(filepath.Join(base, "checkpoints", "session"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	return store, root
+}
+
+// writeFiles creates files under dir with the given contents
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFile returns the content of a file, or "<missing>" if it does not exist
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "<missing>"
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSessionDir(t *testing.T) {
+	dir, err := SessionDir("/tmp/checkpoints", "20250102-150405-a1b2c3")
+	if err != nil {
+		t.Fatalf("SessionDir failed: %v", err)
+	}
+	if dir != "/tmp/checkpoints/20250102-150405-a1b2c3" {
+		t.Errorf("Expected session directory under /tmp/checkpoints, got %s", dir)
+	}
+
+	for _, id := range []string{"", "../escape", "a/b", ".hidden"} {
+		if _, err := SessionDir("/tmp/checkpoints", id); err == nil {
+			t.Errorf("Expected error for session id %q", id)
+		}
+	}
+}
+
+func TestStore_UndoModifiedAndCreatedFiles(t *testing.T) {
+	store, root := newTestStore(t)
+	main := filepath.Join(root, "main.go")
+	created := filepath.Join(root, "new", "file.go")
+
+	store.Begin("change main")
+	if err := store.Save("write", main, created); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	os.WriteFile(main, []byte("package changed\n"), 0644)
+	writeFiles(t, root, map[string]string{"new/file.go": "package new\n"})
+
+	turn, restored, err := store.Undo()
+	if err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if turn != 1 {
+		t.Errorf("Expected turn 1 to be undone, got %d", turn)
+	}
+	if want := []string{main, created}; !reflect.DeepEqual(restored, want) {
+		t.Errorf("Expected restored paths %v, got %v", want, restored)
+	}
+	if got := readFile(t, main); got != "package main\n" {
+		t.Errorf("Expected main.go to be restored, got %q", got)
+	}
+	if got := readFile(t, created); got != "<missing>" {
+		t.Errorf("Expected the created file to be removed, got %q", got)
+	}
+	if len(store.Turns()) != 0 {
+		t.Errorf("Expected no turns after undo, got %d", len(store.Turns()))
+	}
+	if _, _, err := store.Undo(); err == nil || !strings.Contains(err.Error(), "nothing to undo") {
+		t.Errorf("Expected nothing to undo, got %v", err)
+	}
+}
+
+func TestStore_RestoreDeletedDirectory(t *testing.T) {
+	store, root := newTestStore(t)
+	docs := filepath.Join(root, "docs")
+	os.Chmod(filepath.Join(docs, "guide"), 0700)
+
+	store.Begin("remove docs")
+	if err := store.Save("delete", docs); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := os.RemoveAll(docs); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := store.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if got := readFile(t, filepath.Join(docs, "readme.md")); got != "# Readme\n" {
+		t.Errorf("Expected readme.md to be restored, got %q", got)
+	}
+	if got := readFile(t, filepath.Join(docs, "guide", "a.md")); got != "guide\n" {
+		t.Errorf("Expected guide/a.md to be restored, got %q", got)
+	}
+	info, err := os.Stat(filepath.Join(docs, "guide"))
+	if err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("Expected guide to be restored with mode 0700, got %v, %v", info, err)
+	}
+}
+
+func TestStore_RestoreToEarlierTurn(t *testing.T) {
+	store, root := newTestStore(t)
+	main := filepath.Join(root, "main.go")
+
+	for i, content := range []string{"one\n", "two\n", "three\n"} {
+		store.Begin("edit")
+		if err := store.Save("edit", main); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		// Only the first save of a path in a turn is kept
+		os.WriteFile(main, []byte("partial\n"), 0644)
+		store.Save("edit", main)
+		os.WriteFile(main, []byte(content), 0644)
+
+		if turns := store.Turns(); len(turns) != i+1 || len(turns[i].Files) != 1 {
+			t.Fatalf("Expected %d turns with one file each, got %+v", i+1, turns)
+		}
+	}
+
+	if _, err := store.Restore(5); err == nil {
+		t.Error("Expected error for an unknown turn")
+	}
+
+	if _, err := store.Restore(2); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got := readFile(t, main); got != "one\n" {
+		t.Errorf("Expected the content from before turn 2, got %q", got)
+	}
+	if turns := store.Turns(); len(turns) != 1 || turns[0].Number != 1 {
+		t.Errorf("Expected only turn 1 to remain, got %+v", turns)
+	}
+
+	// Numbering continues from the restored turn
+	if n := store.Begin("again"); n != 2 {
+		t.Errorf("Expected the next turn to be 2, got %d", n)
+	}
+}
+
+func TestStore_PersistsAcrossOpen(t *testing.T) {
+	store, root := newTestStore(t)
+	main := filepath.Join(root, "main.go")
+
+	store.Begin("edit main")
+	if err := store.Save("edit", main); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	os.WriteFile(main, []byte("changed\n"), 0644)
+
+	reopened, err := Open(store.Dir())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	turns := reopened.Turns()
+	if len(turns) != 1 || turns[0].Label != "edit main" || turns[0].Files[0].Tool != "edit" {
+		t.Fatalf("Expected the saved turn to be loaded, got %+v", turns)
+	}
+	if _, _, err := reopened.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if got := readFile(t, main); got != "package main\n" {
+		t.Errorf("Expected main.go to be restored, got %q", got)
+	}
+
+	objects, _ := os.ReadDir(filepath.Join(store.Dir(), "objects"))
+	if len(objects) != 0 {
+		t.Errorf("Expected unused objects to be removed, got %d", len(objects))
+	}
+}
+
+func TestStore_WithWorkspace(t *testing.T) {
+	store, root := newTestStore(t)
+	outside := t.TempDir()
+	ws, err := tools.NewWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.WithWorkspace(ws)
+
+	if err := store.Save("write", filepath.Join(outside, "secret.txt")); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if len(store.Turns()) != 0 {
+		t.Error("Expected paths outside the workspace not to be saved")
+	}
+}
+
+func TestStore_Middleware(t *testing.T) {
+	store, root := newTestStore(t)
+	main := filepath.Join(root, "main.go")
+	docs := filepath.Join(root, "docs")
+
+	registry := tools.NewToolRegistry()
+	registry.Register(tools.NewWriteTool())
+	registry.Register(tools.NewDeleteTool())
+	registry.Register(tools.NewReadTool())
+	registry.Use(store.Middleware())
+	ctx := context.Background()
+
+	store.Begin("rewrite and clean up")
+	calls := []struct {
+		tool   string
+		params map[string]interface{}
+	}{
+		{"read", map[string]interface{}{"path": main}},
+		{"write", map[string]interface{}{"path": main, "content": "package rewritten\n"}},
+		{"delete", map[string]interface{}{"path": docs, "recursive": true}},
+	}
+	for _, call := range calls {
+		params, _ := json.Marshal(call.params)
+		if result := registry.Execute(ctx, call.tool, params); !result.Success {
+			t.Fatalf("%s failed: %s", call.tool, result.Error)
+		}
+	}
+
+	turns := store.Turns()
+	if len(turns) != 1 {
+		t.Fatalf("Expected one turn, got %d", len(turns))
+	}
+	for _, file := range turns[0].Files {
+		if file.Tool == "read" {
+			t.Errorf("Expected read-only calls not to be saved, got %+v", file)
+		}
+	}
+
+	if _, _, err := store.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if got := readFile(t, main); got != "package main\n" {
+		t.Errorf("Expected main.go to be restored, got %q", got)
+	}
+	if got := readFile(t, filepath.Join(docs, "guide", "a.md")); got != "guide\n" {
+		t.Errorf("Expected the deleted directory to be restored, got %q", got)
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// resumeID is the session to resume, set by --resume
+var resumeID string
+
 var rootCmd = &cobra.Command{
 	Use:   "agar",
 	Short: "A framework for building AI agent applications",
@@ -15,10 +18,11 @@ var rootCmd = &cobra.Command{
 with TUI components and tool management.
 
 Running 'agar' without arguments launches the interactive TUI interface.
-Use subcommands for specific operations.`,
+Use --resume <id> to continue a saved session, and subcommands for
+specific operations.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Launch TUI when no subcommand is specified
-		if err := app.RunTUI(); err != nil {
+		if err := app.RunTUI(app.TUIOptions{ResumeID: resumeID}); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -38,6 +42,8 @@ func init() {
 	// Global flags can be added here
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.agar.yaml)")
 
+	rootCmd.Flags().StringVar(&resumeID, "resume", "", "resume the session with the given id (see ~/.agar/sessions)")
+
 	// Add subcommands
 	// Note: Subcommands will be added from internal/commands package
 }
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"github.com/geoffjay/agar/tools"
+)
+
+// trashMaxSize is the size beyond which the oldest deleted files are removed
+// from the trash for good
+const trashMaxSize = 1 << 30
+
+// httpCacheMaxSize is the size beyond which the least recently used
+// responses are removed from the fetch cache
+const httpCacheMaxSize = 100 << 20
+
+// systemPrompt is the system prompt of the interactive assistant
+const systemPrompt = `You are an AI assistant integrated into the Agar CLI application, working in the user's current directory.
+
+Agar is a Go framework for building AI agent applications with:
+- Tools Framework: built-in tools for files, search, web access and shell commands
+- TUI Components: Terminal UI with Bubble Tea (Application, Panel, Footer, Layout, Input components)
+- Command System: Slash commands for interactive operations
+- Tool Registry: Thread-safe tool management with middleware
+
+Your role is to:
+1. Help users understand and use Agar framework features
+2. Assist with Go programming and best practices
+3. Provide guidance on building AI agent applications
+4. Read, search and change files in the project with the tools available to you
+5. Help debug issues and suggest solutions
+
+Available slash commands users can type:
+- /help - Show available commands
+- /tools - List all available tools
+- /init <name> - Create a new Agar project
+- /resume [id] - Resume a previous session
+- /undo - Undo the file changes of the last turn
+- /checkpoints [turn] - List file checkpoints, or restore files to before a turn
+- /exit - Exit the application
+- /clear - Clear the screen
+
+Provide a helpful, concise response. If you suggest code, use Go syntax.
+If the user's question could be solved with a slash command, mention it.
+Be friendly and encouraging.`
+
+// newToolRegistry creates the registry of built-in tools offered to the agent
+func newToolRegistry() *tools.ToolRegistry {
+	registry := tools.NewToolRegistry()
+	registry.Register(tools.NewReadTool())
+	registry.Register(tools.NewWriteTool())
+	registry.Register(tools.NewEditTool())
+	registry.Register(tools.NewPatchTool())
+	registry.Register(tools.NewBatchEditTool())
+
+	// Deletes go to ~/.agar/trash so they can be restored
+	if trash, err := tools.NewTrash(""); err == nil {
+		trash.WithMaxSize(trashMaxSize)
+		registry.Register(tools.NewDeleteTool().WithTrash(trash))
+		registry.Register(tools.NewTrashListTool(trash))
+		registry.Register(tools.NewTrashRestoreTool(trash))
+		registry.Register(tools.NewTrashEmptyTool(trash))
+	} else {
+		registry.Register(tools.NewDeleteTool())
+	}
+	registry.Register(tools.NewMoveTool())
+	registry.Register(tools.NewCopyTool())
+	registry.Register(tools.NewMkdirTool())
+
+	registry.Register(tools.NewListTool())
+	registry.Register(tools.NewGlobTool())
+
+	// Fetches are cached in ~/.agar/cache/http so repeated lookups stay local
+	if cache, err := tools.NewHTTPCache(""); err == nil {
+		registry.Register(tools.NewFetchTool().WithCache(cache.WithMaxSize(httpCacheMaxSize)))
+	} else {
+		registry.Register(tools.NewFetchTool())
+	}
+	registry.Register(tools.NewDownloadTool())
+	registry.Register(tools.NewSearchTool())
+	registry.Register(tools.NewGrepTool())
+	registry.Register(tools.NewShellTool())
+	registry.Register(tools.NewTaskListTool())
+
+	return registry
+}
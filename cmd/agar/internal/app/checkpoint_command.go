@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/geoffjay/agar/checkpoint"
+	"github.com/geoffjay/agar/commands"
+)
+
+// maxListedPaths is the number of restored paths shown after an undo
+const maxListedPaths = 10
+
+// UndoCommand restores the files changed in the most recent turn
+type UndoCommand struct {
+	conv *conversation
+}
+
+func NewUndoCommand(conv *conversation) *UndoCommand {
+	return &UndoCommand{conv: conv}
+}
+
+func (c *UndoCommand) Name() string {
+	return "undo"
+}
+
+func (c *UndoCommand) Description() string {
+	return "Undo the file changes made in the last turn"
+}
+
+func (c *UndoCommand) Usage() string {
+	return "/undo"
+}
+
+func (c *UndoCommand) Aliases() []string {
+	return []string{}
+}
+
+func (c *UndoCommand) Execute(ctx context.Context, args []string, state commands.ApplicationState) error {
+	store := checkpointsReady(c.conv, state)
+	if store == nil {
+		return nil
+	}
+
+	turn, restored, err := store.Undo()
+	if err != nil {
+		state.AddLine(fmt.Sprintf("Error: %v", err))
+		return nil
+	}
+
+	renderRestored(state, turn, restored)
+	return nil
+}
+
+// CheckpointsCommand lists the turns that changed files and restores the
+// tree to the state before any of them
+type CheckpointsCommand struct {
+	conv *conversation
+}
+
+func NewCheckpointsCommand(conv *conversation) *CheckpointsCommand {
+	return &CheckpointsCommand{conv: conv}
+}
+
+func (c *CheckpointsCommand) Name() string {
+	return "checkpoints"
+}
+
+func (c *CheckpointsCommand) Description() string {
+	return "List file checkpoints, or restore files to before a turn"
+}
+
+func (c *CheckpointsCommand) Usage() string {
+	return "/checkpoints [turn]"
+}
+
+func (c *CheckpointsCommand) Aliases() []string {
+	return []string{}
+}
+
+func (c *CheckpointsCommand) Execute(ctx context.Context, args []string, state commands.ApplicationState) error {
+	if len(args) == 0 {
+		return c.list(state)
+	}
+
+	turn, err := strconv.Atoi(args[0])
+	if err != nil {
+		state.AddLine(fmt.Sprintf("Error: invalid turn %q", args[0]))
+		return nil
+	}
+
+	store := checkpointsReady(c.conv, state)
+	if store == nil {
+		return nil
+	}
+
+	restored, err := store.Restore(turn)
+	if err != nil {
+		state.AddLine(fmt.Sprintf("Error: %v", err))
+		return nil
+	}
+
+	renderRestored(state, turn, restored)
+	return nil
+}
+
+// list shows the turns that changed files, oldest first
+func (c *CheckpointsCommand) list(state commands.ApplicationState) error {
+	store := c.conv.checkpointStore()
+	if store == nil || len(store.Turns()) == 0 {
+		state.AddLine("No checkpoints in this session")
+		return nil
+	}
+
+	state.AddLine("Checkpoints:")
+	for _, turn := range store.Turns() {
+		label := strings.Join(strings.Fields(turn.Label), " ")
+		if len(label) > 50 {
+			label = label[:47] + "..."
+		}
+		state.AddLine(fmt.Sprintf("  %3d  %s  %d path(s)  %s", turn.Number, turn.Time.Local().Format("15:04:05"), len(turn.Files), label))
+	}
+	state.AddLine("")
+	state.AddLine("Usage: /checkpoints <turn> restores files to before that turn")
+
+	return nil
+}
+
+// checkpointsReady returns the checkpoint store if files can be restored now,
+// or nil after explaining why not in the transcript
+func checkpointsReady(conv *conversation, state commands.ApplicationState) *checkpoint.Store {
+	store := conv.checkpointStore()
+	if store == nil {
+		state.AddLine("No checkpoints in this session")
+		return nil
+	}
+
+	// Don't restore files while tools may still be changing them
+	if turns, ok := state.(interface{ TurnActive() bool }); ok && turns.TurnActive() {
+		state.AddLine("Error: Wait for the current response or press Esc to cancel it first")
+		return nil
+	}
+	return store
+}
+
+// renderRestored reports the paths put back by an undo
+func renderRestored(state commands.ApplicationState, turn int, restored []string) {
+	state.AddLine(fmt.Sprintf("✓ Restored %d path(s) to before turn %d", len(restored), turn))
+	for i, path := range restored {
+		if i == maxListedPaths {
+			state.AddLine(fmt.Sprintf("  ... and %d more", len(restored)-i))
+			break
+		}
+		state.AddLine("  " + path)
+	}
+	state.AddLine("")
+}
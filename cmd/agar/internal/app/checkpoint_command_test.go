@@ -0,0 +1,56 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geoffjay/agar/llm"
+	"github.com/geoffjay/agar/tools"
+)
+
+func TestUndoCommand_RestoresAgentWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	p := newTestProgram(t, scriptedProvider(
+		toolCallMessage(t, "call_1", "write", tools.WriteParams{Path: path, Content: "changed"}),
+		llm.NewTextMessage(llm.RoleAssistant, "Updated the notes"),
+	))
+
+	p.submit("Update the notes")
+	p.waitFor("the approval prompt", p.approvalPending)
+	p.press("y")
+	p.waitFor("the agent to finish", p.idle)
+
+	if got := readFile(t, path); got != "changed" {
+		t.Fatalf("Expected the agent to write the file, got %q", got)
+	}
+
+	p.submit("/checkpoints")
+	if !strings.Contains(p.transcript(), "Update the notes") {
+		t.Errorf("Expected the turn to be listed, got:\n%s", p.transcript())
+	}
+
+	p.submit("/undo")
+
+	if got := readFile(t, path); got != "original" {
+		t.Errorf("Expected /undo to restore the file, got %q", got)
+	}
+	if !strings.Contains(p.transcript(), "Restored 1 path(s) to before turn 1") {
+		t.Errorf("Expected the restore to be reported, got:\n%s", p.transcript())
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"os"
+
+	"github.com/geoffjay/agar/agent"
+	"github.com/geoffjay/agar/llm"
+)
+
+// historyTokenBudget is the token budget for conversation history sent with
+// each prompt, leaving room for the system prompt and the reply
+const historyTokenBudget = 32000
+
+// summaryModel is the model used to summarize older turns
+const summaryModel = "claude-3-5-haiku-20241022"
+
+// newContextManager creates the context manager used to fit the conversation
+// history into the prompt. Older turns are summarized when an Anthropic API
+// key is available and dropped otherwise.
+func newContextManager() *agent.ContextManager {
+	config := agent.ContextConfig{
+		MaxTokens:  historyTokenBudget,
+		KeepRecent: 10,
+	}
+
+	if os.Getenv("ANTHROPIC_API_KEY") != "" {
+		provider := llm.NewAnthropicProvider(llm.AnthropicConfig{Model: summaryModel})
+		config.Summarizer = agent.NewProviderSummarizer(provider, "")
+	}
+
+	return agent.NewContextManager(config)
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/geoffjay/agar/commands"
+	"github.com/geoffjay/agar/session"
+)
+
+// maxListedSessions is the number of recent sessions shown by /resume
+const maxListedSessions = 10
+
+// ResumeCommand reloads a stored session into the transcript and model context
+type ResumeCommand struct {
+	conv *conversation
+}
+
+func NewResumeCommand(conv *conversation) *ResumeCommand {
+	return &ResumeCommand{conv: conv}
+}
+
+func (c *ResumeCommand) Name() string {
+	return "resume"
+}
+
+func (c *ResumeCommand) Description() string {
+	return "Resume a previous conversation session"
+}
+
+func (c *ResumeCommand) Usage() string {
+	return "/resume [session-id]"
+}
+
+func (c *ResumeCommand) Aliases() []string {
+	return []string{}
+}
+
+func (c *ResumeCommand) Execute(ctx context.Context, args []string, state commands.ApplicationState) error {
+	if c.conv.store == nil {
+		state.AddLine("Error: Session store is not available")
+		return nil
+	}
+
+	if len(args) == 0 {
+		return c.list(state)
+	}
+
+	// Don't swap the history out from under an in-flight agent call
+	if turns, ok := state.(interface{ TurnActive() bool }); ok && turns.TurnActive() {
+		state.AddLine("Error: Wait for the current response or press Esc to cancel it first")
+		return nil
+	}
+
+	entries, err := c.conv.resume(args[0])
+	if err != nil {
+		state.AddLine(fmt.Sprintf("Error: %v", err))
+		return nil
+	}
+
+	state.Clear()
+	renderTranscript(state, entries)
+	state.AddLine(fmt.Sprintf("✓ Resumed session %s (%d messages)", args[0], c.conv.messageCount()))
+	state.AddLine("")
+
+	return nil
+}
+
+// list shows the most recent sessions
+func (c *ResumeCommand) list(state commands.ApplicationState) error {
+	sessions, err := c.conv.store.List()
+	if err != nil {
+		return err
+	}
+
+	if len(sessions) == 0 {
+		state.AddLine("No saved sessions")
+		return nil
+	}
+
+	state.AddLine("Recent sessions:")
+	for i, info := range sessions {
+		if i == maxListedSessions {
+			state.AddLine(fmt.Sprintf("  ... and %d more in %s", len(sessions)-i, c.conv.store.Dir()))
+			break
+		}
+
+		title := ""
+		if entries, err := c.conv.store.Load(info.ID); err == nil {
+			title = session.Title(entries, 50)
+		}
+
+		current := ""
+		if c.conv.sessionID() == info.ID {
+			current = " (current)"
+		}
+
+		state.AddLine(fmt.Sprintf("  %s  %s  %s%s", info.ID, info.Modified.Format("2006-01-02 15:04"), title, current))
+	}
+	state.AddLine("")
+	state.AddLine("Usage: /resume <session-id>")
+
+	return nil
+}
@@ -0,0 +1,250 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/geoffjay/agar/agent"
+	"github.com/geoffjay/agar/checkpoint"
+	"github.com/geoffjay/agar/commands"
+	"github.com/geoffjay/agar/llm"
+	"github.com/geoffjay/agar/session"
+	"github.com/geoffjay/agar/tools"
+)
+
+// conversation holds the agent and the session it is recorded to. It is
+// shared by pointer between the TUI model and the /resume, /undo and
+// /checkpoints commands.
+type conversation struct {
+	agent   *agent.Agent
+	store   *session.Store
+	runMu   sync.Mutex        // Serializes runs, so a cancelled run winds down before the next starts
+	onEvent func(agent.Event) // Receives the events of the current run
+
+	// The agent goroutine records to the session and saves checkpoints from
+	// tools while commands may replace them, so they are guarded by mu
+	mu          sync.Mutex
+	session     *session.Session  // Created on the first message
+	checkpoints *checkpoint.Store // Opened with the session
+}
+
+// newConversation creates an empty conversation running tools from registry,
+// recorded to the default session store. Sessions are disabled if the store
+// cannot be located.
+func newConversation(provider llm.Provider, registry *tools.ToolRegistry) *conversation {
+	store, _ := session.NewStore("")
+
+	c := &conversation{store: store}
+	c.agent = agent.New(agent.Config{
+		Provider:     provider,
+		Tools:        registry,
+		SystemPrompt: systemPrompt,
+		Context:      newContextManager(),
+		OnEvent:      c.handleEvent,
+	})
+	return c
+}
+
+// begin prepares for a prompt: the session is created on the first one, and
+// each prompt starts a turn that /undo can take back
+func (c *conversation) begin(input string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.store == nil {
+		return nil
+	}
+
+	if c.session == nil {
+		sess, err := c.store.Create()
+		if err != nil {
+			c.store = nil // Don't retry on every message
+			return err
+		}
+		c.session = sess
+		c.openCheckpoints()
+	}
+
+	if c.checkpoints != nil {
+		c.checkpoints.Begin(input)
+	}
+	return nil
+}
+
+// run sends input to the agent, passing the events of the run to onEvent.
+// It blocks until the agent finishes or ctx is cancelled.
+func (c *conversation) run(ctx context.Context, input string, onEvent func(agent.Event)) (*agent.Result, error) {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	c.onEvent = onEvent
+	defer func() { c.onEvent = nil }()
+
+	return c.agent.Run(ctx, input)
+}
+
+// handleEvent records an agent event to the session and passes it on
+func (c *conversation) handleEvent(e agent.Event) {
+	c.mu.Lock()
+	sess := c.session
+	c.mu.Unlock()
+
+	if sess != nil {
+		// A failed write loses only this entry; the conversation goes on
+		_ = sess.RecordEvent(e)
+	}
+
+	if c.onEvent != nil {
+		c.onEvent(e)
+	}
+}
+
+// messageCount returns the number of messages in the agent history
+func (c *conversation) messageCount() int {
+	return len(c.agent.History())
+}
+
+// resume loads a stored session, replacing the agent history, and continues
+// recording to it
+func (c *conversation) resume(id string) ([]session.Entry, error) {
+	if c.store == nil {
+		return nil, fmt.Errorf("session store is not available")
+	}
+
+	entries, err := c.store.Load(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := c.store.Open(id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.close()
+
+	c.mu.Lock()
+	c.session = sess
+	c.openCheckpoints()
+	c.mu.Unlock()
+
+	c.agent.SetHistory(session.Messages(entries))
+
+	return entries, nil
+}
+
+// openCheckpoints opens the checkpoint store of the current session.
+// Checkpoints are disabled if it cannot be opened. The caller holds mu.
+func (c *conversation) openCheckpoints() {
+	c.checkpoints = nil
+
+	dir, err := checkpoint.SessionDir("", c.session.ID())
+	if err != nil {
+		return
+	}
+	if store, err := checkpoint.Open(dir); err == nil {
+		c.checkpoints = store
+	}
+}
+
+// checkpointStore returns the checkpoint store of the current session, or
+// nil if there is none
+func (c *conversation) checkpointStore() *checkpoint.Store {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checkpoints
+}
+
+// sessionID returns the ID of the current session, or "" if there is none
+func (c *conversation) sessionID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return ""
+	}
+	return c.session.ID()
+}
+
+// checkpointMiddleware saves files to the current session's checkpoint store
+// before tools change them
+func (c *conversation) checkpointMiddleware() tools.Middleware {
+	return func(next tools.Executor) tools.Executor {
+		return func(ctx context.Context, tool tools.Tool, params json.RawMessage) *tools.ToolResult {
+			store := c.checkpointStore()
+			if store == nil {
+				return next(ctx, tool, params)
+			}
+			return store.Middleware()(next)(ctx, tool, params)
+		}
+	}
+}
+
+// close closes the session file
+func (c *conversation) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session != nil {
+		c.session.Close()
+		c.session = nil
+	}
+}
+
+// renderUserInput adds a user prompt to the transcript
+func renderUserInput(state commands.ApplicationState, input string) {
+	state.AddLine("")
+	state.AddLine(lipgloss.NewStyle().Foreground(lipgloss.Color("86")).Render("> " + input))
+	state.AddLine("")
+}
+
+// renderToolCall adds a tool call, with the paths it names, to the transcript
+func renderToolCall(state commands.ApplicationState, call *llm.ContentBlock) {
+	line := "● " + call.Name
+	if paths := tools.ParamPaths(call.Input); len(paths) > 0 {
+		line += " " + strings.Join(paths, ", ")
+	}
+	state.AddLine(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render(line))
+}
+
+// renderToolResult adds the error of a failed tool call to the transcript
+func renderToolResult(state commands.ApplicationState, result *tools.ToolResult) {
+	if result == nil || result.Success {
+		return
+	}
+	state.AddLine(lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("  ✗ " + result.Error))
+}
+
+// renderTranscript replays session entries into the transcript the same way
+// they were shown live
+func renderTranscript(state commands.ApplicationState, entries []session.Entry) {
+	for _, entry := range entries {
+		switch entry.Type {
+		case session.EntryToolCall:
+			if entry.ToolCall != nil {
+				renderToolCall(state, entry.ToolCall)
+			}
+			continue
+		case session.EntryToolResult:
+			renderToolResult(state, entry.Result)
+			continue
+		}
+
+		// Messages holding only tool calls or results have no text to show
+		if entry.Type != session.EntryMessage || entry.Message == nil || entry.Message.Text() == "" {
+			continue
+		}
+
+		switch entry.Message.Role {
+		case llm.RoleUser:
+			renderUserInput(state, entry.Message.Text())
+		case llm.RoleAssistant:
+			state.AddLine(entry.Message.Text())
+			state.AddLine("")
+		}
+	}
+}
@@ -8,8 +8,8 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/geoffjay/agar/cmd/agar/baml_client"
-	"github.com/geoffjay/agar/cmd/agar/baml_client/types"
+	"github.com/geoffjay/agar/agent"
+	"github.com/geoffjay/agar/llm"
 	"github.com/geoffjay/agar/tools"
 	"github.com/geoffjay/agar/tui"
 )
@@ -20,37 +20,76 @@ type cliModel struct {
 	prompt            tui.PromptModel
 	width             int
 	height            int
-	conversationHist  []types.ConversationMessage
+	conv              *conversation
+	send              func(tea.Msg) // Delivers agent progress to the running program
 	waitingForAgent   bool
+	turn              int    // Incremented for every agent call so stale replies can be ignored
+	streamText        string // Text streamed so far for the current model call
 }
 
-// agentResponseMsg contains the response from the AI agent
+// TUIOptions holds options for launching the TUI
+type TUIOptions struct {
+	ResumeID string // Session to resume, if any
+}
+
+// agentResponseMsg contains the final response from the AI agent
 type agentResponseMsg struct {
-	response    string
-	suggestions []string
-	err         error
+	turn     int
+	response string
+	err      error
+}
+
+// agentEventMsg reports the progress of an agent call
+type agentEventMsg struct {
+	turn  int
+	event agent.Event
 }
 
 // RunTUI launches the TUI application
-func RunTUI() error {
+func RunTUI(options TUIOptions) error {
 	cwd, err := os.Getwd()
 	if err != nil {
 		cwd = "/"
 	}
 
+	// Agent calls report progress through the program
+	var p *tea.Program
+	send := func(msg tea.Msg) { p.Send(msg) }
+
+	model := newCLIModel(cwd, llm.NewAnthropicProvider(llm.AnthropicConfig{}), send)
+	conv := model.conv
+	defer conv.close()
+
+	// Reload a previous session into the transcript and model context
+	if options.ResumeID != "" {
+		entries, err := conv.resume(options.ResumeID)
+		if err != nil {
+			return fmt.Errorf("failed to resume session: %w", err)
+		}
+		renderTranscript(model.app, entries)
+		model.app.AddLine(fmt.Sprintf("✓ Resumed session %s (%d messages)", options.ResumeID, conv.messageCount()))
+		model.app.AddLine("")
+	}
+
+	// Run the application
+	p = tea.NewProgram(model, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("failed to run TUI: %w", err)
+	}
+
+	if id := conv.sessionID(); id != "" {
+		fmt.Printf("Resume this session with: agar --resume %s\n", id)
+	}
+
+	return nil
+}
+
+// newCLIModel creates the application, its tools and commands, and the
+// conversation running prompts through the agent with provider. Agent
+// progress is delivered to the model through send.
+func newCLIModel(cwd string, provider llm.Provider, send func(tea.Msg)) cliModel {
 	// Create tool registry and register built-in tools
-	toolRegistry := tools.NewToolRegistry()
-	toolRegistry.Register(tools.NewReadTool())
-	toolRegistry.Register(tools.NewWriteTool())
-	toolRegistry.Register(tools.NewDeleteTool())
-	toolRegistry.Register(tools.NewListTool())
-	toolRegistry.Register(tools.NewGlobTool())
-	toolRegistry.Register(tools.NewFetchTool())
-	toolRegistry.Register(tools.NewDownloadTool())
-	toolRegistry.Register(tools.NewSearchTool())
-	toolRegistry.Register(tools.NewGrepTool())
-	toolRegistry.Register(tools.NewShellTool())
-	toolRegistry.Register(tools.NewTaskListTool())
+	toolRegistry := newToolRegistry()
 
 	// Create TUI application
 	app := tui.NewApplication(tui.ApplicationConfig{
@@ -65,11 +104,29 @@ func RunTUI() error {
 		ToolRegistry:   toolRegistry,
 	})
 
+	// Prompts run through the agent loop, which calls tools from the registry
+	conv := newConversation(provider, toolRegistry)
+
+	// Tools that change files, run commands or use the network ask first;
+	// denied calls never reach the checkpoint store
+	gate := tools.NewApprovalGate(tools.ApprovalPolicy{}, tui.NewApprover(send))
+	toolRegistry.Use(gate.Middleware())
+	toolRegistry.Use(conv.checkpointMiddleware())
+
 	// Register CLI-specific commands
 	initCmd := NewInitCommand()
 	if err := app.RegisterCommand(initCmd); err != nil {
 		fmt.Printf("Warning: failed to register /init command: %v\n", err)
 	}
+	if err := app.RegisterCommand(NewResumeCommand(conv)); err != nil {
+		fmt.Printf("Warning: failed to register /resume command: %v\n", err)
+	}
+	if err := app.RegisterCommand(NewUndoCommand(conv)); err != nil {
+		fmt.Printf("Warning: failed to register /undo command: %v\n", err)
+	}
+	if err := app.RegisterCommand(NewCheckpointsCommand(conv)); err != nil {
+		fmt.Printf("Warning: failed to register /checkpoints command: %v\n", err)
+	}
 
 	// Add welcome content
 	app.AddLine("")
@@ -86,10 +143,12 @@ func RunTUI() error {
 	app.AddLine("    /help          - Show all available commands")
 	app.AddLine("    /tools         - List all available tools")
 	app.AddLine("    /init <name>   - Create a new Agar project")
+	app.AddLine("    /resume [id]   - Resume a previous session")
+	app.AddLine("    /undo          - Undo file changes from the last turn")
 	app.AddLine("")
 	app.AddLine("  AI Assistant:")
 	app.AddLine("    Type any message (without /) to chat with the AI assistant")
-	app.AddLine("    Ask about Agar features, Go programming, or get coding help")
+	app.AddLine("    Ask about Agar features, Go programming, or have it work on your files")
 	app.AddLine("")
 	app.AddLine("─────────────────────────────────────────────────────────────────────────────────────────")
 	app.AddLine("")
@@ -108,22 +167,15 @@ func RunTUI() error {
 		WithHistory(true).
 		WithCommandManager(cmdMgr)
 
-	model := cliModel{
+	return cliModel{
 		app:              app,
 		prompt:           prompt,
 		width:            80,
 		height:           24,
-		conversationHist: make([]types.ConversationMessage, 0),
+		conv:             conv,
+		send:             send,
 		waitingForAgent:  false,
 	}
-
-	// Run the application
-	p := tea.NewProgram(model, tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		return fmt.Errorf("failed to run TUI: %w", err)
-	}
-
-	return nil
 }
 
 func (m cliModel) Init() tea.Cmd {
@@ -144,8 +196,21 @@ func (m cliModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
-		if msg.String() == "ctrl+c" {
-			return m, tea.Quit
+		// An open approval prompt takes every key until it is answered
+		if m.app.ApprovalPending() {
+			updatedApp, cmd := m.app.Update(msg)
+			m.app = updatedApp.(*tui.Application)
+			return m, cmd
+		}
+
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			// The application cancels an in-flight agent call on the first
+			// press and quits on the next
+			updatedApp, cmd := m.app.Update(msg)
+			m.app = updatedApp.(*tui.Application)
+			m.waitingForAgent = m.app.TurnActive()
+			return m, cmd
 		}
 
 	case tui.PromptSubmitMsg:
@@ -177,56 +242,64 @@ func (m cliModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// Non-slash input: submit as prompt to AI agent
-		m.app.AddLine("")
-		m.app.AddLine(lipgloss.NewStyle().Foreground(lipgloss.Color("86")).Render("> " + input))
-		m.app.AddLine("")
+		renderUserInput(m.app, input)
 
-		// Add user message to conversation history
-		m.conversationHist = append(m.conversationHist, types.ConversationMessage{
-			Role:    "user",
-			Content: input,
-		})
+		// Start recording the session and a checkpoint turn for /undo
+		if err := m.conv.begin(input); err != nil {
+			m.app.AddLine(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("Warning: session not saved: " + err.Error()))
+		}
 
-		// Show loading indicator
-		m.app.AddLine(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("● Thinking..."))
-		m.app.AddLine("")
+		// Open a streamed block showing the loading indicator until text arrives
+		m.app.Update(tui.StreamStartMsg{
+			Placeholder: lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("● Thinking... (esc to cancel)"),
+		})
 		m.waitingForAgent = true
+		m.turn++
+		m.streamText = ""
+		ctx := m.app.StartTurn()
 
 		// Update prompt to clear it
 		updated, cmd := m.prompt.Update(msg)
 		m.prompt = updated.(tui.PromptModel)
 
 		// Call agent in background
-		return m, tea.Batch(cmd, callAgentCmd(input, m.conversationHist))
+		return m, tea.Batch(cmd, runAgentCmd(ctx, m.turn, m.conv, input, m.send))
+
+	case tui.ApprovalRequestMsg:
+		// A tool call is waiting for the user; the application shows it
+		updatedApp, cmd := m.app.Update(msg)
+		m.app = updatedApp.(*tui.Application)
+		return m, cmd
+
+	case agentEventMsg:
+		// Events of cancelled turns are not shown
+		if msg.turn != m.turn || !m.waitingForAgent {
+			return m, nil
+		}
+		m.showAgentEvent(msg.event)
+		return m, nil
 
 	case agentResponseMsg:
+		// Replies to cancelled turns have already been marked in the transcript
+		if msg.turn != m.turn || !m.waitingForAgent {
+			return m, nil
+		}
+
 		m.waitingForAgent = false
+		m.app.EndTurn()
 
 		if msg.err != nil {
-			m.app.AddLine(lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("✗ Error: " + msg.err.Error()))
+			m.app.Update(tui.StreamDoneMsg{
+				Text: lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("✗ Error: " + msg.err.Error()),
+			})
 			m.app.AddLine("")
 			return m, nil
 		}
 
-		// Add assistant response to conversation history
-		m.conversationHist = append(m.conversationHist, types.ConversationMessage{
-			Role:    "assistant",
-			Content: msg.response,
-		})
-
-		// Display response
-		m.app.AddLine(msg.response)
+		// Display the complete response in place of the streamed text
+		m.app.Update(tui.StreamDoneMsg{Text: msg.response})
 		m.app.AddLine("")
 
-		// Display suggestions if any
-		if len(msg.suggestions) > 0 {
-			m.app.AddLine(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("Suggestions:"))
-			for _, suggestion := range msg.suggestions {
-				m.app.AddLine(lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("  • " + suggestion))
-			}
-			m.app.AddLine("")
-		}
-
 		return m, nil
 	}
 
@@ -250,8 +323,14 @@ func (m cliModel) View() string {
 	// Get content lines
 	contentLines := m.app.GetContent()
 
+	// A pending approval prompt replaces the input until it is answered
+	input := m.prompt.View()
+	if approval := m.app.ApprovalView(); approval != "" {
+		input = approval
+	}
+
 	// Calculate available lines for content (leave room for prompt)
-	promptViewLines := strings.Count(m.prompt.View(), "\n") + 1
+	promptViewLines := strings.Count(input, "\n") + 1
 	separatorLines := 1
 	availableContentLines := panel.GetContentHeight() - promptViewLines - separatorLines
 
@@ -268,7 +347,7 @@ func (m cliModel) View() string {
 	panelContent.WriteString("\n")
 	panelContent.WriteString(strings.Repeat("─", panel.GetContentWidth()))
 	panelContent.WriteString("\n")
-	panelContent.WriteString(m.prompt.View())
+	panelContent.WriteString(input)
 
 	panel.SetContent(panelContent.String())
 
@@ -307,26 +386,50 @@ func (m cliModel) View() string {
 	return output.String()
 }
 
-// callAgentCmd creates a command that calls the BAML agent
-func callAgentCmd(userInput string, history []types.ConversationMessage) tea.Cmd {
-	return func() tea.Msg {
-		ctx := context.Background()
+// showAgentEvent adds the progress of an agent call to the transcript. The
+// streamed block is completed when the model calls tools, which are listed
+// below it, and reopened while the model considers their results.
+func (m *cliModel) showAgentEvent(e agent.Event) {
+	switch e.Type {
+	case agent.EventDelta:
+		if e.Delta.Text != "" {
+			m.streamText += e.Delta.Text
+			m.app.Update(tui.StreamUpdateMsg{Text: m.streamText})
+		}
 
-		// Limit conversation history to last 10 messages to avoid token limits
-		historyToSend := history
-		if len(history) > 10 {
-			historyToSend = history[len(history)-10:]
+	case agent.EventModelResponse:
+		if len(e.Message.ToolUses()) > 0 {
+			m.app.Update(tui.StreamDoneMsg{Text: e.Message.Text()})
+			m.streamText = ""
 		}
 
-		// Call BAML agent
-		response, err := baml_client.AgentPrompt(ctx, userInput, historyToSend)
-		if err != nil {
-			return agentResponseMsg{err: err}
+	case agent.EventToolCall:
+		renderToolCall(m.app, e.ToolCall)
+
+	case agent.EventToolResult:
+		renderToolResult(m.app, e.Result)
+
+	case agent.EventMessage:
+		// Tool results go back to the model in a user message
+		if e.Turn > 0 && e.Message.Role == llm.RoleUser {
+			m.app.Update(tui.StreamStartMsg{
+				Placeholder: lipgloss.NewStyle().Foreground(lipgloss.Color("240")).Render("● Thinking... (esc to cancel)"),
+			})
 		}
+	}
+}
 
-		return agentResponseMsg{
-			response:    response.Response,
-			suggestions: response.Suggestions,
+// runAgentCmd creates a command that runs the agent on the user input,
+// delivering its progress through send. The run is cancelled with ctx.
+func runAgentCmd(ctx context.Context, turn int, conv *conversation, input string, send func(tea.Msg)) tea.Cmd {
+	return func() tea.Msg {
+		result, err := conv.run(ctx, input, func(e agent.Event) {
+			send(agentEventMsg{turn: turn, event: e})
+		})
+		if err != nil {
+			return agentResponseMsg{turn: turn, err: err}
 		}
+
+		return agentResponseMsg{turn: turn, response: result.Text}
 	}
 }
@@ -0,0 +1,264 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/geoffjay/agar/llm"
+	"github.com/geoffjay/agar/tools"
+	"github.com/geoffjay/agar/tui"
+)
+
+// testProgram drives a cliModel the way tea.Program does: commands run in the
+// background, and the messages they return, along with those sent by the
+// agent, are passed to Update one at a time
+type testProgram struct {
+	t     *testing.T
+	model cliModel
+	msgs  chan tea.Msg
+	done  chan struct{}
+}
+
+// newTestProgram creates a CLI model answering prompts with provider. Sessions,
+// checkpoints and the trash are kept under a temporary home directory.
+func newTestProgram(t *testing.T, provider llm.Provider) *testProgram {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	p := &testProgram{t: t, msgs: make(chan tea.Msg), done: make(chan struct{})}
+	p.model = newCLIModel(t.TempDir(), provider, p.send)
+	t.Cleanup(func() {
+		close(p.done)
+		p.model.conv.close()
+	})
+	return p
+}
+
+// send delivers a message to the model, as tea.Program.Send does
+func (p *testProgram) send(msg tea.Msg) {
+	select {
+	case p.msgs <- msg:
+	case <-p.done:
+	}
+}
+
+// update passes msg to the model and runs the command it returns
+func (p *testProgram) update(msg tea.Msg) {
+	model, cmd := p.model.Update(msg)
+	p.model = model.(cliModel)
+	p.run(cmd)
+}
+
+// run executes cmd in the background, delivering its messages
+func (p *testProgram) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() {
+		switch msg := cmd().(type) {
+		case nil:
+		case tea.BatchMsg:
+			for _, c := range msg {
+				p.run(c)
+			}
+		default:
+			p.send(msg)
+		}
+	}()
+}
+
+// waitFor processes messages until cond holds
+func (p *testProgram) waitFor(what string, cond func() bool) {
+	p.t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for !cond() {
+		select {
+		case msg := <-p.msgs:
+			p.update(msg)
+		case <-time.After(10 * time.Millisecond):
+			// Conditions may also change outside the model
+		case <-timeout:
+			p.t.Fatalf("Timed out waiting for %s, transcript:\n%s", what, p.transcript())
+		}
+	}
+}
+
+// submit enters input at the prompt
+func (p *testProgram) submit(input string) {
+	p.update(tui.PromptSubmitMsg{Input: input})
+}
+
+// press sends a key press, such as "y", "enter" or "esc"
+func (p *testProgram) press(key string) {
+	switch key {
+	case "esc":
+		p.update(tea.KeyMsg{Type: tea.KeyEsc})
+	case "enter":
+		p.update(tea.KeyMsg{Type: tea.KeyEnter})
+	default:
+		p.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	}
+}
+
+// approvalPending reports whether a tool call is waiting for the user
+func (p *testProgram) approvalPending() bool {
+	return p.model.app.ApprovalPending()
+}
+
+// idle reports whether no agent call is in flight
+func (p *testProgram) idle() bool {
+	return !p.model.waitingForAgent
+}
+
+// transcript returns the content of the application panel
+func (p *testProgram) transcript() string {
+	return strings.Join(p.model.app.GetContent(), "\n")
+}
+
+// scriptedProvider returns a provider replying with messages in order
+func scriptedProvider(messages ...llm.Message) llm.Provider {
+	var mu sync.Mutex
+	calls := 0
+
+	return llm.ProviderFunc(func(ctx context.Context, req *llm.Request) (*llm.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if calls >= len(messages) {
+			return nil, fmt.Errorf("unexpected model call %d", calls+1)
+		}
+		msg := messages[calls]
+		calls++
+
+		stop := llm.StopEndTurn
+		if len(msg.ToolUses()) > 0 {
+			stop = llm.StopToolUse
+		}
+		return &llm.Response{Message: msg, StopReason: stop}, nil
+	})
+}
+
+// toolCallMessage returns an assistant message calling the named tool
+func toolCallMessage(t *testing.T, id, name string, params interface{}) llm.Message {
+	t.Helper()
+
+	input, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("Failed to encode params: %v", err)
+	}
+	return llm.Message{
+		Role:    llm.RoleAssistant,
+		Content: []llm.ContentBlock{llm.NewToolUseBlock(id, name, input)},
+	}
+}
+
+func TestCLIModel_RunsAgent(t *testing.T) {
+	p := newTestProgram(t, scriptedProvider(llm.NewTextMessage(llm.RoleAssistant, "Hello from the agent")))
+
+	p.submit("Hi")
+	p.waitFor("the agent to reply", p.idle)
+
+	if !strings.Contains(p.transcript(), "Hello from the agent") {
+		t.Errorf("Expected transcript to contain the reply, got:\n%s", p.transcript())
+	}
+	if p.model.conv.messageCount() != 2 {
+		t.Errorf("Expected 2 messages in the history, got %d", p.model.conv.messageCount())
+	}
+	if p.model.conv.sessionID() == "" {
+		t.Error("Expected the prompt to start a session")
+	}
+}
+
+func TestCLIModel_ApprovalDenied(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+
+	p := newTestProgram(t, scriptedProvider(
+		toolCallMessage(t, "call_1", "write", tools.WriteParams{Path: path, Content: "changed"}),
+		llm.NewTextMessage(llm.RoleAssistant, "Leaving the notes alone"),
+	))
+
+	p.submit("Write the notes")
+	p.waitFor("the approval prompt", p.approvalPending)
+
+	if view := p.model.View(); !strings.Contains(view, "Allow write") || !strings.Contains(view, "[x] No") {
+		t.Errorf("Expected the approval prompt in place of the input, focused on No, got:\n%s", view)
+	}
+
+	// Keys answer the prompt rather than reaching the input, and Enter
+	// alone denies the call
+	p.press("enter")
+	p.waitFor("the agent to finish", p.idle)
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the denied write not to create the file, got %v", err)
+	}
+	if p.model.prompt.GetInput() != "" {
+		t.Errorf("Expected the answer not to reach the prompt, got %q", p.model.prompt.GetInput())
+	}
+
+	transcript := p.transcript()
+	for _, want := range []string{"✗ Denied write", "was not approved by the user", "Leaving the notes alone"} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("Expected transcript to contain %q, got:\n%s", want, transcript)
+		}
+	}
+}
+
+func TestCLIModel_EscCancelsRunningTool(t *testing.T) {
+	dir := t.TempDir()
+	started := filepath.Join(dir, "started")
+
+	p := newTestProgram(t, scriptedProvider(
+		toolCallMessage(t, "call_1", "shell", tools.ShellParams{
+			Command:    "touch started && sleep 30",
+			Shell:      "sh",
+			WorkingDir: dir,
+		}),
+	))
+
+	p.submit("Run the slow command")
+	p.waitFor("the approval prompt", p.approvalPending)
+	p.press("y")
+	p.waitFor("the command to start", func() bool {
+		_, err := os.Stat(started)
+		return err == nil
+	})
+
+	start := time.Now()
+	p.press("esc")
+
+	if !p.idle() {
+		t.Error("Expected Esc to end the turn")
+	}
+	if !strings.Contains(p.transcript(), "Cancelled") {
+		t.Errorf("Expected a cancelled marker, got:\n%s", p.transcript())
+	}
+
+	// The agent run returns once the command is killed
+	p.waitFor("the agent run to stop", func() bool {
+		if !p.model.conv.runMu.TryLock() {
+			return false
+		}
+		p.model.conv.runMu.Unlock()
+		return true
+	})
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected the command to be stopped promptly, took %v", elapsed)
+	}
+
+	// The cancelled call is answered in the history, ready for the next prompt
+	history := p.model.conv.agent.History()
+	last := history[len(history)-1]
+	if last.Role != llm.RoleUser || len(last.Content) != 1 || !last.Content[0].IsError {
+		t.Errorf("Expected an error result for the cancelled call, got %+v", last)
+	}
+}
@@ -0,0 +1,365 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAnthropicBaseURL is the Anthropic API endpoint
+	DefaultAnthropicBaseURL = "https://api.anthropic.com"
+
+	// DefaultAnthropicVersion is the Messages API version sent with each request
+	DefaultAnthropicVersion = "2023-06-01"
+
+	// DefaultAnthropicModel is used when neither the config nor the request names a model
+	DefaultAnthropicModel = "claude-sonnet-4-20250514"
+)
+
+// AnthropicConfig holds configuration for the Anthropic provider
+type AnthropicConfig struct {
+	APIKey     string       // API key (default: $ANTHROPIC_API_KEY)
+	BaseURL    string       // API base URL (default: https://api.anthropic.com)
+	Model      string       // Default model (default: claude-sonnet-4-20250514)
+	Version    string       // anthropic-version header (default: 2023-06-01)
+	MaxTokens  int          // Default output token limit (default: 4096)
+	HTTPClient *http.Client // Optional HTTP client
+}
+
+// AnthropicProvider implements Provider and Streamer using the Anthropic
+// Messages API
+type AnthropicProvider struct {
+	config AnthropicConfig
+	client *http.Client
+}
+
+// anthropicRequest is the Messages API request body
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	Tools         []ToolDefinition   `json:"tools,omitempty"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    Role             `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+// anthropicBlock mirrors ContentBlock but always sends input for tool_use blocks
+type anthropicBlock struct {
+	Type      ContentType     `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+}
+
+// anthropicResponse is the Messages API response body
+type anthropicResponse struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	Role       Role             `json:"role"`
+	Model      string           `json:"model"`
+	Content    []anthropicBlock `json:"content"`
+	StopReason StopReason       `json:"stop_reason"`
+	Usage      Usage            `json:"usage"`
+}
+
+// anthropicStreamEvent is a Messages API streaming event. Which fields are
+// set depends on Type.
+type anthropicStreamEvent struct {
+	Type         string             `json:"type"`
+	Index        int                `json:"index"`
+	Message      *anthropicResponse `json:"message,omitempty"`       // message_start
+	ContentBlock *anthropicBlock    `json:"content_block,omitempty"` // content_block_start
+	Delta        struct {
+		Type        string     `json:"type"`
+		Text        string     `json:"text,omitempty"`         // text_delta
+		PartialJSON string     `json:"partial_json,omitempty"` // input_json_delta
+		StopReason  StopReason `json:"stop_reason,omitempty"`  // message_delta
+	} `json:"delta"`
+	Usage *Usage `json:"usage,omitempty"` // message_delta
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// anthropicError is the Messages API error body
+type anthropicError struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewAnthropicProvider creates a new Anthropic provider instance
+func NewAnthropicProvider(config AnthropicConfig) *AnthropicProvider {
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultAnthropicBaseURL
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.Model == "" {
+		config.Model = DefaultAnthropicModel
+	}
+	if config.Version == "" {
+		config.Version = DefaultAnthropicVersion
+	}
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = DefaultMaxTokens
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Minute}
+	}
+
+	return &AnthropicProvider{
+		config: config,
+		client: client,
+	}
+}
+
+// Complete sends the request to the Messages API and returns the reply
+func (p *AnthropicProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := p.send(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var out anthropicResponse
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return out.toResponse(), nil
+}
+
+// Stream sends the request with streaming enabled, calling handler for every
+// text or tool use delta, and returns the assembled reply
+func (p *AnthropicProvider) Stream(ctx context.Context, req *Request, handler StreamHandler) (*Response, error) {
+	resp, err := p.send(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	out := &anthropicResponse{}
+	inputs := make(map[int]*strings.Builder)
+
+	emit := func(delta Delta) {
+		if handler != nil {
+			handler(delta)
+		}
+	}
+
+	err = readSSE(resp.Body, func(event sseEvent) error {
+		var data anthropicStreamEvent
+		if err := json.Unmarshal([]byte(event.Data), &data); err != nil {
+			return fmt.Errorf("failed to decode stream event: %w", err)
+		}
+
+		switch data.Type {
+		case "message_start":
+			if data.Message != nil {
+				out.ID = data.Message.ID
+				out.Model = data.Message.Model
+				out.Usage = data.Message.Usage
+			}
+
+		case "content_block_start":
+			if data.ContentBlock == nil {
+				return nil
+			}
+			// Blocks arrive in index order, so the slice index matches
+			for len(out.Content) <= data.Index {
+				out.Content = append(out.Content, anthropicBlock{})
+			}
+			block := *data.ContentBlock
+			if block.Type == ContentToolUse {
+				block.Input = nil // Assembled from the input deltas
+				inputs[data.Index] = &strings.Builder{}
+				emit(Delta{Type: DeltaToolUse, Index: data.Index, ID: block.ID, Name: block.Name})
+			}
+			out.Content[data.Index] = block
+
+		case "content_block_delta":
+			if data.Index >= len(out.Content) {
+				return nil
+			}
+			switch data.Delta.Type {
+			case "text_delta":
+				out.Content[data.Index].Text += data.Delta.Text
+				emit(Delta{Type: DeltaText, Index: data.Index, Text: data.Delta.Text})
+			case "input_json_delta":
+				if input, ok := inputs[data.Index]; ok {
+					input.WriteString(data.Delta.PartialJSON)
+					emit(Delta{Type: DeltaToolUse, Index: data.Index, InputJSON: data.Delta.PartialJSON})
+				}
+			}
+
+		case "message_delta":
+			if data.Delta.StopReason != "" {
+				out.StopReason = data.Delta.StopReason
+			}
+			if data.Usage != nil {
+				out.Usage.OutputTokens = data.Usage.OutputTokens
+			}
+
+		case "message_stop":
+			return errStreamDone
+
+		case "error":
+			if data.Error != nil {
+				return &APIError{StatusCode: http.StatusOK, Type: data.Error.Type, Message: data.Error.Message}
+			}
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStreamDone) {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			return nil, apiErr
+		}
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	for index, input := range inputs {
+		out.Content[index].Input = toolArguments(input.String())
+	}
+
+	return out.toResponse(), nil
+}
+
+// send posts the request and returns the response once the status is checked
+func (p *AnthropicProvider) send(ctx context.Context, req *Request, stream bool) (*http.Response, error) {
+	if p.config.APIKey == "" {
+		return nil, fmt.Errorf("anthropic API key is not set")
+	}
+
+	wire := p.buildRequest(req)
+	wire.Stream = stream
+
+	body, err := json.Marshal(wire)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.BaseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.config.APIKey)
+	httpReq.Header.Set("anthropic-version", p.config.Version)
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, parseAnthropicError(resp.StatusCode, data)
+	}
+
+	return resp, nil
+}
+
+// buildRequest converts a provider-neutral request to the Messages API format
+func (p *AnthropicProvider) buildRequest(req *Request) *anthropicRequest {
+	out := &anthropicRequest{
+		Model:         req.Model,
+		MaxTokens:     req.MaxTokens,
+		System:        req.System,
+		Messages:      make([]anthropicMessage, 0, len(req.Messages)),
+		Tools:         req.Tools,
+		Temperature:   req.Temperature,
+		StopSequences: req.StopSequences,
+	}
+	if out.Model == "" {
+		out.Model = p.config.Model
+	}
+	if out.MaxTokens <= 0 {
+		out.MaxTokens = p.config.MaxTokens
+	}
+
+	for _, msg := range req.Messages {
+		blocks := make([]anthropicBlock, 0, len(msg.Content))
+		for _, block := range msg.Content {
+			wire := anthropicBlock(block)
+			// The API requires an input object on every tool_use block
+			if block.Type == ContentToolUse && len(wire.Input) == 0 {
+				wire.Input = json.RawMessage(`{}`)
+			}
+			blocks = append(blocks, wire)
+		}
+		out.Messages = append(out.Messages, anthropicMessage{Role: msg.Role, Content: blocks})
+	}
+
+	return out
+}
+
+// toResponse converts a Messages API response to the provider-neutral format
+func (r *anthropicResponse) toResponse() *Response {
+	content := make([]ContentBlock, 0, len(r.Content))
+	for _, block := range r.Content {
+		// Skip block types we don't model (e.g. thinking)
+		if block.Type != ContentText && block.Type != ContentToolUse {
+			continue
+		}
+		content = append(content, ContentBlock(block))
+	}
+
+	return &Response{
+		ID:         r.ID,
+		Model:      r.Model,
+		Message:    Message{Role: RoleAssistant, Content: content},
+		StopReason: r.StopReason,
+		Usage:      r.Usage,
+	}
+}
+
+// parseAnthropicError builds an APIError from an error response body
+func parseAnthropicError(status int, data []byte) error {
+	var body anthropicError
+	if err := json.Unmarshal(data, &body); err == nil && body.Error.Message != "" {
+		return &APIError{StatusCode: status, Type: body.Error.Type, Message: body.Error.Message}
+	}
+
+	return &APIError{StatusCode: status, Message: strings.TrimSpace(string(data))}
+}
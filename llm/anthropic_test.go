@@ -0,0 +1,327 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newAnthropicTestServer(t *testing.T, handler func(t *testing.T, body map[string]interface{}, w http.ResponseWriter)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("Expected path /v1/messages, got %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" {
+			t.Errorf("Expected x-api-key header, got '%s'", r.Header.Get("x-api-key"))
+		}
+		if r.Header.Get("anthropic-version") != DefaultAnthropicVersion {
+			t.Errorf("Expected anthropic-version header, got '%s'", r.Header.Get("anthropic-version"))
+		}
+
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Fatalf("Request body is not valid JSON: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		handler(t, body, w)
+	}))
+}
+
+func TestAnthropicProvider_Complete_Text(t *testing.T) {
+	server := newAnthropicTestServer(t, func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		if body["model"] != "test-model" {
+			t.Errorf("Expected model 'test-model', got %v", body["model"])
+		}
+		if body["system"] != "be brief" {
+			t.Errorf("Expected system prompt, got %v", body["system"])
+		}
+		if body["max_tokens"].(float64) != DefaultMaxTokens {
+			t.Errorf("Expected default max_tokens, got %v", body["max_tokens"])
+		}
+
+		w.Write([]byte(`{
+			"id": "msg_1",
+			"type": "message",
+			"role": "assistant",
+			"model": "test-model",
+			"content": [{"type": "text", "text": "Hello there"}],
+			"stop_reason": "end_turn",
+			"usage": {"input_tokens": 12, "output_tokens": 3}
+		}`))
+	})
+	defer server.Close()
+
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "test-key", BaseURL: server.URL, Model: "test-model"})
+	resp, err := provider.Complete(context.Background(), &Request{
+		System:   "be brief",
+		Messages: []Message{NewTextMessage(RoleUser, "Hi")},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	if resp.Message.Text() != "Hello there" {
+		t.Errorf("Expected 'Hello there', got '%s'", resp.Message.Text())
+	}
+	if resp.StopReason != StopEndTurn {
+		t.Errorf("Expected end_turn, got %s", resp.StopReason)
+	}
+	if resp.Usage.InputTokens != 12 || resp.Usage.OutputTokens != 3 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
+	if resp.ID != "msg_1" {
+		t.Errorf("Expected id 'msg_1', got '%s'", resp.ID)
+	}
+}
+
+func TestAnthropicProvider_Complete_ToolUse(t *testing.T) {
+	server := newAnthropicTestServer(t, func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		tools := body["tools"].([]interface{})
+		if len(tools) != 1 {
+			t.Fatalf("Expected 1 tool, got %d", len(tools))
+		}
+		tool := tools[0].(map[string]interface{})
+		if tool["name"] != "read" || tool["input_schema"] == nil {
+			t.Errorf("Unexpected tool definition: %v", tool)
+		}
+
+		// The previous tool use and result must be sent in wire format
+		messages := body["messages"].([]interface{})
+		if len(messages) != 3 {
+			t.Fatalf("Expected 3 messages, got %d", len(messages))
+		}
+		use := messages[1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+		if use["type"] != "tool_use" || use["id"] != "toolu_0" {
+			t.Errorf("Unexpected tool_use block: %v", use)
+		}
+		if _, ok := use["input"].(map[string]interface{}); !ok {
+			t.Errorf("Expected tool_use input object, got %v", use["input"])
+		}
+		result := messages[2].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+		if result["type"] != "tool_result" || result["tool_use_id"] != "toolu_0" || result["is_error"] != true {
+			t.Errorf("Unexpected tool_result block: %v", result)
+		}
+
+		w.Write([]byte(`{
+			"id": "msg_2",
+			"type": "message",
+			"role": "assistant",
+			"model": "test-model",
+			"content": [
+				{"type": "text", "text": "Reading the file."},
+				{"type": "tool_use", "id": "toolu_1", "name": "read", "input": {"path": "README.md"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 40, "output_tokens": 20}
+		}`))
+	})
+	defer server.Close()
+
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "test-key", BaseURL: server.URL + "/"})
+	resp, err := provider.Complete(context.Background(), &Request{
+		Model: "test-model",
+		Messages: []Message{
+			NewTextMessage(RoleUser, "Read the readme"),
+			{Role: RoleAssistant, Content: []ContentBlock{NewToolUseBlock("toolu_0", "read", nil)}},
+			{Role: RoleUser, Content: []ContentBlock{NewToolResultBlock("toolu_0", "not found", true)}},
+		},
+		Tools: []ToolDefinition{{
+			Name:        "read",
+			Description: "Read a file",
+			InputSchema: map[string]interface{}{"type": "object"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	if resp.StopReason != StopToolUse {
+		t.Errorf("Expected tool_use stop reason, got %s", resp.StopReason)
+	}
+
+	uses := resp.Message.ToolUses()
+	if len(uses) != 1 {
+		t.Fatalf("Expected 1 tool use, got %d", len(uses))
+	}
+	if uses[0].ID != "toolu_1" || uses[0].Name != "read" {
+		t.Errorf("Unexpected tool use: %+v", uses[0])
+	}
+
+	var input map[string]string
+	if err := json.Unmarshal(uses[0].Input, &input); err != nil || input["path"] != "README.md" {
+		t.Errorf("Unexpected tool input: %s", string(uses[0].Input))
+	}
+}
+
+func TestAnthropicProvider_Stream(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_3","type":"message","role":"assistant","model":"test-model","content":[],"usage":{"input_tokens":20,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"ping"}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"check."}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"grep","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"pattern\":"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"TODO\"}"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":9}}`,
+		`{"type":"message_stop"}`,
+	}
+
+	server := newAnthropicTestServer(t, func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		if body["stream"] != true {
+			t.Errorf("Expected stream true, got %v", body["stream"])
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			var typed struct{ Type string }
+			json.Unmarshal([]byte(event), &typed)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typed.Type, event)
+		}
+	})
+	defer server.Close()
+
+	var deltas []Delta
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "test-key", BaseURL: server.URL})
+	resp, err := provider.Stream(context.Background(), &Request{
+		Messages: []Message{NewTextMessage(RoleUser, "find todos")},
+	}, func(d Delta) {
+		deltas = append(deltas, d)
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	var text strings.Builder
+	var input strings.Builder
+	for _, d := range deltas {
+		switch d.Type {
+		case DeltaText:
+			text.WriteString(d.Text)
+		case DeltaToolUse:
+			input.WriteString(d.InputJSON)
+		}
+	}
+	if text.String() != "Let me check." {
+		t.Errorf("Expected streamed text 'Let me check.', got '%s'", text.String())
+	}
+	if input.String() != `{"pattern":"TODO"}` {
+		t.Errorf("Expected streamed input, got '%s'", input.String())
+	}
+	if deltas[2].ID != "toolu_1" || deltas[2].Name != "grep" || deltas[2].Index != 1 {
+		t.Errorf("Expected first tool delta to carry id and name, got %+v", deltas[2])
+	}
+
+	if resp.ID != "msg_3" || resp.Model != "test-model" || resp.StopReason != StopToolUse {
+		t.Errorf("Unexpected response metadata: %s %s %s", resp.ID, resp.Model, resp.StopReason)
+	}
+	if resp.Usage.InputTokens != 20 || resp.Usage.OutputTokens != 9 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
+	if resp.Message.Text() != "Let me check." {
+		t.Errorf("Expected assembled text, got '%s'", resp.Message.Text())
+	}
+	uses := resp.Message.ToolUses()
+	if len(uses) != 1 || uses[0].ID != "toolu_1" || string(uses[0].Input) != `{"pattern":"TODO"}` {
+		t.Errorf("Expected assembled tool use, got %+v", uses)
+	}
+}
+
+func TestAnthropicProvider_Stream_Error(t *testing.T) {
+	server := newAnthropicTestServer(t, func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	})
+	defer server.Close()
+
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "test-key", BaseURL: server.URL})
+	_, err := provider.Stream(context.Background(), &Request{
+		Messages: []Message{NewTextMessage(RoleUser, "Hi")},
+	}, nil)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Type != "overloaded_error" {
+		t.Errorf("Expected an overloaded APIError, got %v", err)
+	}
+}
+
+func TestAnthropicProvider_Complete_APIError(t *testing.T) {
+	server := newAnthropicTestServer(t, func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"type": "error", "error": {"type": "rate_limit_error", "message": "slow down"}}`))
+	})
+	defer server.Close()
+
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "test-key", BaseURL: server.URL})
+	_, err := provider.Complete(context.Background(), &Request{
+		Messages: []Message{NewTextMessage(RoleUser, "Hi")},
+	})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.Type != "rate_limit_error" || apiErr.Message != "slow down" {
+		t.Errorf("Unexpected API error: %+v", apiErr)
+	}
+}
+
+func TestAnthropicProvider_MissingAPIKey(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	provider := NewAnthropicProvider(AnthropicConfig{})
+	_, err := provider.Complete(context.Background(), &Request{
+		Messages: []Message{NewTextMessage(RoleUser, "Hi")},
+	})
+	if err == nil {
+		t.Error("Expected error when API key is missing")
+	}
+}
+
+func TestAnthropicProvider_ContextCancelled(t *testing.T) {
+	server := newAnthropicTestServer(t, func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		w.Write([]byte(`{}`))
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	provider := NewAnthropicProvider(AnthropicConfig{APIKey: "test-key", BaseURL: server.URL})
+	_, err := provider.Complete(ctx, &Request{Messages: []Message{NewTextMessage(RoleUser, "Hi")}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestMessage_Helpers(t *testing.T) {
+	msg := Message{Role: RoleAssistant, Content: []ContentBlock{
+		{Type: ContentText, Text: "one "},
+		NewToolUseBlock("a", "read", json.RawMessage(`{}`)),
+		{Type: ContentText, Text: "two"},
+	}}
+
+	if msg.Text() != "one two" {
+		t.Errorf("Expected 'one two', got '%s'", msg.Text())
+	}
+	if len(msg.ToolUses()) != 1 {
+		t.Errorf("Expected 1 tool use, got %d", len(msg.ToolUses()))
+	}
+
+	usage := Usage{InputTokens: 1, OutputTokens: 2}
+	usage.Add(Usage{InputTokens: 3, OutputTokens: 4})
+	if usage.InputTokens != 4 || usage.OutputTokens != 6 {
+		t.Errorf("Unexpected usage sum: %+v", usage)
+	}
+}
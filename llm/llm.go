@@ -0,0 +1,223 @@
+// Package llm provides a provider-neutral client interface for large language
+// models.
+//
+// A Provider turns a Request (system prompt, messages and tool definitions)
+// into a Response (assistant message, stop reason and token usage). Messages
+// are made of content blocks that carry text, tool_use requests from the model
+// or tool_result blocks sent back to it.
+//
+// # Basic Usage
+//
+//	provider := llm.NewAnthropicProvider(llm.AnthropicConfig{
+//	    Model: "claude-sonnet-4-20250514",
+//	})
+//
+//	resp, err := provider.Complete(ctx, &llm.Request{
+//	    System:   "You are a helpful assistant.",
+//	    Messages: []llm.Message{llm.NewTextMessage(llm.RoleUser, "Hello!")},
+//	})
+//	fmt.Println(resp.Message.Text())
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Role identifies the author of a message
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// ContentType identifies the kind of a content block
+type ContentType string
+
+const (
+	ContentText       ContentType = "text"
+	ContentToolUse    ContentType = "tool_use"
+	ContentToolResult ContentType = "tool_result"
+)
+
+// StopReason describes why the model stopped generating
+type StopReason string
+
+const (
+	StopEndTurn      StopReason = "end_turn"
+	StopToolUse      StopReason = "tool_use"
+	StopMaxTokens    StopReason = "max_tokens"
+	StopStopSequence StopReason = "stop_sequence"
+)
+
+// DefaultMaxTokens is the output token limit used when a request does not set one
+const DefaultMaxTokens = 4096
+
+// ContentBlock is a single piece of message content. Depending on Type it
+// carries text, a tool use request from the model, or a tool result.
+type ContentBlock struct {
+	Type ContentType `json:"type"`
+
+	// Text content (type "text")
+	Text string `json:"text,omitempty"`
+
+	// Tool use request (type "tool_use")
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// Tool result (type "tool_result")
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+}
+
+// Message is a single conversation turn
+type Message struct {
+	Role    Role           `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// ToolDefinition describes a tool the model may call
+type ToolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// Request is the input for a single model call
+type Request struct {
+	Model         string           `json:"model,omitempty"` // Overrides the provider's default model
+	System        string           `json:"system,omitempty"`
+	Messages      []Message        `json:"messages"`
+	Tools         []ToolDefinition `json:"tools,omitempty"`
+	MaxTokens     int              `json:"max_tokens,omitempty"`
+	Temperature   *float64         `json:"temperature,omitempty"`
+	StopSequences []string         `json:"stop_sequences,omitempty"`
+}
+
+// Usage reports token consumption for a model call
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Add accumulates another usage report into u
+func (u *Usage) Add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+}
+
+// Response is the output of a single model call
+type Response struct {
+	ID         string     `json:"id,omitempty"`
+	Model      string     `json:"model,omitempty"`
+	Message    Message    `json:"message"`
+	StopReason StopReason `json:"stop_reason"`
+	Usage      Usage      `json:"usage"`
+}
+
+// Provider is the interface every model backend implements
+type Provider interface {
+	// Complete sends the request to the model and returns its reply
+	Complete(ctx context.Context, req *Request) (*Response, error)
+}
+
+// DeltaType identifies the kind of a streamed delta
+type DeltaType string
+
+const (
+	DeltaText    DeltaType = "text"
+	DeltaToolUse DeltaType = "tool_use"
+)
+
+// Delta is an incremental piece of a streamed response
+type Delta struct {
+	Type  DeltaType `json:"type"`
+	Index int       `json:"index"` // Index of the content block being built
+
+	// Text fragment (type "text")
+	Text string `json:"text,omitempty"`
+
+	// Tool use fragment (type "tool_use"). ID and Name are set on the first
+	// delta for a block; InputJSON carries partial JSON for the input.
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	InputJSON string `json:"input_json,omitempty"`
+}
+
+// StreamHandler receives deltas as they arrive
+type StreamHandler func(Delta)
+
+// Streamer is implemented by providers that can stream responses
+type Streamer interface {
+	// Stream sends the request to the model, calling handler for every delta,
+	// and returns the assembled reply once the stream ends
+	Stream(ctx context.Context, req *Request, handler StreamHandler) (*Response, error)
+}
+
+// ProviderFunc adapts a plain function to the Provider interface
+type ProviderFunc func(ctx context.Context, req *Request) (*Response, error)
+
+// Complete calls f(ctx, req)
+func (f ProviderFunc) Complete(ctx context.Context, req *Request) (*Response, error) {
+	return f(ctx, req)
+}
+
+// APIError is returned when a provider responds with an error status
+type APIError struct {
+	StatusCode int    `json:"status_code"`
+	Type       string `json:"type,omitempty"`
+	Message    string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("API error (HTTP %d, %s): %s", e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("API error (HTTP %d): %s", e.StatusCode, e.Message)
+}
+
+// NewTextMessage creates a message with a single text block
+func NewTextMessage(role Role, text string) Message {
+	return Message{
+		Role:    role,
+		Content: []ContentBlock{{Type: ContentText, Text: text}},
+	}
+}
+
+// NewToolUseBlock creates a tool use content block
+func NewToolUseBlock(id, name string, input json.RawMessage) ContentBlock {
+	return ContentBlock{Type: ContentToolUse, ID: id, Name: name, Input: input}
+}
+
+// NewToolResultBlock creates a tool result content block
+func NewToolResultBlock(toolUseID, content string, isError bool) ContentBlock {
+	return ContentBlock{Type: ContentToolResult, ToolUseID: toolUseID, Content: content, IsError: isError}
+}
+
+// Text returns the concatenated text blocks of the message
+func (m Message) Text() string {
+	var b strings.Builder
+	for _, block := range m.Content {
+		if block.Type == ContentText {
+			b.WriteString(block.Text)
+		}
+	}
+	return b.String()
+}
+
+// ToolUses returns the tool use blocks of the message
+func (m Message) ToolUses() []ContentBlock {
+	var uses []ContentBlock
+	for _, block := range m.Content {
+		if block.Type == ContentToolUse {
+			uses = append(uses, block)
+		}
+	}
+	return uses
+}
@@ -0,0 +1,450 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultOpenAIBaseURL is the OpenAI API endpoint. Local servers such as
+// Ollama (http://localhost:11434/v1), vLLM or llama.cpp expose the same API
+// under their own base URL.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// errStreamDone stops SSE parsing at the [DONE] sentinel
+var errStreamDone = errors.New("stream done")
+
+// OpenAIConfig holds configuration for the OpenAI-compatible provider
+type OpenAIConfig struct {
+	APIKey     string       // API key (default: $OPENAI_API_KEY, optional for local servers)
+	BaseURL    string       // API base URL including the /v1 suffix (default: https://api.openai.com/v1)
+	Model      string       // Default model (required unless every request sets one)
+	MaxTokens  int          // Default output token limit (default: 4096)
+	HTTPClient *http.Client // Optional HTTP client
+}
+
+// OpenAIProvider implements Provider and Streamer using the OpenAI-compatible
+// /chat/completions endpoint
+type OpenAIProvider struct {
+	config OpenAIConfig
+	client *http.Client
+}
+
+// openAIRequest is the chat completions request body
+type openAIRequest struct {
+	Model         string               `json:"model"`
+	Messages      []openAIMessage      `json:"messages"`
+	Tools         []openAITool         `json:"tools,omitempty"`
+	MaxTokens     int                  `json:"max_tokens,omitempty"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	Stop          []string             `json:"stop,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    *string          `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAITool struct {
+	Type     string             `json:"type"`
+	Function openAIToolFunction `json:"function"`
+}
+
+type openAIToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type openAIToolCall struct {
+	Index    *int               `json:"index,omitempty"`
+	ID       string             `json:"id,omitempty"`
+	Type     string             `json:"type,omitempty"`
+	Function openAIFunctionCall `json:"function"`
+}
+
+type openAIFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// openAIResponse is the chat completions response body
+type openAIResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// openAIChunk is a single streamed chat completions chunk
+type openAIChunk struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta struct {
+			Content   string           `json:"content"`
+			ToolCalls []openAIToolCall `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// openAIError is the chat completions error body
+type openAIError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// NewOpenAIProvider creates a new OpenAI-compatible provider instance
+func NewOpenAIProvider(config OpenAIConfig) *OpenAIProvider {
+	if config.APIKey == "" {
+		config.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultOpenAIBaseURL
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.MaxTokens <= 0 {
+		config.MaxTokens = DefaultMaxTokens
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Minute}
+	}
+
+	return &OpenAIProvider{
+		config: config,
+		client: client,
+	}
+}
+
+// Complete sends the request to the chat completions endpoint and returns the reply
+func (p *OpenAIProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	resp, err := p.send(ctx, req, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(out.Choices) == 0 {
+		return nil, fmt.Errorf("response contained no choices")
+	}
+
+	choice := out.Choices[0]
+	msg := Message{Role: RoleAssistant, Content: []ContentBlock{}}
+	if choice.Message.Content != nil && *choice.Message.Content != "" {
+		msg.Content = append(msg.Content, ContentBlock{Type: ContentText, Text: *choice.Message.Content})
+	}
+	for i, call := range choice.Message.ToolCalls {
+		msg.Content = append(msg.Content, NewToolUseBlock(toolCallID(call.ID, i), call.Function.Name, toolArguments(call.Function.Arguments)))
+	}
+
+	result := &Response{
+		ID:         out.ID,
+		Model:      out.Model,
+		Message:    msg,
+		StopReason: openAIStopReason(choice.FinishReason),
+	}
+	if out.Usage != nil {
+		result.Usage = Usage{InputTokens: out.Usage.PromptTokens, OutputTokens: out.Usage.CompletionTokens}
+	}
+
+	return result, nil
+}
+
+// Stream sends the request with streaming enabled, calling handler for every
+// text or tool call delta, and returns the assembled reply
+func (p *OpenAIProvider) Stream(ctx context.Context, req *Request, handler StreamHandler) (*Response, error) {
+	resp, err := p.send(ctx, req, true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result := &Response{StopReason: StopEndTurn}
+	var text strings.Builder
+	calls := make(map[int]*openAIToolCall)
+
+	// Text deltas use block index 0 and tool calls start at index 1
+	emit := func(delta Delta) {
+		if handler != nil {
+			handler(delta)
+		}
+	}
+
+	err = readSSE(resp.Body, func(event sseEvent) error {
+		if event.Data == "[DONE]" {
+			return errStreamDone
+		}
+
+		var chunk openAIChunk
+		if err := json.Unmarshal([]byte(event.Data), &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+
+		if chunk.ID != "" {
+			result.ID = chunk.ID
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.Usage = Usage{InputTokens: chunk.Usage.PromptTokens, OutputTokens: chunk.Usage.CompletionTokens}
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				emit(Delta{Type: DeltaText, Index: 0, Text: choice.Delta.Content})
+			}
+
+			for i, fragment := range choice.Delta.ToolCalls {
+				index := i
+				if fragment.Index != nil {
+					index = *fragment.Index
+				}
+
+				call, exists := calls[index]
+				if !exists {
+					call = &openAIToolCall{ID: toolCallID(fragment.ID, index)}
+					calls[index] = call
+				}
+				if fragment.Function.Name != "" {
+					call.Function.Name += fragment.Function.Name
+				}
+				call.Function.Arguments += fragment.Function.Arguments
+
+				delta := Delta{Type: DeltaToolUse, Index: index + 1, InputJSON: fragment.Function.Arguments}
+				if !exists {
+					delta.ID = call.ID
+					delta.Name = fragment.Function.Name
+				}
+				emit(delta)
+			}
+
+			if choice.FinishReason != nil && *choice.FinishReason != "" {
+				result.StopReason = openAIStopReason(*choice.FinishReason)
+			}
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStreamDone) {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	msg := Message{Role: RoleAssistant, Content: []ContentBlock{}}
+	if text.Len() > 0 {
+		msg.Content = append(msg.Content, ContentBlock{Type: ContentText, Text: text.String()})
+	}
+
+	indexes := make([]int, 0, len(calls))
+	for index := range calls {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		call := calls[index]
+		msg.Content = append(msg.Content, NewToolUseBlock(call.ID, call.Function.Name, toolArguments(call.Function.Arguments)))
+	}
+
+	result.Message = msg
+	return result, nil
+}
+
+// send posts the request and returns the response once the status is checked
+func (p *OpenAIProvider) send(ctx context.Context, req *Request, stream bool) (*http.Response, error) {
+	wire, err := p.buildRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if stream {
+		wire.Stream = true
+		wire.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+
+	body, err := json.Marshal(wire)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	if p.config.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+	}
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return nil, parseOpenAIError(resp.StatusCode, data)
+	}
+
+	return resp, nil
+}
+
+// buildRequest converts a provider-neutral request to the chat completions format
+func (p *OpenAIProvider) buildRequest(req *Request) (*openAIRequest, error) {
+	out := &openAIRequest{
+		Model:       req.Model,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		Stop:        req.StopSequences,
+	}
+	if out.Model == "" {
+		out.Model = p.config.Model
+	}
+	if out.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+	if out.MaxTokens <= 0 {
+		out.MaxTokens = p.config.MaxTokens
+	}
+
+	if req.System != "" {
+		out.Messages = append(out.Messages, openAIMessage{Role: "system", Content: stringPtr(req.System)})
+	}
+
+	for _, msg := range req.Messages {
+		out.Messages = append(out.Messages, convertOpenAIMessage(msg)...)
+	}
+
+	for _, tool := range req.Tools {
+		out.Tools = append(out.Tools, openAITool{
+			Type: "function",
+			Function: openAIToolFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.InputSchema,
+			},
+		})
+	}
+
+	return out, nil
+}
+
+// convertOpenAIMessage maps one message to chat completions messages. Tool
+// results become separate "tool" role messages.
+func convertOpenAIMessage(msg Message) []openAIMessage {
+	var out []openAIMessage
+	var text strings.Builder
+	var calls []openAIToolCall
+
+	for _, block := range msg.Content {
+		switch block.Type {
+		case ContentText:
+			text.WriteString(block.Text)
+		case ContentToolUse:
+			args := string(block.Input)
+			if args == "" {
+				args = "{}"
+			}
+			calls = append(calls, openAIToolCall{
+				ID:       block.ID,
+				Type:     "function",
+				Function: openAIFunctionCall{Name: block.Name, Arguments: args},
+			})
+		case ContentToolResult:
+			out = append(out, openAIMessage{Role: "tool", ToolCallID: block.ToolUseID, Content: stringPtr(block.Content)})
+		}
+	}
+
+	if text.Len() > 0 || len(calls) > 0 {
+		m := openAIMessage{Role: string(msg.Role), ToolCalls: calls}
+		if text.Len() > 0 {
+			m.Content = stringPtr(text.String())
+		}
+		out = append(out, m)
+	}
+
+	return out
+}
+
+// openAIStopReason maps a finish_reason to a StopReason
+func openAIStopReason(reason string) StopReason {
+	switch reason {
+	case "tool_calls", "function_call":
+		return StopToolUse
+	case "length":
+		return StopMaxTokens
+	default:
+		return StopEndTurn
+	}
+}
+
+// toolCallID returns the call ID, generating one for servers that omit it
+func toolCallID(id string, index int) string {
+	if id != "" {
+		return id
+	}
+	return fmt.Sprintf("call_%d", index)
+}
+
+// toolArguments converts a JSON arguments string to a raw input object
+func toolArguments(args string) json.RawMessage {
+	if strings.TrimSpace(args) == "" {
+		return json.RawMessage(`{}`)
+	}
+	return json.RawMessage(args)
+}
+
+// parseOpenAIError builds an APIError from an error response body
+func parseOpenAIError(status int, data []byte) error {
+	var body openAIError
+	if err := json.Unmarshal(data, &body); err == nil && body.Error.Message != "" {
+		return &APIError{StatusCode: status, Type: body.Error.Type, Message: body.Error.Message}
+	}
+
+	return &APIError{StatusCode: status, Message: strings.TrimSpace(string(data))}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,334 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newOpenAITestServer(t *testing.T, handler func(t *testing.T, body map[string]interface{}, w http.ResponseWriter)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Expected path /v1/chat/completions, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Expected Authorization header, got '%s'", r.Header.Get("Authorization"))
+		}
+
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Fatalf("Request body is not valid JSON: %v", err)
+		}
+
+		handler(t, body, w)
+	}))
+}
+
+func newTestOpenAIProvider(server *httptest.Server) *OpenAIProvider {
+	return NewOpenAIProvider(OpenAIConfig{
+		APIKey:  "test-key",
+		BaseURL: server.URL + "/v1/",
+		Model:   "test-model",
+	})
+}
+
+func TestOpenAIProvider_Complete_Text(t *testing.T) {
+	server := newOpenAITestServer(t, func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		if body["model"] != "test-model" {
+			t.Errorf("Expected model 'test-model', got %v", body["model"])
+		}
+		if _, ok := body["stream"]; ok {
+			t.Error("Expected stream to be omitted")
+		}
+
+		messages := body["messages"].([]interface{})
+		if len(messages) != 2 {
+			t.Fatalf("Expected system and user messages, got %d", len(messages))
+		}
+		system := messages[0].(map[string]interface{})
+		if system["role"] != "system" || system["content"] != "be brief" {
+			t.Errorf("Unexpected system message: %v", system)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "chatcmpl-1",
+			"model": "test-model",
+			"choices": [{"index": 0, "message": {"role": "assistant", "content": "Hello there"}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 3}
+		}`))
+	})
+	defer server.Close()
+
+	resp, err := newTestOpenAIProvider(server).Complete(context.Background(), &Request{
+		System:   "be brief",
+		Messages: []Message{NewTextMessage(RoleUser, "hi")},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	if resp.Message.Text() != "Hello there" {
+		t.Errorf("Expected 'Hello there', got '%s'", resp.Message.Text())
+	}
+	if resp.StopReason != StopEndTurn {
+		t.Errorf("Expected end_turn, got %s", resp.StopReason)
+	}
+	if resp.Usage.InputTokens != 12 || resp.Usage.OutputTokens != 3 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIProvider_Complete_ToolCalls(t *testing.T) {
+	server := newOpenAITestServer(t, func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		tools := body["tools"].([]interface{})
+		tool := tools[0].(map[string]interface{})
+		if tool["type"] != "function" {
+			t.Errorf("Expected function tool, got %v", tool["type"])
+		}
+		function := tool["function"].(map[string]interface{})
+		if function["name"] != "read" {
+			t.Errorf("Expected tool 'read', got %v", function["name"])
+		}
+		if _, ok := function["parameters"].(map[string]interface{}); !ok {
+			t.Error("Expected parameters schema")
+		}
+
+		// user, assistant with tool_calls, tool result
+		messages := body["messages"].([]interface{})
+		if len(messages) != 3 {
+			t.Fatalf("Expected 3 messages, got %d", len(messages))
+		}
+
+		assistant := messages[1].(map[string]interface{})
+		calls := assistant["tool_calls"].([]interface{})
+		call := calls[0].(map[string]interface{})
+		if call["id"] != "call_a" {
+			t.Errorf("Expected call id 'call_a', got %v", call["id"])
+		}
+		fn := call["function"].(map[string]interface{})
+		if fn["arguments"] != `{"path":"a.txt"}` {
+			t.Errorf("Expected arguments string, got %v", fn["arguments"])
+		}
+
+		result := messages[2].(map[string]interface{})
+		if result["role"] != "tool" || result["tool_call_id"] != "call_a" || result["content"] != "contents" {
+			t.Errorf("Unexpected tool message: %v", result)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"choices": [{
+				"message": {
+					"role": "assistant",
+					"content": null,
+					"tool_calls": [
+						{"id": "call_b", "type": "function", "function": {"name": "read", "arguments": "{\"path\":\"b.txt\"}"}},
+						{"type": "function", "function": {"name": "list", "arguments": ""}}
+					]
+				},
+				"finish_reason": "tool_calls"
+			}]
+		}`))
+	})
+	defer server.Close()
+
+	resp, err := newTestOpenAIProvider(server).Complete(context.Background(), &Request{
+		Messages: []Message{
+			NewTextMessage(RoleUser, "read a.txt"),
+			{Role: RoleAssistant, Content: []ContentBlock{NewToolUseBlock("call_a", "read", json.RawMessage(`{"path":"a.txt"}`))}},
+			{Role: RoleUser, Content: []ContentBlock{NewToolResultBlock("call_a", "contents", false)}},
+		},
+		Tools: []ToolDefinition{{
+			Name:        "read",
+			Description: "Read a file",
+			InputSchema: map[string]interface{}{"type": "object"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	if resp.StopReason != StopToolUse {
+		t.Errorf("Expected tool_use, got %s", resp.StopReason)
+	}
+
+	uses := resp.Message.ToolUses()
+	if len(uses) != 2 {
+		t.Fatalf("Expected 2 tool uses, got %d", len(uses))
+	}
+	if uses[0].ID != "call_b" || string(uses[0].Input) != `{"path":"b.txt"}` {
+		t.Errorf("Unexpected first tool use: %+v", uses[0])
+	}
+	if uses[1].ID != "call_1" {
+		t.Errorf("Expected generated id 'call_1', got '%s'", uses[1].ID)
+	}
+	if string(uses[1].Input) != `{}` {
+		t.Errorf("Expected empty arguments to become {}, got %s", uses[1].Input)
+	}
+}
+
+func TestOpenAIProvider_Stream(t *testing.T) {
+	chunks := []string{
+		`{"id":"chatcmpl-2","model":"test-model","choices":[{"delta":{"role":"assistant","content":"Let me "}}]}`,
+		`{"choices":[{"delta":{"content":"check."}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_x","type":"function","function":{"name":"grep","arguments":""}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"pattern\":"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"TODO\"}"}}]}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":20,"completion_tokens":9}}`,
+	}
+
+	server := newOpenAITestServer(t, func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		if body["stream"] != true {
+			t.Errorf("Expected stream true, got %v", body["stream"])
+		}
+		options, _ := body["stream_options"].(map[string]interface{})
+		if options["include_usage"] != true {
+			t.Errorf("Expected include_usage, got %v", body["stream_options"])
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+	defer server.Close()
+
+	var deltas []Delta
+	resp, err := newTestOpenAIProvider(server).Stream(context.Background(), &Request{
+		Messages: []Message{NewTextMessage(RoleUser, "find todos")},
+	}, func(d Delta) {
+		deltas = append(deltas, d)
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	var text strings.Builder
+	var input strings.Builder
+	for _, d := range deltas {
+		switch d.Type {
+		case DeltaText:
+			text.WriteString(d.Text)
+		case DeltaToolUse:
+			input.WriteString(d.InputJSON)
+		}
+	}
+	if text.String() != "Let me check." {
+		t.Errorf("Expected streamed text 'Let me check.', got '%s'", text.String())
+	}
+	if input.String() != `{"pattern":"TODO"}` {
+		t.Errorf("Expected streamed input, got '%s'", input.String())
+	}
+	if deltas[2].ID != "call_x" || deltas[2].Name != "grep" || deltas[2].Index != 1 {
+		t.Errorf("Expected first tool delta to carry id and name, got %+v", deltas[2])
+	}
+
+	if resp.ID != "chatcmpl-2" || resp.Model != "test-model" {
+		t.Errorf("Unexpected response metadata: %s %s", resp.ID, resp.Model)
+	}
+	if resp.Message.Text() != "Let me check." {
+		t.Errorf("Expected assembled text, got '%s'", resp.Message.Text())
+	}
+	uses := resp.Message.ToolUses()
+	if len(uses) != 1 || uses[0].Name != "grep" || string(uses[0].Input) != `{"pattern":"TODO"}` {
+		t.Errorf("Unexpected tool uses: %+v", uses)
+	}
+	if resp.StopReason != StopToolUse {
+		t.Errorf("Expected tool_use, got %s", resp.StopReason)
+	}
+	if resp.Usage.InputTokens != 20 || resp.Usage.OutputTokens != 9 {
+		t.Errorf("Unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIProvider_APIError(t *testing.T) {
+	server := newOpenAITestServer(t, func(t *testing.T, body map[string]interface{}, w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": {"message": "model 'missing' not found", "type": "invalid_request_error", "code": null}}`))
+	})
+	defer server.Close()
+
+	_, err := newTestOpenAIProvider(server).Complete(context.Background(), &Request{
+		Messages: []Message{NewTextMessage(RoleUser, "hi")},
+	})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", apiErr.StatusCode)
+	}
+	if apiErr.Message != "model 'missing' not found" {
+		t.Errorf("Unexpected message: %s", apiErr.Message)
+	}
+}
+
+func TestOpenAIProvider_LocalServerWithoutKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Expected no Authorization header, got '%s'", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "ok"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider(OpenAIConfig{BaseURL: server.URL, Model: "llama3"})
+	resp, err := provider.Complete(context.Background(), &Request{
+		Messages: []Message{NewTextMessage(RoleUser, "hi")},
+	})
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if resp.Message.Text() != "ok" {
+		t.Errorf("Expected 'ok', got '%s'", resp.Message.Text())
+	}
+}
+
+func TestOpenAIProvider_MissingModel(t *testing.T) {
+	provider := NewOpenAIProvider(OpenAIConfig{BaseURL: "http://127.0.0.1:0"})
+	_, err := provider.Complete(context.Background(), &Request{
+		Messages: []Message{NewTextMessage(RoleUser, "hi")},
+	})
+	if err == nil {
+		t.Error("Expected error when no model is configured")
+	}
+}
+
+func TestReadSSE(t *testing.T) {
+	input := "event: message\ndata: line one\ndata: line two\n\n: comment\ndata:trailing"
+
+	var events []sseEvent
+	err := readSSE(strings.NewReader(input), func(e sseEvent) error {
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readSSE failed: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if events[0].Event != "message" || events[0].Data != "line one\nline two" {
+		t.Errorf("Unexpected first event: %+v", events[0])
+	}
+	if events[1].Data != "trailing" {
+		t.Errorf("Unexpected second event: %+v", events[1])
+	}
+}
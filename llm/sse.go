@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// sseEvent is a single server-sent event
+type sseEvent struct {
+	Event string
+	Data  string
+}
+
+// readSSE parses a text/event-stream body, calling fn for every complete
+// event. Reading stops at EOF or when fn returns an error.
+func readSSE(r io.Reader, fn func(sseEvent) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var event sseEvent
+	var data []string
+
+	dispatch := func() error {
+		if len(data) == 0 {
+			event = sseEvent{}
+			return nil
+		}
+		event.Data = strings.Join(data, "\n")
+		err := fn(event)
+		event = sseEvent{}
+		data = data[:0]
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// A blank line terminates the event
+		if line == "" {
+			if err := dispatch(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Comment lines start with a colon
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// Flush a trailing event that wasn't followed by a blank line
+	return dispatch()
+}
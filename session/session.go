@@ -0,0 +1,417 @@
+// Package session records agar conversations to disk so they can be resumed.
+//
+// A session is an append-only JSON Lines file under ~/.agar/sessions/<id>.jsonl.
+// Every message and tool event is written as one Entry as it happens, so a
+// session survives crashes and can be replayed into both the transcript and
+// the model context.
+//
+// # Basic Usage
+//
+//	store, _ := session.NewStore("")
+//	sess, _ := store.Create()
+//	defer sess.Close()
+//
+//	a := agent.New(agent.Config{
+//	    Provider: provider,
+//	    OnEvent:  func(e agent.Event) { sess.RecordEvent(e) },
+//	})
+//
+//	// Later
+//	entries, _ := store.Load(sess.ID())
+//	a.SetHistory(session.Messages(entries))
+package session
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geoffjay/agar/agent"
+	"github.com/geoffjay/agar/llm"
+	"github.com/geoffjay/agar/tools"
+)
+
+// EntryType identifies the kind of a session entry
+type EntryType string
+
+const (
+	EntryMessage    EntryType = "message"     // A conversation message
+	EntryToolCall   EntryType = "tool_call"   // A tool call requested by the model
+	EntryToolResult EntryType = "tool_result" // The result of a tool call
+)
+
+// Entry is a single line of a session file
+type Entry struct {
+	Type     EntryType              `json:"type"`
+	Time     time.Time              `json:"time"`
+	Message  *llm.Message           `json:"message,omitempty"`
+	ToolCall *llm.ContentBlock      `json:"tool_call,omitempty"`
+	Result   *tools.ToolResult      `json:"result,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Info summarizes a stored session
+type Info struct {
+	ID       string    `json:"id"`
+	Path     string    `json:"path"`
+	Modified time.Time `json:"modified"`
+	Size     int64     `json:"size"`
+}
+
+// ErrNotFound is returned when a session does not exist
+var ErrNotFound = errors.New("session not found")
+
+// Store manages session files in a directory
+type Store struct {
+	dir string
+}
+
+// DefaultDir returns the default session directory, ~/.agar/sessions
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".agar", "sessions"), nil
+}
+
+// NewStore creates a store for the given directory. An empty dir uses DefaultDir.
+func NewStore(dir string) (*Store, error) {
+	if dir == "" {
+		defaultDir, err := DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = defaultDir
+	}
+
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the store directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Create starts a new session with a fresh ID
+func (s *Store) Create() (*Session, error) {
+	return s.open(newID(), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND)
+}
+
+// Open reopens an existing session so that new entries are appended to it
+func (s *Store) Open(id string) (*Session, error) {
+	sess, err := s.open(id, os.O_RDWR|os.O_APPEND)
+	if err != nil {
+		return nil, err
+	}
+
+	// Terminate a partial final line left by a crash so the next entry
+	// starts on a line of its own
+	if info, err := sess.file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := sess.file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			if _, err := sess.file.Write([]byte{'\n'}); err != nil {
+				sess.Close()
+				return nil, fmt.Errorf("failed to write session: %w", err)
+			}
+		}
+	}
+
+	return sess, nil
+}
+
+// Load reads all entries of a session
+func (s *Store) Load(id string) ([]Entry, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to open session: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	line := 0
+	var partial error
+	for scanner.Scan() {
+		line++
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		// Only the final line may be invalid; a crash can leave it partial
+		if partial != nil {
+			return nil, partial
+		}
+
+		var entry Entry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			partial = fmt.Errorf("invalid session entry on line %d: %w", line, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+
+	return entries, nil
+}
+
+// List returns the stored sessions, most recently modified first
+func (s *Store) List() ([]Info, error) {
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Info{}, nil
+		}
+		return nil, fmt.Errorf("failed to read session directory: %w", err)
+	}
+
+	sessions := make([]Info, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			continue
+		}
+
+		sessions = append(sessions, Info{
+			ID:       strings.TrimSuffix(name, ".jsonl"),
+			Path:     filepath.Join(s.dir, name),
+			Modified: info.ModTime(),
+			Size:     info.Size(),
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Modified.After(sessions[j].Modified)
+	})
+
+	return sessions, nil
+}
+
+// path returns the file path for a session ID, rejecting IDs that could
+// escape the store directory
+func (s *Store) path(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid session id: %q", id)
+	}
+	return filepath.Join(s.dir, id+".jsonl"), nil
+}
+
+// open opens the session file with the given flags
+func (s *Store) open(id string, flag int) (*Session, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, flag, 0600)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to open session: %w", err)
+	}
+
+	return &Session{id: id, path: path, file: file}, nil
+}
+
+// Session is an open session file that entries are appended to
+type Session struct {
+	id   string
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+// ID returns the session ID
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Path returns the session file path
+func (s *Session) Path() string {
+	return s.path
+}
+
+// Append writes an entry to the session. The time is set if it is zero.
+func (s *Session) Append(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode session entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("session %s is closed", s.id)
+	}
+
+	// Each entry is a single write so concurrent appends never interleave
+	if _, err := s.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write session entry: %w", err)
+	}
+	return nil
+}
+
+// AppendMessage records a conversation message
+func (s *Session) AppendMessage(msg llm.Message) error {
+	return s.Append(Entry{Type: EntryMessage, Message: &msg})
+}
+
+// RecordEvent records an agent event. Messages, tool calls and tool results
+// are written; other events are ignored.
+func (s *Session) RecordEvent(e agent.Event) error {
+	switch e.Type {
+	case agent.EventMessage:
+		return s.Append(Entry{Type: EntryMessage, Message: e.Message})
+	case agent.EventToolCall:
+		return s.Append(Entry{Type: EntryToolCall, ToolCall: e.ToolCall})
+	case agent.EventToolResult:
+		return s.Append(Entry{Type: EntryToolResult, ToolCall: e.ToolCall, Result: e.Result})
+	}
+	return nil
+}
+
+// Close closes the session file
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// Messages returns the conversation messages recorded in entries, in order,
+// ready to be passed to agent.SetHistory. Tool calls left without a result,
+// because a run was interrupted before the tool returned, are answered with
+// an error result so providers accept the history.
+func Messages(entries []Entry) []llm.Message {
+	messages := make([]llm.Message, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type == EntryMessage && entry.Message != nil {
+			messages = append(messages, *entry.Message)
+		}
+	}
+	return answerToolCalls(messages)
+}
+
+// answerToolCalls adds a "not executed" result for every tool call that the
+// message after it does not answer
+func answerToolCalls(messages []llm.Message) []llm.Message {
+	answered := make([]llm.Message, 0, len(messages))
+	for i, msg := range messages {
+		answered = append(answered, msg)
+		if msg.Role != llm.RoleAssistant {
+			continue
+		}
+		uses := msg.ToolUses()
+		if len(uses) == 0 {
+			continue
+		}
+
+		var next *llm.Message
+		if i+1 < len(messages) && messages[i+1].Role == llm.RoleUser {
+			next = &messages[i+1]
+		}
+		results := map[string]bool{}
+		if next != nil {
+			for _, block := range next.Content {
+				if block.Type == llm.ContentToolResult {
+					results[block.ToolUseID] = true
+				}
+			}
+		}
+
+		var missing []llm.ContentBlock
+		for _, use := range uses {
+			if !results[use.ID] {
+				missing = append(missing, cancelledToolResult(use))
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		// Results must come first in the user message after the call
+		if next != nil {
+			next.Content = append(missing, next.Content...)
+			continue
+		}
+		answered = append(answered, llm.Message{Role: llm.RoleUser, Content: missing})
+	}
+	return answered
+}
+
+// cancelledToolResult builds the error result for a tool call that never
+// returned, matching the one the agent records for calls it skips
+func cancelledToolResult(use llm.ContentBlock) llm.ContentBlock {
+	content, _ := json.Marshal(&tools.ToolResult{Success: false, Error: fmt.Sprintf("not executed: %v", context.Canceled)})
+	return llm.NewToolResultBlock(use.ID, string(content), true)
+}
+
+// Title returns the text of the first user message, shortened to max
+// characters, for listing sessions
+func Title(entries []Entry, max int) string {
+	for _, msg := range Messages(entries) {
+		if msg.Role != llm.RoleUser {
+			continue
+		}
+		text := strings.Join(strings.Fields(msg.Text()), " ")
+		if text == "" {
+			continue
+		}
+		if runes := []rune(text); max > 3 && len(runes) > max {
+			text = string(runes[:max-3]) + "..."
+		}
+		return text
+	}
+	return ""
+}
+
+// newID returns a sortable, unique session ID such as 20250102-150405-a1b2c3
+func newID() string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,300 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/geoffjay/agar/agent"
+	"github.com/geoffjay/agar/llm"
+	"github.com/geoffjay/agar/tools"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := NewStore(filepath.Join(t.TempDir(), "sessions"))
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	return store
+}
+
+func TestStore_CreateAppendLoad(t *testing.T) {
+	store := newTestStore(t)
+
+	sess, err := store.Create()
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	if filepath.Dir(sess.Path()) != store.Dir() || !strings.HasSuffix(sess.Path(), sess.ID()+".jsonl") {
+		t.Errorf("Unexpected session path: %s", sess.Path())
+	}
+
+	use := llm.NewToolUseBlock("call_1", "read", json.RawMessage(`{"path":"a.txt"}`))
+	entries := []Entry{
+		{Type: EntryMessage, Message: &llm.Message{Role: llm.RoleUser, Content: []llm.ContentBlock{{Type: llm.ContentText, Text: "Read a.txt"}}}},
+		{Type: EntryToolCall, ToolCall: &use},
+		{Type: EntryToolResult, ToolCall: &use, Result: &tools.ToolResult{Success: true, Data: "contents"}},
+	}
+	for _, entry := range entries {
+		if err := sess.Append(entry); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	if err := sess.AppendMessage(llm.NewTextMessage(llm.RoleAssistant, "It says contents")); err != nil {
+		t.Fatalf("AppendMessage failed: %v", err)
+	}
+	sess.Close()
+
+	loaded, err := store.Load(sess.ID())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(loaded))
+	}
+	if loaded[0].Time.IsZero() {
+		t.Error("Expected entry time to be set")
+	}
+	if loaded[1].ToolCall.Name != "read" || string(loaded[1].ToolCall.Input) != `{"path":"a.txt"}` {
+		t.Errorf("Unexpected tool call: %+v", loaded[1].ToolCall)
+	}
+	if !loaded[2].Result.Success {
+		t.Error("Expected successful tool result")
+	}
+
+	messages := Messages(loaded)
+	if len(messages) != 2 || messages[1].Text() != "It says contents" {
+		t.Errorf("Unexpected messages: %+v", messages)
+	}
+	if title := Title(loaded, 20); title != "Read a.txt" {
+		t.Errorf("Expected title 'Read a.txt', got '%s'", title)
+	}
+}
+
+func TestStore_OpenAppendsAfterPartialLine(t *testing.T) {
+	store := newTestStore(t)
+
+	sess, _ := store.Create()
+	sess.AppendMessage(llm.NewTextMessage(llm.RoleUser, "first"))
+	sess.Close()
+
+	// Simulate a crash in the middle of a write
+	file, _ := os.OpenFile(sess.Path(), os.O_WRONLY|os.O_APPEND, 0600)
+	file.WriteString(`{"type":"message","mess`)
+	file.Close()
+
+	// The partial final line is skipped
+	entries, err := store.Load(sess.ID())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+
+	reopened, err := store.Open(sess.ID())
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	reopened.AppendMessage(llm.NewTextMessage(llm.RoleUser, "second"))
+	reopened.Close()
+
+	// The partial line is now in the middle of the file and is an error
+	if _, err := store.Load(sess.ID()); err == nil {
+		t.Fatal("Expected error for invalid line in the middle of a session")
+	}
+}
+
+func TestStore_NotFoundAndInvalidIDs(t *testing.T) {
+	store := newTestStore(t)
+
+	if _, err := store.Load("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := store.Open("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from Open, got %v", err)
+	}
+
+	for _, id := range []string{"", "../escape", "a/b", ".hidden"} {
+		if _, err := store.Load(id); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected invalid id error for %q, got %v", id, err)
+		}
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	store := newTestStore(t)
+
+	sessions, err := store.List()
+	if err != nil {
+		t.Fatalf("List failed on missing directory: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("Expected no sessions, got %d", len(sessions))
+	}
+
+	first, _ := store.Create()
+	first.Close()
+	second, _ := store.Create()
+	second.AppendMessage(llm.NewTextMessage(llm.RoleUser, "hi"))
+	second.Close()
+
+	// Make the first session clearly older
+	old := mustModTime(t, second.Path()).Add(-time.Minute)
+	os.Chtimes(first.Path(), old, old)
+
+	sessions, err = store.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+	if sessions[0].ID != second.ID() {
+		t.Errorf("Expected most recent session first, got %s", sessions[0].ID)
+	}
+}
+
+func TestSession_RecordAgentEvents(t *testing.T) {
+	store := newTestStore(t)
+	sess, _ := store.Create()
+	defer sess.Close()
+
+	calls := 0
+	provider := llm.ProviderFunc(func(ctx context.Context, req *llm.Request) (*llm.Response, error) {
+		calls++
+		if calls == 1 {
+			return &llm.Response{
+				Message: llm.Message{Role: llm.RoleAssistant, Content: []llm.ContentBlock{
+					llm.NewToolUseBlock("call_1", "missing", json.RawMessage(`{}`)),
+				}},
+				StopReason: llm.StopToolUse,
+			}, nil
+		}
+		return &llm.Response{Message: llm.NewTextMessage(llm.RoleAssistant, "done"), StopReason: llm.StopEndTurn}, nil
+	})
+
+	a := agent.New(agent.Config{
+		Provider: provider,
+		Tools:    tools.NewToolRegistry(),
+		OnEvent: func(e agent.Event) {
+			if err := sess.RecordEvent(e); err != nil {
+				t.Errorf("RecordEvent failed: %v", err)
+			}
+		},
+	})
+	if _, err := a.Run(context.Background(), "go"); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	entries, err := store.Load(sess.ID())
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	var types []EntryType
+	for _, entry := range entries {
+		types = append(types, entry.Type)
+	}
+	expected := []EntryType{EntryMessage, EntryMessage, EntryToolCall, EntryToolResult, EntryMessage, EntryMessage}
+	if len(types) != len(expected) {
+		t.Fatalf("Expected entries %v, got %v", expected, types)
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Errorf("Entry %d: expected %s, got %s", i, expected[i], types[i])
+		}
+	}
+
+	// The recorded messages rebuild the agent history exactly
+	restored := Messages(entries)
+	history := a.History()
+	if len(restored) != len(history) {
+		t.Fatalf("Expected %d messages, got %d", len(history), len(restored))
+	}
+	for i := range history {
+		want, _ := json.Marshal(history[i])
+		got, _ := json.Marshal(restored[i])
+		if string(want) != string(got) {
+			t.Errorf("Message %d differs:\nwant %s\ngot  %s", i, want, got)
+		}
+	}
+}
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		text string
+		max  int
+		want string
+	}{
+		{"Read a.txt", 20, "Read a.txt"},
+		{"Summarize   the\nREADME file please", 20, "Summarize the REA..."},
+		{"Überprüfe die Größe aller Dateien", 12, "Überprüfe..."},
+		{"日本語のファイルを読んで", 8, "日本語のフ..."},
+	}
+
+	for _, tt := range tests {
+		msg := llm.NewTextMessage(llm.RoleUser, tt.text)
+		title := Title([]Entry{{Type: EntryMessage, Message: &msg}}, tt.max)
+		if title != tt.want {
+			t.Errorf("Title(%q, %d): expected %q, got %q", tt.text, tt.max, tt.want, title)
+		}
+		if !utf8.ValidString(title) {
+			t.Errorf("Title(%q, %d) is not valid UTF-8: %q", tt.text, tt.max, title)
+		}
+	}
+}
+
+func TestMessages_AnswersInterruptedToolCalls(t *testing.T) {
+	prompt := llm.NewTextMessage(llm.RoleUser, "go")
+	call := llm.Message{Role: llm.RoleAssistant, Content: []llm.ContentBlock{
+		llm.NewToolUseBlock("call_1", "shell", json.RawMessage(`{"command": "sleep 60"}`)),
+	}}
+	entries := []Entry{
+		{Type: EntryMessage, Message: &prompt},
+		{Type: EntryMessage, Message: &call},
+		{Type: EntryToolCall, ToolCall: &call.Content[0]},
+	}
+
+	// The session ended while the tool was running
+	messages := Messages(entries)
+	if len(messages) != 3 || messages[2].Role != llm.RoleUser {
+		t.Fatalf("Expected a result message after the call, got %+v", messages)
+	}
+	result := messages[2].Content[0]
+	if result.Type != llm.ContentToolResult || result.ToolUseID != "call_1" || !result.IsError || !strings.Contains(result.Content, "not executed") {
+		t.Errorf("Expected a cancelled result for call_1, got %+v", result)
+	}
+
+	// The conversation went on after resuming: the result goes first in the
+	// next user message
+	next := llm.NewTextMessage(llm.RoleUser, "try again")
+	entries = append(entries, Entry{Type: EntryMessage, Message: &next})
+	messages = Messages(entries)
+	if len(messages) != 3 {
+		t.Fatalf("Expected the result to join the next message, got %+v", messages)
+	}
+	if content := messages[2].Content; len(content) != 2 || content[0].ToolUseID != "call_1" || content[1].Text != "try again" {
+		t.Errorf("Expected the result before the text, got %+v", content)
+	}
+	if len(next.Content) != 1 {
+		t.Error("Expected the recorded message to be left unchanged")
+	}
+}
+
+func mustModTime(t *testing.T, path string) time.Time {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	return info.ModTime()
+}
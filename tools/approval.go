@@ -0,0 +1,344 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ApprovalMode is what a policy requires before a tool call runs
+type ApprovalMode string
+
+const (
+	ApproveAlways ApprovalMode = "always" // Run without asking
+	ApproveAsk    ApprovalMode = "ask"    // Ask the user first
+	ApproveDeny   ApprovalMode = "deny"   // Never run
+)
+
+// DefaultApprovalModes are used for calls no rule matches: read-only tools
+// run without asking, everything else needs approval
+var DefaultApprovalModes = map[Risk]ApprovalMode{
+	RiskReadOnly:    ApproveAlways,
+	RiskMutating:    ApproveAsk,
+	RiskDestructive: ApproveAsk,
+	RiskNetwork:     ApproveAsk,
+}
+
+// ApprovalRule sets the approval mode for matching tool calls. Empty fields
+// match anything.
+type ApprovalRule struct {
+	Tool string       // Tool name, or a path.Match pattern such as "*"
+	Risk Risk         // Risk class of the tool
+	Path string       // MatchPath pattern for the paths in the call, such as "docs/**"
+	Mode ApprovalMode // Mode applied when the rule matches
+}
+
+// ApprovalPolicy decides which tool calls run, need approval or are denied.
+// Rules are checked in order and the first match wins; calls no rule matches
+// use Defaults for the tool's risk class, falling back to
+// DefaultApprovalModes.
+//
+// A rule with a Path matches a deny rule if any path in the call matches, and
+// otherwise only if every path matches, so allowing "docs/**" never allows a
+// call that also touches a file outside docs.
+type ApprovalPolicy struct {
+	Rules    []ApprovalRule
+	Defaults map[Risk]ApprovalMode
+}
+
+// Mode returns the approval mode for calling tool with params
+func (p ApprovalPolicy) Mode(tool Tool, params json.RawMessage) ApprovalMode {
+	risk := RiskOf(tool)
+	paths := callPaths(tool, params)
+
+	for _, rule := range p.Rules {
+		if rule.matches(tool.Name(), risk, paths) {
+			return rule.Mode
+		}
+	}
+
+	if mode, ok := p.Defaults[risk]; ok {
+		return mode
+	}
+	if mode, ok := DefaultApprovalModes[risk]; ok {
+		return mode
+	}
+	return ApproveAsk
+}
+
+// matches reports whether the rule applies to a call
+func (r ApprovalRule) matches(name string, risk Risk, paths []string) bool {
+	if r.Tool != "" {
+		if ok, err := path.Match(r.Tool, name); err != nil || !ok {
+			return false
+		}
+	}
+	if r.Risk != "" && r.Risk != risk {
+		return false
+	}
+	if r.Path == "" {
+		return true
+	}
+	if len(paths) == 0 {
+		return false
+	}
+
+	for _, p := range paths {
+		matched := matchCallPath(r.Path, p)
+		if r.Mode == ApproveDeny && matched {
+			return true
+		}
+		if r.Mode != ApproveDeny && !matched {
+			return false
+		}
+	}
+	return r.Mode != ApproveDeny
+}
+
+// matchCallPath matches a pattern against a path as given, and against its
+// absolute form and its form relative to the working directory
+func matchCallPath(pattern, p string) bool {
+	candidates := []string{filepath.Clean(p)}
+	if abs, err := filepath.Abs(p); err == nil {
+		candidates = append(candidates, abs)
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				candidates = append(candidates, rel)
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		if MatchPath(pattern, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathParams are parameter names that hold file paths
+var pathParams = map[string]bool{
+	"path":        true,
+	"paths":       true,
+	"files":       true,
+	"source":      true,
+	"destination": true,
+	"working_dir": true,
+}
+
+// ParamPaths returns the file paths named in tool parameters: the values of
+// "path", "paths", "files", "source", "destination", "working_dir" and any
+// parameter ending in "_path", at any depth. The result is sorted and
+// deduplicated.
+func ParamPaths(params json.RawMessage) []string {
+	var value interface{}
+	if err := json.Unmarshal(params, &value); err != nil {
+		return nil
+	}
+
+	found := map[string]bool{}
+	collectPaths(value, found)
+
+	paths := make([]string, 0, len(found))
+	for p := range found {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// callPaths returns the paths named in the parameters together with the
+// paths the tool reports it will change, such as the files inside a patch
+func callPaths(tool Tool, params json.RawMessage) []string {
+	found := map[string]bool{}
+	for _, p := range ParamPaths(params) {
+		found[p] = true
+	}
+	for _, p := range ChangedPaths(tool, params) {
+		found[p] = true
+	}
+
+	paths := make([]string, 0, len(found))
+	for p := range found {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// collectPaths walks a decoded JSON value for path parameters
+func collectPaths(value interface{}, found map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if pathParams[key] || strings.HasSuffix(key, "_path") {
+				switch p := child.(type) {
+				case string:
+					if p != "" {
+						found[p] = true
+					}
+					continue
+				case []interface{}:
+					for _, item := range p {
+						if s, ok := item.(string); ok && s != "" {
+							found[s] = true
+						}
+					}
+					continue
+				}
+			}
+			collectPaths(child, found)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectPaths(item, found)
+		}
+	}
+}
+
+// ApprovalDecision is the user's answer to an approval request
+type ApprovalDecision int
+
+const (
+	DecisionDeny         ApprovalDecision = iota // Do not run the call
+	DecisionAllowOnce                            // Run this call only
+	DecisionAllowSession                         // Run this call and later calls of the same tool
+)
+
+// ApprovalRequest describes a tool call waiting for approval
+type ApprovalRequest struct {
+	Tool        string          `json:"tool"`
+	Description string          `json:"description"`
+	Risk        Risk            `json:"risk"`
+	Params      json.RawMessage `json:"params"`
+	Paths       []string        `json:"paths,omitempty"`
+}
+
+// Approver asks the user whether a tool call may run. It should return when
+// ctx is cancelled.
+type Approver interface {
+	Approve(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error)
+}
+
+// ApproverFunc adapts a plain function to the Approver interface
+type ApproverFunc func(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error)
+
+// Approve calls f(ctx, req)
+func (f ApproverFunc) Approve(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	return f(ctx, req)
+}
+
+// ApprovalGate enforces an ApprovalPolicy, asking an Approver when the policy
+// says to and remembering tools the user allowed for the session
+type ApprovalGate struct {
+	policy   ApprovalPolicy
+	approver Approver
+
+	mu      sync.Mutex
+	session map[string]bool // Tools allowed for the rest of the session
+}
+
+// NewApprovalGate creates a gate for policy. approver may be nil, in which
+// case calls that need approval are denied.
+func NewApprovalGate(policy ApprovalPolicy, approver Approver) *ApprovalGate {
+	return &ApprovalGate{
+		policy:   policy,
+		approver: approver,
+		session:  make(map[string]bool),
+	}
+}
+
+// Check returns nil if the call may run, asking the approver if needed, or
+// an error explaining why it may not. Calls with "confirm": true always ask,
+// even when the policy would allow them.
+func (g *ApprovalGate) Check(ctx context.Context, tool Tool, params json.RawMessage) error {
+	mode := g.policy.Mode(tool, params)
+	if mode == ApproveDeny {
+		return fmt.Errorf("tool %s is denied by the approval policy", tool.Name())
+	}
+
+	confirm := requestsConfirmation(params)
+	if mode == ApproveAlways && !confirm {
+		return nil
+	}
+
+	g.mu.Lock()
+	allowed := g.session[tool.Name()]
+	g.mu.Unlock()
+	if allowed && !confirm {
+		return nil
+	}
+
+	if g.approver == nil {
+		return fmt.Errorf("tool %s requires approval, but no approver is configured", tool.Name())
+	}
+
+	decision, err := g.approver.Approve(ctx, ApprovalRequest{
+		Tool:        tool.Name(),
+		Description: tool.Description(),
+		Risk:        RiskOf(tool),
+		Params:      params,
+		Paths:       callPaths(tool, params),
+	})
+	if err != nil {
+		return fmt.Errorf("approval for tool %s failed: %w", tool.Name(), err)
+	}
+
+	switch decision {
+	case DecisionAllowSession:
+		g.AllowForSession(tool.Name())
+		return nil
+	case DecisionAllowOnce:
+		return nil
+	}
+	return fmt.Errorf("tool %s was not approved by the user", tool.Name())
+}
+
+// AllowForSession lets later calls of the named tool run without asking,
+// unless the policy denies them
+func (g *ApprovalGate) AllowForSession(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.session[name] = true
+}
+
+// ResetSession forgets the tools allowed for the session
+func (g *ApprovalGate) ResetSession() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.session = make(map[string]bool)
+}
+
+// Middleware returns a Middleware that checks every call with the gate.
+// Parameters are validated first so the user is never asked about a call
+// that would be rejected anyway.
+func (g *ApprovalGate) Middleware() Middleware {
+	return func(next Executor) Executor {
+		return func(ctx context.Context, tool Tool, params json.RawMessage) *ToolResult {
+			if err := validateTool(tool, params); err != nil {
+				return &ToolResult{Success: false, Error: err.Error()}
+			}
+			if err := g.Check(ctx, tool, params); err != nil {
+				return &ToolResult{Success: false, Error: err.Error()}
+			}
+			return next(ctx, tool, params)
+		}
+	}
+}
+
+// requestsConfirmation reports whether params set "confirm": true
+func requestsConfirmation(params json.RawMessage) bool {
+	var p struct {
+		Confirm bool `json:"confirm"`
+	}
+	_ = json.Unmarshal(params, &p)
+	return p.Confirm
+}
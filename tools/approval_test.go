@@ -0,0 +1,251 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRiskOf(t *testing.T) {
+	tests := []struct {
+		tool Tool
+		want Risk
+	}{
+		{NewReadTool(), RiskReadOnly},
+		{NewGrepTool(), RiskReadOnly},
+		{NewWriteTool(), RiskMutating},
+		{NewEditTool(), RiskMutating},
+		{NewPatchTool(), RiskMutating},
+		{NewBatchEditTool(), RiskMutating},
+		{NewDeleteTool(), RiskDestructive},
+		{NewTrashListTool(nil), RiskReadOnly},
+		{NewTrashRestoreTool(nil), RiskMutating},
+		{NewTrashEmptyTool(nil), RiskDestructive},
+		{NewMoveTool(), RiskMutating},
+		{NewCopyTool(), RiskMutating},
+		{NewMkdirTool(), RiskMutating},
+		{NewShellTool(), RiskDestructive},
+		{NewFetchTool(), RiskNetwork},
+		{NewDownloadTool(), RiskNetwork},
+		{&mockTool{name: "custom"}, RiskMutating},
+	}
+
+	for _, tt := range tests {
+		if got := RiskOf(tt.tool); got != tt.want {
+			t.Errorf("RiskOf(%s) = %s, want %s", tt.tool.Name(), got, tt.want)
+		}
+	}
+}
+
+func TestParamPaths(t *testing.T) {
+	params := json.RawMessage(`{
+		"path": "a.txt",
+		"output_path": "out/b.bin",
+		"files": ["c.go", "a.txt"],
+		"operations": [{"path": "d.md"}, {"source": "e", "destination": "f"}],
+		"content": "not/a/path"
+	}`)
+
+	got := ParamPaths(params)
+	want := []string{"a.txt", "c.go", "d.md", "e", "f", "out/b.bin"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected paths %v, got %v", want, got)
+	}
+}
+
+func TestApprovalPolicy_Mode(t *testing.T) {
+	policy := ApprovalPolicy{
+		Rules: []ApprovalRule{
+			{Path: "**/.env", Mode: ApproveDeny},
+			{Tool: "write", Path: "docs/**", Mode: ApproveAlways},
+			{Tool: "shell", Mode: ApproveDeny},
+			{Risk: RiskNetwork, Mode: ApproveAlways},
+		},
+		Defaults: map[Risk]ApprovalMode{RiskMutating: ApproveAsk},
+	}
+
+	tests := []struct {
+		name   string
+		tool   Tool
+		params string
+		want   ApprovalMode
+	}{
+		{"read-only default", NewReadTool(), `{"path": "main.go"}`, ApproveAlways},
+		{"deny path for any tool", NewReadTool(), `{"path": "config/.env"}`, ApproveDeny},
+		{"allowed path", NewWriteTool(), `{"path": "docs/guide.md", "content": ""}`, ApproveAlways},
+		{"path outside allowed pattern", NewWriteTool(), `{"path": "main.go", "content": ""}`, ApproveAsk},
+		{"deny tool", NewShellTool(), `{"command": "ls"}`, ApproveDeny},
+		{"risk rule", NewFetchTool(), `{"url": "https://example.com"}`, ApproveAlways},
+		{"destructive default", NewDeleteTool(), `{"path": "build"}`, ApproveAsk},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Mode(tt.tool, json.RawMessage(tt.params)); got != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestApprovalPolicy_AbsolutePaths(t *testing.T) {
+	wd, _ := os.Getwd()
+	policy := ApprovalPolicy{Rules: []ApprovalRule{{Tool: "write", Path: "testdata/**", Mode: ApproveAlways}}}
+
+	params, _ := json.Marshal(map[string]string{"path": filepath.Join(wd, "testdata", "new.txt"), "content": ""})
+	if got := policy.Mode(NewWriteTool(), params); got != ApproveAlways {
+		t.Errorf("Expected absolute path inside the working directory to match, got %s", got)
+	}
+}
+
+// recordingApprover answers approval requests with a fixed decision
+type recordingApprover struct {
+	decision ApprovalDecision
+	err      error
+	requests []ApprovalRequest
+}
+
+func (r *recordingApprover) Approve(ctx context.Context, req ApprovalRequest) (ApprovalDecision, error) {
+	r.requests = append(r.requests, req)
+	return r.decision, r.err
+}
+
+func TestApprovalGate(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "file.txt")
+	params := func() json.RawMessage {
+		data, _ := json.Marshal(map[string]string{"path": target})
+		return data
+	}
+
+	approver := &recordingApprover{decision: DecisionDeny}
+	gate := NewApprovalGate(ApprovalPolicy{}, approver)
+
+	registry := NewToolRegistry()
+	registry.Register(NewDeleteTool())
+	registry.Register(NewReadTool())
+	registry.Use(gate.Middleware())
+
+	os.WriteFile(target, []byte("data"), 0644)
+
+	// Denied by the user: the file is kept
+	result := registry.Execute(context.Background(), "delete", params())
+	if result.Success || result.Error != "tool delete was not approved by the user" {
+		t.Errorf("Expected denial, got %+v", result)
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Fatal("Expected file to survive a denied delete")
+	}
+	if len(approver.requests) != 1 || approver.requests[0].Risk != RiskDestructive || approver.requests[0].Paths[0] != target {
+		t.Errorf("Unexpected approval request: %+v", approver.requests)
+	}
+
+	// Read-only tools run without asking
+	registry.Execute(context.Background(), "read", params())
+	if len(approver.requests) != 1 {
+		t.Error("Expected read to run without approval")
+	}
+
+	// Invalid calls are rejected before asking
+	registry.Execute(context.Background(), "delete", json.RawMessage(`{"path": 1}`))
+	if len(approver.requests) != 1 {
+		t.Error("Expected invalid params not to be sent for approval")
+	}
+
+	// Allowed for the session: later calls do not ask again
+	approver.decision = DecisionAllowSession
+	if result := registry.Execute(context.Background(), "delete", params()); !result.Success {
+		t.Fatalf("Expected approved delete to succeed, got %s", result.Error)
+	}
+	os.WriteFile(target, []byte("data"), 0644)
+	if result := registry.Execute(context.Background(), "delete", params()); !result.Success {
+		t.Fatalf("Expected delete allowed for the session to succeed, got %s", result.Error)
+	}
+	if len(approver.requests) != 2 {
+		t.Errorf("Expected no new request after allowing for the session, got %d", len(approver.requests))
+	}
+
+	// confirm: true always asks
+	os.WriteFile(target, []byte("data"), 0644)
+	approver.decision = DecisionAllowOnce
+	registry.Execute(context.Background(), "delete", json.RawMessage(`{"path": "`+filepath.ToSlash(target)+`", "confirm": true}`))
+	if len(approver.requests) != 3 {
+		t.Error("Expected confirm to require approval")
+	}
+
+	gate.ResetSession()
+	os.WriteFile(target, []byte("data"), 0644)
+	approver.decision = DecisionDeny
+	if result := registry.Execute(context.Background(), "delete", params()); result.Success {
+		t.Error("Expected session allowance to be forgotten after reset")
+	}
+}
+
+func TestApprovalGate_DenyAndErrors(t *testing.T) {
+	policy := ApprovalPolicy{Rules: []ApprovalRule{{Tool: "shell", Mode: ApproveDeny}}}
+
+	// A session allowance never overrides a deny rule
+	gate := NewApprovalGate(policy, nil)
+	gate.AllowForSession("shell")
+	err := gate.Check(context.Background(), NewShellTool(), json.RawMessage(`{"command": "ls"}`))
+	if err == nil || !strings.Contains(err.Error(), "denied by the approval policy") {
+		t.Errorf("Expected policy denial, got %v", err)
+	}
+
+	// Without an approver, calls that need approval are denied
+	err = gate.Check(context.Background(), NewWriteTool(), json.RawMessage(`{"path": "a", "content": ""}`))
+	if err == nil || !strings.Contains(err.Error(), "no approver") {
+		t.Errorf("Expected missing approver error, got %v", err)
+	}
+
+	// Approver errors are reported
+	gate = NewApprovalGate(ApprovalPolicy{}, &recordingApprover{err: errors.New("closed")})
+	err = gate.Check(context.Background(), NewWriteTool(), json.RawMessage(`{"path": "a", "content": ""}`))
+	if err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Errorf("Expected approver error, got %v", err)
+	}
+}
+
+func TestApprovalGate_PatchPaths(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.txt")
+	os.WriteFile(secret, []byte("old\n"), 0644)
+
+	patch := "--- a/secret.txt\n+++ b/secret.txt\n@@ -1 +1 @@\n-old\n+new\n"
+	params, _ := json.Marshal(map[string]string{"patch": patch, "working_dir": dir})
+
+	policy := ApprovalPolicy{Rules: []ApprovalRule{
+		{Path: "**/secret.txt", Mode: ApproveDeny},
+		{Tool: "patch", Path: filepath.ToSlash(dir), Mode: ApproveAlways},
+	}}
+
+	// The deny rule sees the file inside the diff
+	registry := NewToolRegistry()
+	registry.Register(NewPatchTool())
+	registry.Use(NewApprovalGate(policy, nil).Middleware())
+
+	result := registry.Execute(context.Background(), "patch", params)
+	if result.Success || !strings.Contains(result.Error, "denied by the approval policy") {
+		t.Errorf("Expected patch of secret.txt to be denied, got %+v", result)
+	}
+	if data, _ := os.ReadFile(secret); string(data) != "old\n" {
+		t.Errorf("Expected secret.txt to be unchanged, got %q", data)
+	}
+
+	// Allowing the working directory does not allow the files it patches
+	policy.Rules = policy.Rules[1:]
+	if got := policy.Mode(NewPatchTool(), params); got != ApproveAsk {
+		t.Errorf("Expected patch outside the allowed path to ask, got %s", got)
+	}
+
+	// The approval request lists the patched file
+	approver := &recordingApprover{decision: DecisionDeny}
+	NewApprovalGate(ApprovalPolicy{}, approver).Check(context.Background(), NewPatchTool(), params)
+	want := []string{dir, secret}
+	if len(approver.requests) != 1 || !reflect.DeepEqual(approver.requests[0].Paths, want) {
+		t.Errorf("Expected approval paths %v, got %+v", want, approver.requests)
+	}
+}
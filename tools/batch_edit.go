@@ -0,0 +1,474 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BatchEditTool implements applying several file changes as one transaction
+type BatchEditTool struct {
+	workspace *Workspace
+}
+
+// BatchEditParams defines the parameters for the BatchEdit tool
+type BatchEditParams struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation is one change in a batch
+type BatchOperation struct {
+	Op           string `json:"op"` // "write", "edit", "delete", "rename"
+	Path         string `json:"path"`
+	Content      string `json:"content,omitempty"`     // write
+	OldString    string `json:"old_string,omitempty"`  // edit
+	NewString    string `json:"new_string,omitempty"`  // edit
+	ReplaceAll   bool   `json:"replace_all,omitempty"` // edit
+	Destination  string `json:"destination,omitempty"` // rename
+	ExpectedHash string `json:"expected_hash,omitempty"`
+}
+
+// BatchEditResult represents the result of a batch edit
+type BatchEditResult struct {
+	Operations []BatchOperationResult `json:"operations"`
+	Touched    []string               `json:"touched"` // Every path created, modified or removed
+	Committed  bool                   `json:"committed"`
+	Message    string                 `json:"message"`
+}
+
+// BatchOperationResult reports the outcome of one operation
+type BatchOperationResult struct {
+	Index        int    `json:"index"` // 1-based position in the batch
+	Op           string `json:"op"`
+	Path         string `json:"path"`
+	Destination  string `json:"destination,omitempty"`
+	Hash         string `json:"hash,omitempty"` // SHA-256 of the file after the operation
+	Replacements int    `json:"replacements,omitempty"`
+	Diff         string `json:"diff,omitempty"`
+}
+
+// batchFile is the state of a file as the batch sees it
+type batchFile struct {
+	content string
+	perm    os.FileMode
+	exists  bool
+}
+
+// NewBatchEditTool creates a new BatchEdit tool instance
+func NewBatchEditTool() *BatchEditTool {
+	return &BatchEditTool{}
+}
+
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *BatchEditTool) WithWorkspace(ws *Workspace) *BatchEditTool {
+	t.workspace = ws
+	return t
+}
+
+// Name returns the tool's name
+func (t *BatchEditTool) Name() string {
+	return "batch_edit"
+}
+
+// Description returns the tool's description
+func (t *BatchEditTool) Description() string {
+	return "Apply an ordered list of write, edit, delete and rename operations to files as one transaction: either every change is made or none is"
+}
+
+// Risk returns the tool's risk class
+func (t *BatchEditTool) Risk() Risk {
+	return RiskMutating
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *BatchEditTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"operations": map[string]interface{}{
+				"type":        "array",
+				"description": "Operations to apply in order; later operations see the results of earlier ones",
+				"minItems":    1,
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"op": map[string]interface{}{
+							"type":        "string",
+							"description": "Operation: 'write' replaces or creates a file, 'edit' replaces an exact string, 'delete' removes a file, 'rename' moves a file to destination",
+							"enum":        []string{"write", "edit", "delete", "rename"},
+						},
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Path to the file",
+						},
+						"content": map[string]interface{}{
+							"type":        "string",
+							"description": "Content to write (write only)",
+						},
+						"old_string": map[string]interface{}{
+							"type":        "string",
+							"description": "Exact text to replace; must be unique unless replace_all is set (edit only)",
+						},
+						"new_string": map[string]interface{}{
+							"type":        "string",
+							"description": "Text to replace it with (edit only)",
+						},
+						"replace_all": map[string]interface{}{
+							"type":        "boolean",
+							"description": "Replace every occurrence of old_string (edit only)",
+						},
+						"destination": map[string]interface{}{
+							"type":        "string",
+							"description": "New path for the file; must not exist (rename only)",
+						},
+						"expected_hash": map[string]interface{}{
+							"type":        "string",
+							"description": "SHA-256 of the content, as hex, the file must have before this operation; a missing file has the hash of empty content",
+							"pattern":     "^(sha256:)?[0-9a-fA-F]{64}$",
+						},
+					},
+					"required":             []string{"op", "path"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"operations"},
+		"additionalProperties": false,
+	}
+}
+
+// Validate checks if the parameters are valid
+func (t *BatchEditTool) Validate(params json.RawMessage) error {
+	var p BatchEditParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if len(p.Operations) == 0 {
+		return fmt.Errorf("operations is required")
+	}
+
+	for i, op := range p.Operations {
+		if err := validateBatchOperation(op); err != nil {
+			return fmt.Errorf("operation %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// validateBatchOperation checks that an operation has the fields its kind needs
+func validateBatchOperation(op BatchOperation) error {
+	if op.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+
+	switch op.Op {
+	case "write", "delete":
+	case "edit":
+		if op.OldString == "" {
+			return fmt.Errorf("old_string is required for edit")
+		}
+		if op.OldString == op.NewString {
+			return fmt.Errorf("old_string and new_string must be different")
+		}
+	case "rename":
+		if op.Destination == "" {
+			return fmt.Errorf("destination is required for rename")
+		}
+	default:
+		return fmt.Errorf("op must be 'write', 'edit', 'delete', or 'rename'")
+	}
+
+	return nil
+}
+
+// Execute runs the tool with the given parameters
+func (t *BatchEditTool) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p BatchEditParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	// Apply every operation in memory, checking preconditions as we go
+	files := make(map[string]*batchFile)
+	var order []string // Paths in the order they were first touched
+	result := &BatchEditResult{}
+
+	for i, op := range p.Operations {
+		res, err := t.apply(op, files, &order)
+		if err != nil {
+			result.Message = "No files were changed"
+			return result, fmt.Errorf("operation %d (%s %s): %w; no files were changed", i+1, op.Op, op.Path, err)
+		}
+		res.Index = i + 1
+		result.Operations = append(result.Operations, *res)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := t.commit(files, order); err != nil {
+		result.Message = "No files were changed"
+		return result, err
+	}
+
+	result.Touched = append([]string(nil), order...)
+	sort.Strings(result.Touched)
+	result.Committed = true
+	result.Message = fmt.Sprintf("Applied %d operation(s) to %d file(s)", len(result.Operations), len(result.Touched))
+	return result, nil
+}
+
+// apply runs one operation against the in-memory view of the files
+func (t *BatchEditTool) apply(op BatchOperation, files map[string]*batchFile, order *[]string) (*BatchOperationResult, error) {
+	if err := validateBatchOperation(op); err != nil {
+		return nil, err
+	}
+
+	file, err := t.load(op.Path, files, order)
+	if err != nil {
+		return nil, err
+	}
+
+	if op.ExpectedHash != "" {
+		want := strings.ToLower(strings.TrimPrefix(op.ExpectedHash, "sha256:"))
+		if got := contentHash(file.content); got != want {
+			return nil, fmt.Errorf("%s has changed: expected hash %s, got %s", op.Path, want, got)
+		}
+	}
+
+	res := &BatchOperationResult{Op: op.Op, Path: op.Path}
+
+	switch op.Op {
+	case "write":
+		if file.exists {
+			res.Diff = UnifiedDiff(op.Path, op.Path, file.content, op.Content)
+		}
+		file.content = op.Content
+		file.exists = true
+		res.Hash = contentHash(file.content)
+
+	case "edit":
+		if !file.exists {
+			return nil, fmt.Errorf("%s does not exist", op.Path)
+		}
+		updated, replacements, err := replaceString(op.Path, file.content, op.OldString, op.NewString, op.ReplaceAll)
+		if err != nil {
+			return nil, err
+		}
+		res.Diff = UnifiedDiff(op.Path, op.Path, file.content, updated)
+		res.Replacements = replacements
+		file.content = updated
+		res.Hash = contentHash(file.content)
+
+	case "delete":
+		if !file.exists {
+			return nil, fmt.Errorf("%s does not exist", op.Path)
+		}
+		file.content = ""
+		file.exists = false
+
+	case "rename":
+		if !file.exists {
+			return nil, fmt.Errorf("%s does not exist", op.Path)
+		}
+		dest, err := t.load(op.Destination, files, order)
+		if err != nil {
+			return nil, err
+		}
+		if dest == file {
+			return nil, fmt.Errorf("destination is the same file as %s", op.Path)
+		}
+		if dest.exists {
+			return nil, fmt.Errorf("destination %s already exists", op.Destination)
+		}
+		*dest = *file
+		file.content = ""
+		file.exists = false
+		res.Destination = op.Destination
+		res.Hash = contentHash(dest.content)
+	}
+
+	return res, nil
+}
+
+// load returns the batch's view of a file, reading it from disk the first
+// time the batch touches it
+func (t *BatchEditTool) load(path string, files map[string]*batchFile, order *[]string) (*batchFile, error) {
+	if err := t.workspace.Check(path); err != nil {
+		return nil, err
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve path %s: %w", path, err)
+	}
+	if file, ok := files[abs]; ok {
+		return file, nil
+	}
+
+	file := &batchFile{perm: 0644}
+	info, err := os.Stat(abs)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("cannot access %s: %w", path, err)
+	case info.IsDir():
+		return nil, fmt.Errorf("%s is a directory", path)
+	default:
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		file.content = string(data)
+		file.perm = info.Mode().Perm()
+		file.exists = true
+	}
+
+	files[abs] = file
+	*order = append(*order, abs)
+	return file, nil
+}
+
+// commit writes the batch to disk. Every new file content is staged in a
+// temporary file first, then files being replaced or removed are moved aside,
+// and the staged files are renamed into place. If any step fails, everything
+// done so far is undone.
+func (t *BatchEditTool) commit(files map[string]*batchFile, order []string) error {
+	type change struct {
+		path   string
+		file   *batchFile
+		tmp    string // Staged new content, "" if the file is removed
+		backup string // Where the original was moved, "" if there was none
+		placed bool   // Staged content has been renamed into place
+	}
+
+	var changes []*change
+	var createdDirs []string
+
+	rollback := func() {
+		for i := len(changes) - 1; i >= 0; i-- {
+			c := changes[i]
+			if c.placed {
+				os.Remove(c.path)
+			} else if c.tmp != "" {
+				os.Remove(c.tmp)
+			}
+			if c.backup != "" {
+				os.Rename(c.backup, c.path)
+			}
+		}
+		for i := len(createdDirs) - 1; i >= 0; i-- {
+			os.Remove(createdDirs[i]) // Only succeeds if still empty
+		}
+	}
+
+	// Stage new content
+	for _, path := range order {
+		c := &change{path: path, file: files[path]}
+		changes = append(changes, c)
+		if !c.file.exists {
+			continue
+		}
+
+		dirs, err := mkdirAllTracked(filepath.Dir(path))
+		createdDirs = append(createdDirs, dirs...)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to create directory for %s: %w", path, err)
+		}
+		tmp, err := stageFile(path, []byte(c.file.content), c.file.perm)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to stage %s: %w", path, err)
+		}
+		c.tmp = tmp
+	}
+
+	// Move originals aside
+	for _, c := range changes {
+		if _, err := os.Lstat(c.path); os.IsNotExist(err) {
+			continue
+		}
+		backup, err := reserveTempName(c.path, ".orig")
+		if err != nil {
+			rollback()
+			return fmt.Errorf("failed to back up %s: %w", c.path, err)
+		}
+		if err := os.Rename(c.path, backup); err != nil {
+			os.Remove(backup)
+			rollback()
+			return fmt.Errorf("failed to back up %s: %w", c.path, err)
+		}
+		c.backup = backup
+	}
+
+	// Put new content in place
+	for _, c := range changes {
+		if c.tmp == "" {
+			continue
+		}
+		if err := os.Rename(c.tmp, c.path); err != nil {
+			rollback()
+			return fmt.Errorf("failed to write %s: %w", c.path, err)
+		}
+		c.placed = true
+	}
+
+	for _, c := range changes {
+		if c.backup != "" {
+			os.Remove(c.backup)
+		}
+	}
+	return nil
+}
+
+// mkdirAllTracked creates dir and any missing parents, returning the
+// directories it created, outermost first
+func mkdirAllTracked(dir string) ([]string, error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	var created []string
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Mkdir(missing[i], 0755); err != nil && !os.IsExist(err) {
+			return created, err
+		}
+		created = append(created, missing[i])
+	}
+	return created, nil
+}
+
+// reserveTempName creates an empty file with a unique name next to path and
+// returns its name
+func reserveTempName(path, suffix string) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*"+suffix)
+	if err != nil {
+		return "", err
+	}
+	name := f.Name()
+	f.Close()
+	return name, nil
+}
+
+// contentHash returns the hex SHA-256 of content
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
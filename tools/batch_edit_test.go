@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBatchEditTool_Name(t *testing.T) {
+	tool := NewBatchEditTool()
+	if tool.Name() != "batch_edit" {
+		t.Errorf("Expected name 'batch_edit', got '%s'", tool.Name())
+	}
+}
+
+func TestBatchEditTool_Validate(t *testing.T) {
+	tool := NewBatchEditTool()
+
+	tests := []struct {
+		name    string
+		params  string
+		wantErr bool
+	}{
+		{"valid write", `{"operations": [{"op": "write", "path": "a", "content": "x"}]}`, false},
+		{"valid edit and rename", `{"operations": [{"op": "edit", "path": "a", "old_string": "x", "new_string": "y"}, {"op": "rename", "path": "a", "destination": "b"}]}`, false},
+		{"no operations", `{"operations": []}`, true},
+		{"unknown op", `{"operations": [{"op": "chmod", "path": "a"}]}`, true},
+		{"edit without old_string", `{"operations": [{"op": "edit", "path": "a", "new_string": "y"}]}`, true},
+		{"rename without destination", `{"operations": [{"op": "rename", "path": "a"}]}`, true},
+		{"missing path", `{"operations": [{"op": "delete"}]}`, true},
+		{"invalid json", `{invalid}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tool.Validate(json.RawMessage(tt.params))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// runBatch runs a batch of operations
+func runBatch(t *testing.T, operations ...map[string]interface{}) (*BatchEditResult, error) {
+	t.Helper()
+	params, _ := json.Marshal(map[string]interface{}{"operations": operations})
+	result, err := NewBatchEditTool().Execute(context.Background(), params)
+	if result == nil {
+		return nil, err
+	}
+	return result.(*BatchEditResult), err
+}
+
+func TestBatchEditTool_Execute(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go":   "package a\n\nfunc Old() {}\n",
+		"b.go":   "package a\n\nvar x = Old\n",
+		"old.md": "notes\n",
+		"gone":   "bye\n",
+	})
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	result, err := runBatch(t,
+		map[string]interface{}{"op": "edit", "path": path("a.go"), "old_string": "Old", "new_string": "New", "expected_hash": contentHash("package a\n\nfunc Old() {}\n")},
+		map[string]interface{}{"op": "edit", "path": path("b.go"), "old_string": "Old", "new_string": "New"},
+		map[string]interface{}{"op": "write", "path": path("pkg/c.go"), "content": "package pkg\n"},
+		map[string]interface{}{"op": "rename", "path": path("old.md"), "destination": path("docs/new.md")},
+		map[string]interface{}{"op": "delete", "path": path("gone")},
+		map[string]interface{}{"op": "edit", "path": path("docs/new.md"), "old_string": "notes", "new_string": "docs"},
+	)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !result.Committed || len(result.Operations) != 6 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	want := map[string]string{
+		"a.go":        "package a\n\nfunc New() {}\n",
+		"b.go":        "package a\n\nvar x = New\n",
+		"pkg/c.go":    "package pkg\n",
+		"old.md":      "<missing>",
+		"docs/new.md": "docs\n",
+		"gone":        "<missing>",
+	}
+	for name, content := range want {
+		if got := readFile(t, path(name)); got != content {
+			t.Errorf("%s: expected %q, got %q", name, content, got)
+		}
+	}
+
+	touched := []string{path("a.go"), path("b.go"), path("docs/new.md"), path("gone"), path("old.md"), path("pkg/c.go")}
+	if strings.Join(result.Touched, ",") != strings.Join(touched, ",") {
+		t.Errorf("Expected touched %v, got %v", touched, result.Touched)
+	}
+	if result.Operations[0].Hash != contentHash(want["a.go"]) {
+		t.Errorf("Expected hash of the new content, got %s", result.Operations[0].Hash)
+	}
+	if !strings.Contains(result.Operations[0].Diff, "-func Old() {}\n+func New() {}\n") {
+		t.Errorf("Unexpected diff:\n%s", result.Operations[0].Diff)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") || strings.HasSuffix(entry.Name(), ".orig") {
+			t.Errorf("Temporary file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestBatchEditTool_Execute_FailureChangesNothing(t *testing.T) {
+	tests := []struct {
+		name    string
+		last    map[string]interface{}
+		wantErr string
+	}{
+		{"hash mismatch", map[string]interface{}{"op": "write", "path": "b.go", "content": "x", "expected_hash": contentHash("other")}, "b.go has changed"},
+		{"edit not found", map[string]interface{}{"op": "edit", "path": "b.go", "old_string": "missing", "new_string": "x"}, "old_string not found"},
+		{"delete missing", map[string]interface{}{"op": "delete", "path": "missing.go"}, "missing.go does not exist"},
+		{"rename over existing", map[string]interface{}{"op": "rename", "path": "b.go", "destination": "a.go"}, "a.go already exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{"a.go": "a\n", "b.go": "b\n"})
+			tt.last["path"] = filepath.Join(dir, tt.last["path"].(string))
+			if dest, ok := tt.last["destination"]; ok {
+				tt.last["destination"] = filepath.Join(dir, dest.(string))
+			}
+
+			result, err := runBatch(t,
+				map[string]interface{}{"op": "write", "path": filepath.Join(dir, "a.go"), "content": "changed\n"},
+				map[string]interface{}{"op": "write", "path": filepath.Join(dir, "new/c.go"), "content": "c\n"},
+				tt.last,
+			)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "operation 3") {
+				t.Fatalf("Expected error for operation 3 containing %q, got %v", tt.wantErr, err)
+			}
+			if result.Committed {
+				t.Error("Expected the batch not to be committed")
+			}
+
+			if got := readFile(t, filepath.Join(dir, "a.go")); got != "a\n" {
+				t.Errorf("Expected a.go to be unchanged, got %q", got)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "new")); !os.IsNotExist(err) {
+				t.Error("Expected no directory to be created")
+			}
+		})
+	}
+}
+
+func TestBatchEditTool_Execute_RollsBack(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.go": "a\n", "b.go": "b\n"})
+
+	// A directory where b.go would be staged makes the commit fail after
+	// a.go and new/c.go have been staged
+	if err := os.Mkdir(filepath.Join(dir, "b.go.tmp"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := runBatch(t,
+		map[string]interface{}{"op": "write", "path": filepath.Join(dir, "a.go"), "content": "changed\n"},
+		map[string]interface{}{"op": "write", "path": filepath.Join(dir, "new", "c.go"), "content": "c\n"},
+		map[string]interface{}{"op": "write", "path": filepath.Join(dir, "b.go"), "content": "changed\n"},
+	)
+	if err == nil || result.Committed {
+		t.Fatal("Expected the commit to fail")
+	}
+	if !strings.Contains(err.Error(), "failed to stage") {
+		t.Errorf("Expected a staging error, got %v", err)
+	}
+
+	if got := readFile(t, filepath.Join(dir, "a.go")); got != "a\n" {
+		t.Errorf("Expected a.go to be unchanged, got %q", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "b.go")); got != "b\n" {
+		t.Errorf("Expected b.go to be unchanged, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new")); !os.IsNotExist(err) {
+		t.Error("Expected the created directory to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.go.tmp")); !os.IsNotExist(err) {
+		t.Error("Expected the staged file to be removed")
+	}
+}
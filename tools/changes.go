@@ -0,0 +1,26 @@
+package tools
+
+import "encoding/json"
+
+// FileChanger is implemented by tools that can name the files a call will
+// create, modify or delete before it runs
+type FileChanger interface {
+	ChangedPaths(params json.RawMessage) []string
+}
+
+// ChangedPaths returns the paths a call may change, so they can be saved
+// before it runs. Tools that implement FileChanger report their own; for
+// other mutating and destructive tools the paths named in the parameters are
+// used (see ParamPaths). Read-only and network tools are assumed to change
+// nothing unless they implement FileChanger.
+func ChangedPaths(tool Tool, params json.RawMessage) []string {
+	if changer, ok := tool.(FileChanger); ok {
+		return changer.ChangedPaths(params)
+	}
+
+	switch RiskOf(tool) {
+	case RiskMutating, RiskDestructive:
+		return ParamPaths(params)
+	}
+	return nil
+}
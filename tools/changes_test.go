@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestChangedPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		tool   Tool
+		params string
+		want   []string
+	}{
+		{"read-only", NewReadTool(), `{"path": "a.go"}`, nil},
+		{"write", NewWriteTool(), `{"path": "a.go", "content": "x"}`, []string{"a.go"}},
+		{"write with backup", NewWriteTool(), `{"path": "a.go", "content": "x", "backup": true}`, []string{"a.go", "a.go.backup"}},
+		{"edit", NewEditTool(), `{"path": "a.go", "old_string": "a", "new_string": "b"}`, []string{"a.go"}},
+		{"batch_edit", NewBatchEditTool(), `{"operations": [{"op": "rename", "path": "a.go", "destination": "b.go"}, {"op": "delete", "path": "c.go"}]}`, []string{"a.go", "b.go", "c.go"}},
+		{"patch", NewPatchTool(), `{"patch": "--- a/a.go\n+++ b/b.go\n@@ -1 +1 @@\n-a\n+b\n", "working_dir": "src"}`, []string{filepath.Join("src", "a.go"), filepath.Join("src", "b.go")}},
+		{"delete", NewDeleteTool(), `{"path": "dir", "recursive": true}`, []string{"dir"}},
+		{"download", NewDownloadTool(), `{"url": "https://example.com/f", "output_path": "f.bin"}`, []string{"f.bin"}},
+		{"fetch", NewFetchTool(), `{"url": "https://example.com"}`, nil},
+		{"fetch to file", NewFetchTool(), `{"url": "https://example.com", "save_path": "page.html"}`, []string{"page.html"}},
+		{"shell", NewShellTool(), `{"command": "make", "working_dir": "src"}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ChangedPaths(tt.tool, json.RawMessage(tt.params))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
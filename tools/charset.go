@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// charsetDeclaration finds a charset declared inside an HTML or XML
+// document, by <meta charset>, <meta http-equiv> or <?xml encoding?>
+var charsetDeclaration = regexp.MustCompile(`(?i)(?:<meta[^>]+charset\s*=\s*["']?|<\?xml[^>]+encoding\s*=\s*["'])([a-z0-9_:.-]+)`)
+
+// windows1252 maps bytes 0x80 to 0x9F of Windows-1252 to Unicode; the other
+// bytes are the same as in ISO-8859-1. Undefined bytes map to themselves.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// decodeCharset decodes body to UTF-8 and returns the name of the charset
+// it was decoded from. The charset comes from contentType, a byte order
+// mark, or a declaration in an HTML or XML document, and defaults to UTF-8.
+// UTF-8, UTF-16, US-ASCII, ISO-8859-1 and Windows-1252 are supported; as
+// browsers do, ISO-8859-1 is decoded as its superset Windows-1252. Other
+// charsets return an error naming them rather than mangled text. When cut
+// is set the body was cut off at an arbitrary byte, so a character left
+// incomplete at its end is dropped.
+func decodeCharset(body []byte, contentType string, cut bool) (string, string, error) {
+	charset := ""
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		charset = strings.ToLower(strings.Trim(params["charset"], `"' `))
+	}
+
+	switch {
+	case bytes.HasPrefix(body, []byte{0xEF, 0xBB, 0xBF}):
+		return decodeUTF8(body[3:], cut), "utf-8", nil
+	case bytes.HasPrefix(body, []byte{0xFF, 0xFE}):
+		return decodeUTF16(body[2:], binary.LittleEndian, cut), "utf-16le", nil
+	case bytes.HasPrefix(body, []byte{0xFE, 0xFF}):
+		return decodeUTF16(body[2:], binary.BigEndian, cut), "utf-16be", nil
+	}
+
+	if charset == "" {
+		head := body[:min(len(body), 1024)]
+		if match := charsetDeclaration.FindSubmatch(head); match != nil {
+			charset = strings.ToLower(string(match[1]))
+		}
+	}
+
+	switch charset {
+	case "", "utf-8", "utf8", "unicode-1-1-utf-8", "us-ascii", "ascii":
+		return decodeUTF8(body, cut), "utf-8", nil
+	case "iso-8859-1", "iso8859-1", "latin1", "l1", "windows-1252", "cp1252", "x-cp1252":
+		return decodeWindows1252(body), "windows-1252", nil
+	case "utf-16", "utf-16be":
+		return decodeUTF16(body, binary.BigEndian, cut), "utf-16be", nil
+	case "utf-16le":
+		return decodeUTF16(body, binary.LittleEndian, cut), "utf-16le", nil
+	}
+	return "", charset, fmt.Errorf("unsupported charset %s", charset)
+}
+
+// decodeUTF8 returns UTF-8 text, dropping a sequence cut off at its end
+// when cut is set
+func decodeUTF8(body []byte, cut bool) string {
+	if cut {
+		body = trimPartialRune(body)
+	}
+	return string(body)
+}
+
+// trimPartialRune drops a UTF-8 sequence cut off at the end of body
+func trimPartialRune(body []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(body); i++ {
+		if utf8.RuneStart(body[len(body)-i]) {
+			if !utf8.FullRune(body[len(body)-i:]) {
+				return body[:len(body)-i]
+			}
+			break
+		}
+	}
+	return body
+}
+
+// decodeWindows1252 decodes Windows-1252 text
+func decodeWindows1252(body []byte) string {
+	var b strings.Builder
+	b.Grow(len(body))
+	for _, c := range body {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c < 0xA0:
+			b.WriteRune(windows1252[c-0x80])
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// decodeUTF16 decodes UTF-16 text with the given byte order. When cut is
+// set, a trailing odd byte and an unpaired high surrogate are dropped as
+// the remains of a cut off character; otherwise a trailing odd byte is
+// replaced with U+FFFD.
+func decodeUTF16(body []byte, order binary.ByteOrder, cut bool) string {
+	units := make([]uint16, len(body)/2)
+	for i := range units {
+		units[i] = order.Uint16(body[2*i:])
+	}
+	if n := len(units); cut && n > 0 && units[n-1] >= 0xD800 && units[n-1] < 0xDC00 {
+		units = units[:n-1]
+	}
+
+	text := string(utf16.Decode(units))
+	if len(body)%2 != 0 && !cut {
+		text += string(utf8.RuneError)
+	}
+	return text
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        string
+		wantCharset string
+	}{
+		{"utf-8", "café", "text/plain; charset=utf-8", "café", "utf-8"},
+		{"no charset", "café", "text/plain", "café", "utf-8"},
+		{"utf-8 bom", "\xef\xbb\xbfcafé", "text/plain", "café", "utf-8"},
+		{"latin-1", "caf\xe9", "text/plain; charset=iso-8859-1", "café", "windows-1252"},
+		{"quoted charset", "caf\xe9", `text/plain; charset="Latin1"`, "café", "windows-1252"},
+		{"windows-1252", "\x80 \x85 \x99", "text/plain; charset=windows-1252", "€ … ™", "windows-1252"},
+		{"utf-16le bom", "\xff\xfeh\x00i\x00", "text/plain", "hi", "utf-16le"},
+		{"utf-16be bom", "\xfe\xff\x00h\x00i", "text/plain", "hi", "utf-16be"},
+		{"utf-16le label", "h\x00i\x00", "text/plain; charset=utf-16le", "hi", "utf-16le"},
+		{"odd utf-16", "h\x00i", "text/plain; charset=utf-16le", "h�", "utf-16le"},
+		{"meta charset", `<meta charset="iso-8859-1">caf` + "\xe9", "text/html", `<meta charset="iso-8859-1">café`, "windows-1252"},
+		{"http-equiv", `<meta http-equiv="Content-Type" content="text/html; charset=windows-1252">` + "\x93", "", `<meta http-equiv="Content-Type" content="text/html; charset=windows-1252">“`, "windows-1252"},
+		{"header beats meta", `<meta charset="iso-8859-1">café`, "text/html; charset=utf-8", `<meta charset="iso-8859-1">café`, "utf-8"},
+		{"xml declaration", `<?xml version="1.0" encoding="ISO-8859-1"?><a>` + "\xe9</a>", "application/xml", `<?xml version="1.0" encoding="ISO-8859-1"?><a>é</a>`, "windows-1252"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, charset, err := decodeCharset([]byte(tt.body), tt.contentType, false)
+			if err != nil {
+				t.Fatalf("decodeCharset() failed: %v", err)
+			}
+			if got != tt.want || charset != tt.wantCharset {
+				t.Errorf("decodeCharset() = %q, %q, want %q, %q", got, charset, tt.want, tt.wantCharset)
+			}
+		})
+	}
+}
+
+func TestDecodeCharset_Unsupported(t *testing.T) {
+	got, charset, err := decodeCharset([]byte("\x82\xa0"), "text/plain; charset=Shift_JIS", false)
+	if err == nil || !strings.Contains(err.Error(), "shift_jis") {
+		t.Errorf("Expected an error naming the charset, got %v", err)
+	}
+	if got != "" || charset != "shift_jis" {
+		t.Errorf("Expected no text and the charset name, got %q, %q", got, charset)
+	}
+}
+
+func TestDecodeCharset_Cut(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        string
+	}{
+		{"utf-8", "caf\xc3", "text/plain; charset=utf-8", "caf"},
+		{"utf-8 whole", "café", "text/plain", "café"},
+		{"latin-1", "caf\xe9", "text/plain; charset=iso-8859-1", "café"},
+		{"windows-1252 lead byte", "\xc0", "text/plain; charset=windows-1252", "À"},
+		{"utf-16 odd byte", "h\x00i", "text/plain; charset=utf-16le", "h"},
+		{"utf-16 bom odd byte", "\xfe\xff\x00h\x00", "text/plain", "h"},
+		{"utf-16 high surrogate", "h\x00\x3d\xd8", "text/plain; charset=utf-16le", "h"},
+		{"utf-16 surrogate pair", "\x3d\xd8\x00\xde", "text/plain; charset=utf-16le", "😀"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _, _ := decodeCharset([]byte(tt.body), tt.contentType, true); got != tt.want {
+				t.Errorf("decodeCharset() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBinaryContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        bool
+	}{
+		{"text", "text/plain", "hello", false},
+		{"json", "application/json; charset=utf-8", `{"a": 1}`, false},
+		{"structured json", "application/vnd.api+json", `{"a": 1}`, false},
+		{"structured xml", "application/atom+xml", "<feed/>", false},
+		{"javascript", "application/javascript", "let a = 1", false},
+		{"image", "image/png", "\x89PNG\r\n", true},
+		{"svg", "image/svg+xml", "<svg/>", false},
+		{"video", "video/mp4", "", true},
+		{"octet-stream text", "application/octet-stream", "hello", false},
+		{"octet-stream binary", "application/octet-stream", "\x00\x01\x02", true},
+		{"pdf", "application/pdf", "%PDF-1.7\n", true},
+		{"gzip", "application/gzip", "\x1f\x8b\x08\x00", true},
+		{"no type text", "", "hello", false},
+		{"no type zip", "", "PK\x03\x04", true},
+		{"no type utf-16", "", "\xff\xfeh\x00i\x00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBinaryContent(tt.contentType, []byte(tt.body)); got != tt.want {
+				t.Errorf("isBinaryContent(%q) = %v, want %v", tt.contentType, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// CopyTool implements file and directory copying
+type CopyTool struct {
+	workspace *Workspace
+}
+
+// CopyParams defines the parameters for the Copy tool
+type CopyParams struct {
+	Source           string `json:"source"`
+	Destination      string `json:"destination"`
+	Recursive        bool   `json:"recursive,omitempty"`
+	Overwrite        bool   `json:"overwrite,omitempty"`
+	PreserveSymlinks bool   `json:"preserve_symlinks,omitempty"` // Copy links as links instead of what they point to
+	DryRun           bool   `json:"dry_run,omitempty"`
+}
+
+// CopyResult represents the result of a copy operation
+type CopyResult struct {
+	Source      string     `json:"source"`
+	Destination string     `json:"destination"`
+	DryRun      bool       `json:"dry_run,omitempty"`
+	Files       []FileInfo `json:"files"` // Every file and directory created, at its new path
+	Count       int        `json:"count"`
+	Message     string     `json:"message"`
+}
+
+// NewCopyTool creates a new Copy tool instance
+func NewCopyTool() *CopyTool {
+	return &CopyTool{}
+}
+
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *CopyTool) WithWorkspace(ws *Workspace) *CopyTool {
+	t.workspace = ws
+	return t
+}
+
+// Name returns the tool's name
+func (t *CopyTool) Name() string {
+	return "copy"
+}
+
+// Description returns the tool's description
+func (t *CopyTool) Description() string {
+	return "Copy a file or directory tree, preserving permissions and optionally symlinks, without overwriting unless asked; supports dry-run mode"
+}
+
+// Risk returns the tool's risk class
+func (t *CopyTool) Risk() Risk {
+	return RiskMutating
+}
+
+// ChangedPaths returns the destination; the source is only read
+func (t *CopyTool) ChangedPaths(params json.RawMessage) []string {
+	var p CopyParams
+	if err := json.Unmarshal(params, &p); err != nil || p.Destination == "" {
+		return nil
+	}
+	return []string{p.Destination}
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *CopyTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"source": map[string]interface{}{
+				"type":        "string",
+				"description": "Path of the file or directory to copy",
+			},
+			"destination": map[string]interface{}{
+				"type":        "string",
+				"description": "Path of the copy; parent directories are created as needed",
+			},
+			"recursive": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Copy directories and everything in them",
+			},
+			"overwrite": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Replace existing files at the destination and merge into existing directories (default: false)",
+			},
+			"preserve_symlinks": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Copy symlinks as links instead of copying what they point to (default: false)",
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "List what would be copied without copying anything",
+			},
+		},
+		"required":             []string{"source", "destination"},
+		"additionalProperties": false,
+	}
+}
+
+// Validate checks if the parameters are valid
+func (t *CopyTool) Validate(params json.RawMessage) error {
+	var p CopyParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.Source == "" {
+		return fmt.Errorf("source is required")
+	}
+
+	if p.Destination == "" {
+		return fmt.Errorf("destination is required")
+	}
+
+	return nil
+}
+
+// Execute runs the tool with the given parameters
+func (t *CopyTool) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p CopyParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	for _, path := range []string{p.Source, p.Destination} {
+		if err := t.workspace.Check(path); err != nil {
+			return nil, err
+		}
+	}
+
+	info, err := os.Stat(p.Source)
+	if p.PreserveSymlinks {
+		info, err = os.Lstat(p.Source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot access source: %w", err)
+	}
+	if info.IsDir() && !p.Recursive {
+		return nil, fmt.Errorf("source is a directory, use recursive=true to copy directories")
+	}
+
+	if err := checkTransfer(p.Source, p.Destination, info.IsDir(), p.Overwrite); err != nil {
+		return nil, err
+	}
+
+	opts := copyOptions{
+		followSymlinks: !p.PreserveSymlinks,
+		overwrite:      p.Overwrite,
+		dryRun:         p.DryRun,
+	}
+	if t.workspace != nil {
+		opts.check = t.workspace.Check
+	}
+
+	if !p.DryRun {
+		if err := os.MkdirAll(filepath.Dir(p.Destination), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	files, err := copyTree(ctx, p.Source, p.Destination, opts)
+	result := &CopyResult{
+		Source:      p.Source,
+		Destination: p.Destination,
+		DryRun:      p.DryRun,
+		Files:       files,
+		Count:       len(files),
+	}
+	if err != nil {
+		result.Message = fmt.Sprintf("Copied %d item(s) before failing", len(files))
+		return result, fmt.Errorf("failed to copy %s: %w", p.Source, err)
+	}
+
+	if p.DryRun {
+		result.Message = fmt.Sprintf("Would copy %d item(s)", len(files))
+	} else {
+		result.Message = fmt.Sprintf("Copied %d item(s)", len(files))
+	}
+	return result, nil
+}
+
+// checkTransfer checks that src can be copied or moved to dst: they must
+// differ, a directory cannot go inside itself, and dst may only exist if
+// overwrite is set
+func checkTransfer(src, dst string, isDir, overwrite bool) error {
+	absSrc, err := filepath.Abs(src)
+	if err != nil {
+		return fmt.Errorf("cannot resolve path %s: %w", src, err)
+	}
+	absDst, err := filepath.Abs(dst)
+	if err != nil {
+		return fmt.Errorf("cannot resolve path %s: %w", dst, err)
+	}
+
+	if absSrc == absDst {
+		return fmt.Errorf("source and destination are the same path: %s", src)
+	}
+	if _, inside := within(absSrc, absDst); inside && isDir {
+		return fmt.Errorf("cannot copy or move directory %s into itself", src)
+	}
+
+	if _, err := os.Lstat(dst); err == nil && !overwrite {
+		return fmt.Errorf("destination %s already exists; set overwrite to replace it", dst)
+	}
+	return nil
+}
+
+// copyOptions control copyTree
+type copyOptions struct {
+	followSymlinks bool               // Copy what links point to instead of the links
+	overwrite      bool               // Replace existing files and merge into existing directories
+	dryRun         bool               // Only report what would be copied
+	check          func(string) error // Called with every source path before it is copied
+	target         string             // Real path of the destination, set by copyTree
+}
+
+// copyTree copies a file, symlink or directory tree from src to dst, keeping
+// permissions and modification times. It returns what it created, at the new
+// paths. Symlink loops are reported as errors when links are followed.
+func copyTree(ctx context.Context, src, dst string, opts copyOptions) ([]FileInfo, error) {
+	if parent, err := filepath.EvalSymlinks(filepath.Dir(dst)); err == nil {
+		opts.target = filepath.Join(parent, filepath.Base(dst))
+	}
+
+	var files []FileInfo
+	err := copyEntry(ctx, src, dst, opts, map[string]bool{}, &files)
+	return files, err
+}
+
+// copyEntry copies one path, recursing into directories. visited holds the
+// real paths of the directories being copied, to detect symlink loops.
+func copyEntry(ctx context.Context, src, dst string, opts copyOptions, visited map[string]bool, files *[]FileInfo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if opts.check != nil {
+		if err := opts.check(src); err != nil {
+			return err
+		}
+	}
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if opts.followSymlinks && info.Mode()&os.ModeSymlink != 0 {
+		if info, err = os.Stat(src); err != nil {
+			return err
+		}
+	}
+
+	existing, err := os.Lstat(dst)
+	exists := err == nil
+	if exists && !opts.overwrite {
+		return fmt.Errorf("destination %s already exists", dst)
+	}
+
+	switch {
+	case info.IsDir():
+		real, err := filepath.EvalSymlinks(src)
+		if err != nil {
+			return err
+		}
+		if visited[real] {
+			return fmt.Errorf("symlink loop at %s", src)
+		}
+		if real == opts.target {
+			return fmt.Errorf("cannot copy %s into itself through a symlink", src)
+		}
+		visited[real] = true
+		defer delete(visited, real)
+
+		if exists && !existing.IsDir() {
+			return fmt.Errorf("destination %s exists and is not a directory", dst)
+		}
+		if !opts.dryRun && !exists {
+			// Keep the directory writable until its entries are copied
+			if err := os.Mkdir(dst, info.Mode().Perm()|0700); err != nil {
+				return err
+			}
+		}
+		if !exists {
+			*files = append(*files, newFileInfo(dst, info))
+		}
+
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyEntry(ctx, filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name()), opts, visited, files); err != nil {
+				return err
+			}
+		}
+
+		if opts.dryRun || exists {
+			return nil
+		}
+		if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		return os.Chtimes(dst, info.ModTime(), info.ModTime())
+
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		*files = append(*files, newFileInfo(dst, info))
+		if opts.dryRun {
+			return nil
+		}
+		if exists {
+			if existing.IsDir() {
+				return fmt.Errorf("destination %s is a directory", dst)
+			}
+			if err := os.Remove(dst); err != nil {
+				return err
+			}
+		}
+		return os.Symlink(target, dst)
+
+	case info.Mode().IsRegular():
+		if exists && existing.IsDir() {
+			return fmt.Errorf("destination %s is a directory", dst)
+		}
+		*files = append(*files, newFileInfo(dst, info))
+		if opts.dryRun {
+			return nil
+		}
+		if err := copyRegularFile(src, dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		return os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+
+	return fmt.Errorf("cannot copy %s: unsupported file type", src)
+}
+
+// copyRegularFile copies the content of src to dst with the given
+// permissions. The content is written to a temporary file that replaces dst
+// once complete, so an existing dst is never left half written.
+func copyRegularFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return commitFile(tmp, dst)
+}
@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopyTool_Name(t *testing.T) {
+	tool := NewCopyTool()
+	if tool.Name() != "copy" {
+		t.Errorf("Expected name 'copy', got '%s'", tool.Name())
+	}
+}
+
+func TestCopyTool_Validate(t *testing.T) {
+	tool := NewCopyTool()
+
+	tests := []struct {
+		name    string
+		params  string
+		wantErr bool
+	}{
+		{
+			name:    "valid params",
+			params:  `{"source": "a.txt", "destination": "b.txt"}`,
+			wantErr: false,
+		},
+		{
+			name:    "valid with options",
+			params:  `{"source": "dir", "destination": "copy", "recursive": true, "overwrite": true, "preserve_symlinks": true, "dry_run": true}`,
+			wantErr: false,
+		},
+		{
+			name:    "missing source",
+			params:  `{"destination": "b.txt"}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing destination",
+			params:  `{"source": "a.txt"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			params:  `{invalid}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tool.Validate(json.RawMessage(tt.params))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCopyTool_Execute_File(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "hello"})
+	src := filepath.Join(dir, "a.txt")
+	os.Chmod(src, 0600)
+	dst := filepath.Join(dir, "nested", "b.txt")
+
+	tool := NewCopyTool()
+	params, _ := json.Marshal(CopyParams{Source: src, Destination: dst})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	copyResult := result.(*CopyResult)
+	if copyResult.Count != 1 || copyResult.Files[0].Path != dst {
+		t.Errorf("Expected the destination to be reported, got %+v", copyResult.Files)
+	}
+	if got := readFile(t, dst); got != "hello" {
+		t.Errorf("Expected content 'hello', got %q", got)
+	}
+	if got := readFile(t, src); got != "hello" {
+		t.Errorf("Expected the source to be kept, got %q", got)
+	}
+	if info, err := os.Stat(dst); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600 to be kept, got %v, %v", info, err)
+	}
+}
+
+func TestCopyTool_Execute_Overwrite(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "new", "b.txt": "old"})
+	src := filepath.Join(dir, "a.txt")
+	dst := filepath.Join(dir, "b.txt")
+
+	tool := NewCopyTool()
+	params, _ := json.Marshal(CopyParams{Source: src, Destination: dst})
+	if _, err := tool.Execute(context.Background(), params); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected an already exists error, got %v", err)
+	}
+	if got := readFile(t, dst); got != "old" {
+		t.Errorf("Expected the destination to be untouched, got %q", got)
+	}
+
+	params, _ = json.Marshal(CopyParams{Source: src, Destination: dst, Overwrite: true})
+	if _, err := tool.Execute(context.Background(), params); err != nil {
+		t.Fatalf("Execute with overwrite failed: %v", err)
+	}
+	if got := readFile(t, dst); got != "new" {
+		t.Errorf("Expected the destination to be replaced, got %q", got)
+	}
+}
+
+func TestCopyTool_Execute_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"src/a.txt":     "a",
+		"src/sub/b.txt": "b",
+	})
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+
+	tool := NewCopyTool()
+	params, _ := json.Marshal(CopyParams{Source: src, Destination: dst})
+	if _, err := tool.Execute(context.Background(), params); err == nil || !strings.Contains(err.Error(), "recursive") {
+		t.Errorf("Expected an error asking for recursive, got %v", err)
+	}
+
+	params, _ = json.Marshal(CopyParams{Source: src, Destination: dst, Recursive: true})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if count := result.(*CopyResult).Count; count != 4 {
+		t.Errorf("Expected 4 items to be copied, got %d", count)
+	}
+	if got := readFile(t, filepath.Join(dst, "sub", "b.txt")); got != "b" {
+		t.Errorf("Expected nested file to be copied, got %q", got)
+	}
+}
+
+func TestCopyTool_Execute_IntoItself(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"src/a.txt": "a"})
+	src := filepath.Join(dir, "src")
+
+	tool := NewCopyTool()
+	params, _ := json.Marshal(CopyParams{Source: src, Destination: filepath.Join(src, "copy"), Recursive: true})
+	if _, err := tool.Execute(context.Background(), params); err == nil || !strings.Contains(err.Error(), "into itself") {
+		t.Errorf("Expected an into itself error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(src, "copy")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be created, got %v", err)
+	}
+}
+
+func TestCopyTool_Execute_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"src/a.txt": "a", "src/b.txt": "b"})
+	dst := filepath.Join(dir, "out", "dst")
+
+	tool := NewCopyTool()
+	params, _ := json.Marshal(CopyParams{Source: filepath.Join(dir, "src"), Destination: dst, Recursive: true, DryRun: true})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	copyResult := result.(*CopyResult)
+	if !copyResult.DryRun || copyResult.Count != 3 {
+		t.Errorf("Expected a dry run of 3 items, got %+v", copyResult)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be created, got %v", err)
+	}
+}
+
+func TestCopyTool_Execute_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"src/a.txt": "a"})
+	if err := os.Symlink("a.txt", filepath.Join(dir, "src", "link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	tool := NewCopyTool()
+
+	params, _ := json.Marshal(CopyParams{Source: filepath.Join(dir, "src"), Destination: filepath.Join(dir, "kept"), Recursive: true, PreserveSymlinks: true})
+	if _, err := tool.Execute(context.Background(), params); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "kept", "link")); err != nil || target != "a.txt" {
+		t.Errorf("Expected the symlink to be preserved, got %q, %v", target, err)
+	}
+
+	params, _ = json.Marshal(CopyParams{Source: filepath.Join(dir, "src"), Destination: filepath.Join(dir, "followed"), Recursive: true})
+	if _, err := tool.Execute(context.Background(), params); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	info, err := os.Lstat(filepath.Join(dir, "followed", "link"))
+	if err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("Expected the symlink to be followed, got %v, %v", info, err)
+	}
+	if got := readFile(t, filepath.Join(dir, "followed", "link")); got != "a" {
+		t.Errorf("Expected the link target's content, got %q", got)
+	}
+}
+
+func TestCopyTool_Execute_SymlinkLoop(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"src/sub/a.txt": "a"})
+	if err := os.Symlink("..", filepath.Join(dir, "src", "sub", "up")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	tool := NewCopyTool()
+	params, _ := json.Marshal(CopyParams{Source: filepath.Join(dir, "src"), Destination: filepath.Join(dir, "dst"), Recursive: true})
+	if _, err := tool.Execute(context.Background(), params); err == nil || !strings.Contains(err.Error(), "symlink loop") {
+		t.Errorf("Expected a symlink loop error, got %v", err)
+	}
+}
+
+func TestCopyTool_Execute_Workspace(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a"})
+	ws, err := NewWorkspace(dir)
+	if err != nil {
+		t.Fatalf("NewWorkspace failed: %v", err)
+	}
+
+	tool := NewCopyTool().WithWorkspace(ws)
+	params, _ := json.Marshal(CopyParams{Source: filepath.Join(dir, "a.txt"), Destination: filepath.Join(t.TempDir(), "a.txt")})
+	if _, err := tool.Execute(context.Background(), params); err == nil {
+		t.Error("Expected a destination outside the workspace to be rejected")
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.txt":         "aaaa",
+		"docs/sub/c.md": "c",
+	})
+	os.Chmod(filepath.Join(dir, "a.txt"), 0600)
+	if err := os.Symlink("a.txt", filepath.Join(dir, "link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "copy")
+	if _, err := copyTree(context.Background(), dir, dst, copyOptions{}); err != nil {
+		t.Fatalf("copyTree failed: %v", err)
+	}
+
+	if got := readFile(t, filepath.Join(dst, "docs", "sub", "c.md")); got != "c" {
+		t.Errorf("Expected nested file to be copied, got %q", got)
+	}
+	if info, err := os.Stat(filepath.Join(dst, "a.txt")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600 to be kept, got %v, %v", info, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "link")); err != nil || target != "a.txt" {
+		t.Errorf("Expected the symlink to be copied, got %q, %v", target, err)
+	}
+}
@@ -9,7 +9,10 @@ import (
 )
 
 // DeleteTool implements file and directory deletion functionality
-type DeleteTool struct{}
+type DeleteTool struct {
+	workspace *Workspace
+	trash     *Trash
+}
 
 // DeleteParams defines the parameters for the Delete tool
 type DeleteParams struct {
@@ -17,16 +20,18 @@ type DeleteParams struct {
 	Recursive bool   `json:"recursive,omitempty"`
 	Confirm   bool   `json:"confirm,omitempty"`
 	DryRun    bool   `json:"dry_run,omitempty"`
+	Trash     *bool  `json:"trash,omitempty"` // Move to the trash; defaults to true when a trash is configured
 }
 
 // DeleteResult represents the result of a delete operation
 type DeleteResult struct {
-	Path      string   `json:"path"`
-	Deleted   bool     `json:"deleted"`
-	DryRun    bool     `json:"dry_run,omitempty"`
-	FilesRemoved int   `json:"files_removed,omitempty"`
-	Message   string   `json:"message,omitempty"`
-	Items     []string `json:"items,omitempty"` // List of items that would be deleted (dry-run)
+	Path         string   `json:"path"`
+	Deleted      bool     `json:"deleted"`
+	DryRun       bool     `json:"dry_run,omitempty"`
+	FilesRemoved int      `json:"files_removed,omitempty"`
+	Message      string   `json:"message,omitempty"`
+	Items        []string `json:"items,omitempty"`    // List of items that would be deleted (dry-run)
+	TrashID      string   `json:"trash_id,omitempty"` // ID for trash_restore when moved to the trash
 }
 
 // NewDeleteTool creates a new Delete tool instance
@@ -34,6 +39,19 @@ func NewDeleteTool() *DeleteTool {
 	return &DeleteTool{}
 }
 
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *DeleteTool) WithWorkspace(ws *Workspace) *DeleteTool {
+	t.workspace = ws
+	return t
+}
+
+// WithTrash moves deleted paths into trash instead of removing them, unless a
+// call sets "trash" to false
+func (t *DeleteTool) WithTrash(trash *Trash) *DeleteTool {
+	t.trash = trash
+	return t
+}
+
 // Name returns the tool's name
 func (t *DeleteTool) Name() string {
 	return "delete"
@@ -44,6 +62,11 @@ func (t *DeleteTool) Description() string {
 	return "Delete files and directories with safety features including recursive deletion, dry-run mode, and confirmation prompts"
 }
 
+// Risk returns the tool's risk class
+func (t *DeleteTool) Risk() Risk {
+	return RiskDestructive
+}
+
 // Schema returns the JSON schema for the tool's parameters
 func (t *DeleteTool) Schema() map[string]interface{} {
 	return map[string]interface{}{
@@ -59,14 +82,19 @@ func (t *DeleteTool) Schema() map[string]interface{} {
 			},
 			"confirm": map[string]interface{}{
 				"type":        "boolean",
-				"description": "Require confirmation before deletion (currently always true for safety)",
+				"description": "Ask the user to approve this deletion even if the approval policy would allow it",
 			},
 			"dry_run": map[string]interface{}{
 				"type":        "boolean",
 				"description": "Simulate deletion without actually removing files",
 			},
+			"trash": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Move to the trash so it can be restored with trash_restore (default: true when a trash is configured); false deletes permanently",
+			},
 		},
-		"required": []string{"path"},
+		"required":             []string{"path"},
+		"additionalProperties": false,
 	}
 }
 
@@ -81,6 +109,10 @@ func (t *DeleteTool) Validate(params json.RawMessage) error {
 		return fmt.Errorf("path is required")
 	}
 
+	if p.Trash != nil && *p.Trash && t.trash == nil {
+		return fmt.Errorf("trash is not configured for this tool")
+	}
+
 	return nil
 }
 
@@ -91,6 +123,13 @@ func (t *DeleteTool) Execute(ctx context.Context, params json.RawMessage) (inter
 		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
 
+	if err := t.workspace.Check(p.Path); err != nil {
+		return nil, err
+	}
+	if t.workspace.IsRoot(p.Path) {
+		return nil, fmt.Errorf("path %s is a workspace root and cannot be deleted", p.Path)
+	}
+
 	// Check if path exists
 	info, err := os.Stat(p.Path)
 	if err != nil {
@@ -105,6 +144,13 @@ func (t *DeleteTool) Execute(ctx context.Context, params json.RawMessage) (inter
 		return nil, fmt.Errorf("path is a directory, use recursive=true to delete directories")
 	}
 
+	// Refuse to delete a directory holding paths the workspace denies
+	if info.IsDir() && t.workspace != nil {
+		if err := t.checkTree(p.Path); err != nil {
+			return nil, err
+		}
+	}
+
 	// Dry run mode - just list what would be deleted
 	if p.DryRun {
 		items, count, err := t.listDeletionItems(p.Path, info.IsDir())
@@ -130,12 +176,30 @@ func (t *DeleteTool) Execute(ctx context.Context, params json.RawMessage) (inter
 			return nil, fmt.Errorf("failed to count files: %w", err)
 		}
 		filesRemoved = count
+	} else {
+		filesRemoved = 1
+	}
+
+	if t.trash != nil && (p.Trash == nil || *p.Trash) {
+		item, err := t.trash.Put(p.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		return &DeleteResult{
+			Path:         p.Path,
+			Deleted:      true,
+			FilesRemoved: filesRemoved,
+			TrashID:      item.ID,
+			Message:      fmt.Sprintf("Moved %d item(s) to the trash as %s", filesRemoved, item.ID),
+		}, nil
+	}
 
+	if info.IsDir() {
 		if err := os.RemoveAll(p.Path); err != nil {
 			return nil, fmt.Errorf("failed to delete directory: %w", err)
 		}
 	} else {
-		filesRemoved = 1
 		if err := os.Remove(p.Path); err != nil {
 			return nil, fmt.Errorf("failed to delete file: %w", err)
 		}
@@ -149,6 +213,24 @@ func (t *DeleteTool) Execute(ctx context.Context, params json.RawMessage) (inter
 	}, nil
 }
 
+// checkTree returns an error for the first path under a directory that the
+// workspace denies. Symlinks are checked by name only, since deleting a link
+// leaves its target alone.
+func (t *DeleteTool) checkTree(path string) error {
+	return filepath.Walk(path, func(walkPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if walkPath == path {
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return t.workspace.checkName(walkPath)
+		}
+		return t.workspace.Check(walkPath)
+	})
+}
+
 // listDeletionItems returns a list of items that would be deleted
 func (t *DeleteTool) listDeletionItems(path string, isDir bool) ([]string, int, error) {
 	items := []string{}
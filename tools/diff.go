@@ -0,0 +1,459 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// maxDiffCells bounds the size of the table used to diff the changed region
+// of two files. Larger regions are shown as a single replacement.
+const maxDiffCells = 4 << 20
+
+// noNewlineMarker follows a diff line that has no trailing newline
+const noNewlineMarker = `\ No newline at end of file`
+
+// diffOp is one line of a line-by-line diff
+type diffOp struct {
+	kind byte   // ' ' for unchanged, '-' for removed, '+' for added
+	line string // Line including its newline, if it has one
+}
+
+// UnifiedDiff returns a unified diff turning oldText into newText, with
+// oldName and newName in the file headers. It returns "" if the texts are
+// equal.
+func UnifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range diffHunks(ops) {
+		writeHunk(&b, ops, hunk)
+	}
+	return b.String()
+}
+
+// splitLines splits text into lines, each keeping its newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a line diff of a and b. Common leading and trailing
+// lines are matched directly, and the rest with a longest common
+// subsequence.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// diffMiddle diffs the changed region of two files
+func diffMiddle(a, b []string) []diffOp {
+	var ops []diffOp
+
+	if len(a) == 0 || len(b) == 0 || (len(a)+1)*(len(b)+1) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i*(m+1)+j] is the length of the longest common subsequence of a[i:] and b[j:]
+	n, m := len(a), len(b)
+	lcs := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			} else {
+				lcs[i*(m+1)+j] = max(lcs[(i+1)*(m+1)+j], lcs[i*(m+1)+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// diffHunk is a range of ops shown as one hunk
+type diffHunk struct {
+	start, end int
+}
+
+// diffHunks groups changes into hunks with diffContext lines of context,
+// merging changes whose context would overlap
+func diffHunks(ops []diffOp) []diffHunk {
+	var hunks []diffHunk
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		start := max(i-diffContext, 0)
+		end := min(i+1+diffContext, len(ops))
+		if n := len(hunks); n > 0 && start <= hunks[n-1].end {
+			hunks[n-1].end = end
+			continue
+		}
+		hunks = append(hunks, diffHunk{start, end})
+	}
+	return hunks
+}
+
+// writeHunk writes a hunk header and its lines
+func writeHunk(b *strings.Builder, ops []diffOp, hunk diffHunk) {
+	// Line numbers before the hunk
+	oldLine, newLine := 0, 0
+	for _, op := range ops[:hunk.start] {
+		if op.kind != '+' {
+			oldLine++
+		}
+		if op.kind != '-' {
+			newLine++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, op := range ops[hunk.start:hunk.end] {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(b, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+	for _, op := range ops[hunk.start:hunk.end] {
+		b.WriteByte(op.kind)
+		if strings.HasSuffix(op.line, "\n") {
+			b.WriteString(op.line)
+		} else {
+			b.WriteString(op.line)
+			b.WriteString("\n" + noNewlineMarker + "\n")
+		}
+	}
+}
+
+// hunkRange formats the start and length of one side of a hunk. Empty ranges
+// start at the line before them, and a length of one is left out.
+func hunkRange(before, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// FilePatch is the part of a unified diff that changes one file
+type FilePatch struct {
+	OldPath string      // Path before the change; "" for a created file
+	NewPath string      // Path after the change; "" for a deleted file
+	Mode    os.FileMode // Permissions of a created file, from a git "new file mode" line
+	Binary  bool        // The change is a binary patch, which cannot be applied
+	Hunks   []Hunk
+}
+
+// Hunk is one "@@" section of a unified diff
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []HunkLine
+}
+
+// HunkLine is a line of a hunk
+type HunkLine struct {
+	Kind byte   // ' ' for context, '-' for removed, '+' for added
+	Text string // Line including its newline, unless marked as having none
+}
+
+// Header returns the hunk's "@@" line without any section heading
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// hunkHeader matches the start of a "@@" line
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParsePatch parses a unified diff, which may change several files and may
+// use git's extended headers to create, delete and rename files. strip
+// leading path components are removed from the paths in "---", "+++" and
+// "diff --git" lines, like patch -p; a negative strip removes git's "a/" and
+// "b/" prefixes when both paths have them. Text outside file headers and
+// hunks is ignored.
+func ParsePatch(text string, strip int) ([]FilePatch, error) {
+	lines := strings.SplitAfter(text, "\n")
+	var patches []FilePatch
+	var cur *FilePatch
+	hasHeader := false // The current file has had its ---/+++ lines
+
+	start := func() {
+		patches = append(patches, FilePatch{})
+		cur = &patches[len(patches)-1]
+		hasHeader = false
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			start()
+			oldPath, newPath := splitGitPaths(line[len("diff --git "):])
+			if err := setPatchPaths(cur, oldPath, newPath, strip); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if cur == nil || hasHeader || len(cur.Hunks) > 0 {
+				start()
+			}
+			oldPath := patchFileName(line[4:])
+			newPath := patchFileName(strings.TrimRight(lines[i+1], "\r\n")[4:])
+			if err := setPatchPaths(cur, oldPath, newPath, strip); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			hasHeader = true
+			i++
+
+		case strings.HasPrefix(line, "@@ "):
+			if cur == nil {
+				return nil, fmt.Errorf("line %d: hunk without a file header", i+1)
+			}
+			hunk, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			cur.Hunks = append(cur.Hunks, hunk)
+			i = next - 1
+
+		case cur != nil && len(cur.Hunks) == 0:
+			parseGitHeader(cur, line)
+		}
+	}
+
+	return patches, nil
+}
+
+// parseGitHeader applies a git extended header line to a file patch
+func parseGitHeader(fp *FilePatch, line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		fp.OldPath = ""
+		if mode, err := strconv.ParseUint(strings.TrimPrefix(line, "new file mode "), 8, 32); err == nil {
+			fp.Mode = os.FileMode(mode).Perm()
+		}
+	case strings.HasPrefix(line, "deleted file mode "):
+		fp.NewPath = ""
+	case strings.HasPrefix(line, "rename from "):
+		fp.OldPath = patchFileName(strings.TrimPrefix(line, "rename from "))
+	case strings.HasPrefix(line, "rename to "):
+		fp.NewPath = patchFileName(strings.TrimPrefix(line, "rename to "))
+	case strings.HasPrefix(line, "GIT binary patch"), strings.HasPrefix(line, "Binary files "):
+		fp.Binary = true
+	}
+}
+
+// parseHunk parses the hunk starting at lines[start] and returns it with the
+// index of the line after it
+func parseHunk(lines []string, start int) (Hunk, int, error) {
+	header := strings.TrimRight(lines[start], "\r\n")
+	m := hunkHeader.FindStringSubmatch(header)
+	if m == nil {
+		return Hunk{}, 0, fmt.Errorf("line %d: malformed hunk header %q", start+1, header)
+	}
+
+	number := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	hunk := Hunk{
+		OldStart: number(m[1]),
+		OldLines: number(m[2]),
+		NewStart: number(m[3]),
+		NewLines: number(m[4]),
+	}
+
+	oldLeft, newLeft := hunk.OldLines, hunk.NewLines
+	i := start + 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+
+		// A marker may follow the hunk's last line
+		if strings.HasPrefix(line, "\\") {
+			if n := len(hunk.Lines); n > 0 {
+				hunk.Lines[n-1].Text = strings.TrimSuffix(hunk.Lines[n-1].Text, "\n")
+			}
+			continue
+		}
+		if oldLeft == 0 && newLeft == 0 {
+			break
+		}
+
+		kind := byte(' ')
+		text := "\n" // Some editors strip the space from empty context lines
+		if line != "\n" && line != "\r\n" {
+			if line == "" {
+				break
+			}
+			kind, text = line[0], line[1:]
+		}
+
+		switch kind {
+		case ' ':
+			oldLeft--
+			newLeft--
+		case '-':
+			oldLeft--
+		case '+':
+			newLeft--
+		default:
+			return Hunk{}, 0, fmt.Errorf("line %d: unexpected line in hunk: %q", i+1, strings.TrimRight(line, "\r\n"))
+		}
+		if oldLeft < 0 || newLeft < 0 {
+			return Hunk{}, 0, fmt.Errorf("line %d: hunk has more lines than its header %s says", i+1, hunk.Header())
+		}
+		hunk.Lines = append(hunk.Lines, HunkLine{Kind: kind, Text: text})
+	}
+
+	if oldLeft > 0 || newLeft > 0 {
+		return Hunk{}, 0, fmt.Errorf("line %d: hunk %s is truncated", start+1, hunk.Header())
+	}
+	return hunk, i, nil
+}
+
+// setPatchPaths sets a file patch's paths from header names, stripping
+// leading components
+func setPatchPaths(fp *FilePatch, oldPath, newPath string, strip int) error {
+	if strip < 0 {
+		strip = 0
+		if (oldPath == "" || strings.HasPrefix(oldPath, "a/")) && (newPath == "" || strings.HasPrefix(newPath, "b/")) && oldPath+newPath != "" {
+			strip = 1
+		}
+	}
+
+	var err error
+	if fp.OldPath, err = stripComponents(oldPath, strip); err != nil {
+		return err
+	}
+	fp.NewPath, err = stripComponents(newPath, strip)
+	return err
+}
+
+// stripComponents removes n leading slash-separated components from p
+func stripComponents(p string, n int) (string, error) {
+	if p == "" {
+		return "", nil
+	}
+	stripped := p
+	for i := 0; i < n; i++ {
+		slash := strings.Index(stripped, "/")
+		if slash < 0 {
+			return "", fmt.Errorf("cannot strip %d leading components from %s", n, p)
+		}
+		stripped = stripped[slash+1:]
+	}
+	return stripped, nil
+}
+
+// patchFileName extracts the file name from a "---" or "+++" line, dropping
+// any timestamp and unquoting git's quoted names. /dev/null becomes "".
+func patchFileName(s string) string {
+	s = strings.TrimRight(s, "\r\n")
+	if strings.HasPrefix(s, `"`) {
+		if end := strings.Index(s[1:], `"`); end >= 0 {
+			if unquoted, err := strconv.Unquote(s[:end+2]); err == nil {
+				s = unquoted
+			}
+		}
+	} else if tab := strings.Index(s, "\t"); tab >= 0 {
+		s = s[:tab]
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	return s
+}
+
+// splitGitPaths splits the "a/x b/x" part of a "diff --git" line. The paths
+// may contain spaces, so an unambiguous split on two equal halves is
+// preferred.
+func splitGitPaths(s string) (string, string) {
+	if strings.HasPrefix(s, `"`) {
+		if end := strings.Index(s[1:], `"`); end >= 0 {
+			return patchFileName(s[:end+2]), patchFileName(strings.TrimSpace(s[end+2:]))
+		}
+	}
+	if n := (len(s) - 1) / 2; len(s)%2 == 1 && s[n] == ' ' {
+		oldPath, newPath := s[:n], s[n+1:]
+		if strings.TrimPrefix(oldPath, "a/") == strings.TrimPrefix(newPath, "b/") {
+			return oldPath, newPath
+		}
+	}
+	if space := strings.Index(s, " b/"); space >= 0 {
+		return s[:space], s[space+1:]
+	}
+	if oldPath, newPath, ok := strings.Cut(s, " "); ok {
+		return oldPath, newPath
+	}
+	return s, s
+}
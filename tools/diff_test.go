@@ -0,0 +1,197 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "equal",
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			name: "change in the middle",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- f\n+++ f\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "insertion at start",
+			old:  "b\n",
+			new:  "a\nb\n",
+			want: "--- f\n+++ f\n@@ -1 +1,2 @@\n+a\n b\n",
+		},
+		{
+			name: "new file",
+			old:  "",
+			new:  "a\n",
+			want: "--- f\n+++ f\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name: "missing final newline",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			want: "--- f\n+++ f\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			name: "separate hunks",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			new:  "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			want: "--- f\n+++ f\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UnifiedDiff("f", "f", tt.old, tt.new)
+			if got != tt.want {
+				t.Errorf("Diff mismatch.\nExpected:\n%s\nGot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff_LargeFile(t *testing.T) {
+	var lines []string
+	for i := 0; i < 20000; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	old := strings.Join(lines, "\n") + "\n"
+	lines[10000] = "changed"
+	updated := strings.Join(lines, "\n") + "\n"
+
+	want := "@@ -9998,7 +9998,7 @@\n line 9997\n line 9998\n line 9999\n-line 10000\n+changed\n"
+	if got := UnifiedDiff("f", "f", old, updated); !strings.Contains(got, want) {
+		t.Errorf("Expected diff to contain:\n%s\nGot:\n%s", want, got)
+	}
+}
+
+func TestParsePatch(t *testing.T) {
+	patch := `diff --git a/main.go b/main.go
+index 83db48f..bf269f4 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@ package main
+ package main
+-// old
++// new
+ func main() {}
+diff --git a/new.sh b/new.sh
+new file mode 100755
+--- /dev/null
++++ b/new.sh
+@@ -0,0 +1 @@
++echo hi
+\ No newline at end of file
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/old name.txt b/new name.txt
+similarity index 100%
+rename from old name.txt
+rename to new name.txt
+`
+
+	patches, err := ParsePatch(patch, -1)
+	if err != nil {
+		t.Fatalf("ParsePatch failed: %v", err)
+	}
+	if len(patches) != 4 {
+		t.Fatalf("Expected 4 file patches, got %d", len(patches))
+	}
+
+	paths := []struct{ old, new string }{
+		{"main.go", "main.go"},
+		{"", "new.sh"},
+		{"gone.txt", ""},
+		{"old name.txt", "new name.txt"},
+	}
+	for i, want := range paths {
+		if patches[i].OldPath != want.old || patches[i].NewPath != want.new {
+			t.Errorf("Patch %d: expected %q -> %q, got %q -> %q", i, want.old, want.new, patches[i].OldPath, patches[i].NewPath)
+		}
+	}
+
+	if patches[1].Mode != 0755 {
+		t.Errorf("Expected mode 0755, got %o", patches[1].Mode)
+	}
+	if got := patches[1].Hunks[0].Lines[0].Text; got != "echo hi" {
+		t.Errorf("Expected line without newline, got %q", got)
+	}
+	hunk := patches[0].Hunks[0]
+	if hunk.OldStart != 1 || hunk.OldLines != 3 || len(hunk.Lines) != 4 || hunk.Lines[1].Kind != '-' {
+		t.Errorf("Unexpected hunk %+v", hunk)
+	}
+	if len(patches[3].Hunks) != 0 {
+		t.Errorf("Expected a pure rename to have no hunks")
+	}
+}
+
+func TestParsePatch_Strip(t *testing.T) {
+	patch := "--- project/src/a.go\t2024-01-01 00:00:00\n+++ project/src/a.go\t2024-01-02 00:00:00\n@@ -1 +1 @@\n-a\n+b\n"
+
+	patches, err := ParsePatch(patch, -1)
+	if err != nil || patches[0].NewPath != "project/src/a.go" {
+		t.Errorf("Expected path to be kept without a/ b/ prefixes, got %+v, %v", patches, err)
+	}
+
+	patches, err = ParsePatch(patch, 1)
+	if err != nil || patches[0].NewPath != "src/a.go" {
+		t.Errorf("Expected one component to be stripped, got %+v, %v", patches, err)
+	}
+
+	if _, err := ParsePatch(patch, 5); err == nil {
+		t.Error("Expected error when stripping too many components")
+	}
+}
+
+func TestParsePatch_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"hunk without header", "@@ -1 +1 @@\n-a\n+b\n"},
+		{"truncated hunk", "--- a\n+++ a\n@@ -1,3 +1,3 @@\n a\n"},
+		{"bad line", "--- a\n+++ a\n@@ -1,2 +1,2 @@\n a\n*b\n"},
+		{"malformed header", "--- a\n+++ a\n@@ -x +1 @@\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePatch(tt.patch, 0); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff_RoundTrip(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk"
+	updated := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+
+	patches, err := ParsePatch(UnifiedDiff("f", "f", old, updated), 0)
+	if err != nil {
+		t.Fatalf("ParsePatch failed: %v", err)
+	}
+	got, results := applyHunks(old, patches[0].Hunks, 0)
+	for _, res := range results {
+		if !res.Applied {
+			t.Errorf("Hunk %d failed: %s", res.Hunk, res.Error)
+		}
+	}
+	if got != updated {
+		t.Errorf("Round trip mismatch.\nExpected: %q\nGot: %q", updated, got)
+	}
+}
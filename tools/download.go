@@ -6,10 +6,12 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"hash"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"time"
@@ -18,6 +20,7 @@ import (
 // DownloadTool implements file download functionality
 type DownloadTool struct {
 	client *http.Client
+	policy *NetworkPolicy
 }
 
 // DownloadParams defines the parameters for the Download tool
@@ -43,13 +46,21 @@ type DownloadResult struct {
 	BytesResumed int64  `json:"bytes_resumed,omitempty"`
 }
 
-// NewDownloadTool creates a new Download tool instance
+// NewDownloadTool creates a new Download tool instance. It uses
+// NewNetworkPolicy, so downloads from loopback and private addresses are
+// blocked until WithNetworkPolicy allows them.
 func NewDownloadTool() *DownloadTool {
-	return &DownloadTool{
-		client: &http.Client{
-			Timeout: 300 * time.Second, // 5 minutes default
-		},
-	}
+	t := &DownloadTool{policy: defaultNetworkPolicy}
+	t.client = newToolClient(func() *NetworkPolicy { return t.policy })
+	return t
+}
+
+// WithNetworkPolicy replaces the policy that decides which URLs may be
+// requested; nil allows every URL
+func (t *DownloadTool) WithNetworkPolicy(policy *NetworkPolicy) *DownloadTool {
+	t.policy = policy
+	t.client.Transport = policy.Transport()
+	return t
 }
 
 // Name returns the tool's name
@@ -62,6 +73,20 @@ func (t *DownloadTool) Description() string {
 	return "Download files from URLs with resume support, integrity verification, and progress tracking"
 }
 
+// Risk reports that the tool reaches the network and writes the response to disk
+func (t *DownloadTool) Risk() Risk {
+	return RiskNetwork
+}
+
+// ChangedPaths returns the file the call downloads to
+func (t *DownloadTool) ChangedPaths(params json.RawMessage) []string {
+	var p DownloadParams
+	if err := json.Unmarshal(params, &p); err != nil || p.OutputPath == "" {
+		return nil
+	}
+	return []string{p.OutputPath}
+}
+
 // Schema returns the JSON schema for the tool's parameters
 func (t *DownloadTool) Schema() map[string]interface{} {
 	return map[string]interface{}{
@@ -100,8 +125,14 @@ func (t *DownloadTool) Schema() map[string]interface{} {
 				"minimum":     0,
 				"maximum":     10,
 			},
+			"chunk_size": map[string]interface{}{
+				"type":        "integer",
+				"description": "Size in bytes of each chunk written to disk (default: 1048576)",
+				"minimum":     1,
+			},
 		},
-		"required": []string{"url", "output_path"},
+		"required":             []string{"url", "output_path"},
+		"additionalProperties": false,
 	}
 }
 
@@ -153,8 +184,13 @@ func (t *DownloadTool) Execute(ctx context.Context, params json.RawMessage) (int
 		p.ChunkSize = 1024 * 1024 // 1MB chunks
 	}
 
-	// Update client timeout
-	t.client.Timeout = time.Duration(p.Timeout) * time.Second
+	target, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if err := t.policy.CheckURL(target); err != nil {
+		return nil, err
+	}
 
 	// Ensure output directory exists
 	dir := filepath.Dir(p.OutputPath)
@@ -174,25 +210,38 @@ func (t *DownloadTool) Execute(ctx context.Context, params json.RawMessage) (int
 
 		lastErr = err
 
-		// Don't retry on context errors
+		// Don't retry on context errors or blocked URLs
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
+		if errors.Is(err, ErrBlockedByPolicy) {
+			return nil, err
+		}
 
-		// Wait before retry (exponential backoff)
+		// Wait before retry, as long as the server asks or with jittered
+		// exponential backoff
 		if attempt < maxAttempts-1 {
-			backoff := time.Duration(1<<uint(attempt)) * time.Second
-			time.Sleep(backoff)
+			var retryAfter string
+			var status *statusError
+			if errors.As(err, &status) {
+				retryAfter = status.RetryAfter
+			}
+			if err := sleepContext(ctx, retryDelay(attempt, retryAfter)); err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	return nil, fmt.Errorf("failed after %d attempts: %w", maxAttempts, lastErr)
 }
 
-// downloadFile performs the actual download
+// downloadFile performs the actual download, limited to p.Timeout
 func (t *DownloadTool) downloadFile(ctx context.Context, p DownloadParams) (*DownloadResult, error) {
 	startTime := time.Now()
 
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(p.Timeout)*time.Second)
+	defer cancel()
+
 	// Check if file exists for resume
 	var resumeFrom int64
 	var resumed bool
@@ -223,7 +272,7 @@ func (t *DownloadTool) downloadFile(ctx context.Context, p DownloadParams) (*Dow
 
 	// Check response
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		return nil, &statusError{StatusCode: resp.StatusCode, RetryAfter: resp.Header.Get("Retry-After")}
 	}
 
 	// Verify resume was accepted
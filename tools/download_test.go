@@ -90,7 +90,7 @@ func TestDownloadTool_Execute_SimpleDownload(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, "downloaded.txt")
 
-	tool := NewDownloadTool()
+	tool := NewDownloadTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -145,7 +145,7 @@ func TestDownloadTool_Execute_WithMD5(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, "downloaded.txt")
 
-	tool := NewDownloadTool()
+	tool := NewDownloadTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -189,7 +189,7 @@ func TestDownloadTool_Execute_WithSHA256(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, "downloaded.txt")
 
-	tool := NewDownloadTool()
+	tool := NewDownloadTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -224,7 +224,7 @@ func TestDownloadTool_Execute_ChecksumMismatch(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, "downloaded.txt")
 
-	tool := NewDownloadTool()
+	tool := NewDownloadTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -275,7 +275,7 @@ func TestDownloadTool_Execute_Resume(t *testing.T) {
 		t.Fatalf("Failed to create partial file: %v", err)
 	}
 
-	tool := NewDownloadTool()
+	tool := NewDownloadTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -327,7 +327,7 @@ func TestDownloadTool_Execute_CreateDirectory(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, "nested", "dir", "file.txt")
 
-	tool := NewDownloadTool()
+	tool := NewDownloadTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -364,7 +364,7 @@ func TestDownloadTool_Execute_Duration(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, "file.txt")
 
-	tool := NewDownloadTool()
+	tool := NewDownloadTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -398,7 +398,7 @@ func TestDownloadTool_Execute_404(t *testing.T) {
 	tmpDir := t.TempDir()
 	outputPath := filepath.Join(tmpDir, "file.txt")
 
-	tool := NewDownloadTool()
+	tool := NewDownloadTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
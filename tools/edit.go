@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EditTool implements exact string replacement in files
+type EditTool struct {
+	workspace *Workspace
+}
+
+// EditParams defines the parameters for the Edit tool
+type EditParams struct {
+	Path       string `json:"path"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+// EditResult represents the result of an edit operation
+type EditResult struct {
+	Path         string `json:"path"`
+	Replacements int    `json:"replacements"`
+	Diff         string `json:"diff"`
+}
+
+// NewEditTool creates a new Edit tool instance
+func NewEditTool() *EditTool {
+	return &EditTool{}
+}
+
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *EditTool) WithWorkspace(ws *Workspace) *EditTool {
+	t.workspace = ws
+	return t
+}
+
+// Name returns the tool's name
+func (t *EditTool) Name() string {
+	return "edit"
+}
+
+// Description returns the tool's description
+func (t *EditTool) Description() string {
+	return "Edit a file by replacing an exact string with another, preserving line endings and file mode, and return a unified diff of the change"
+}
+
+// Risk returns the tool's risk class
+func (t *EditTool) Risk() Risk {
+	return RiskMutating
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *EditTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to the file to edit",
+			},
+			"old_string": map[string]interface{}{
+				"type":        "string",
+				"description": "Exact text to replace; must appear exactly once unless replace_all is set",
+				"minLength":   1,
+			},
+			"new_string": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to replace it with",
+			},
+			"replace_all": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Replace every occurrence of old_string (default: false)",
+			},
+		},
+		"required":             []string{"path", "old_string", "new_string"},
+		"additionalProperties": false,
+	}
+}
+
+// Validate checks if the parameters are valid
+func (t *EditTool) Validate(params json.RawMessage) error {
+	var p EditParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+
+	if p.OldString == "" {
+		return fmt.Errorf("old_string is required")
+	}
+
+	if p.OldString == p.NewString {
+		return fmt.Errorf("old_string and new_string must be different")
+	}
+
+	return nil
+}
+
+// Execute runs the tool with the given parameters
+func (t *EditTool) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p EditParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if err := t.workspace.Check(p.Path); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path is a directory, not a file")
+	}
+
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	content := string(data)
+
+	updated, replacements, err := replaceString(p.Path, content, p.OldString, p.NewString, p.ReplaceAll)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeFileAtomic(p.Path, []byte(updated), info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+
+	return &EditResult{
+		Path:         p.Path,
+		Replacements: replacements,
+		Diff:         UnifiedDiff(p.Path, p.Path, content, updated),
+	}, nil
+}
+
+// replaceString replaces oldString with newString in the content of path,
+// failing if it is missing, or not unique unless replaceAll is set. The
+// strings are converted to the content's line endings first, so edits written
+// with \n apply to \r\n files. It returns the new content and the number of
+// replacements.
+func replaceString(path, content, oldString, newString string, replaceAll bool) (string, int, error) {
+	if lineEnding(content) == "\r\n" {
+		oldString = toCRLF(oldString)
+		newString = toCRLF(newString)
+	}
+
+	count := strings.Count(content, oldString)
+	switch {
+	case count == 0:
+		return "", 0, fmt.Errorf("old_string not found in %s", path)
+	case count > 1 && !replaceAll:
+		return "", 0, fmt.Errorf("old_string appears %d times in %s; include more surrounding text to make it unique, or set replace_all", count, path)
+	}
+
+	replacements := 1
+	if replaceAll {
+		replacements = count
+	}
+	return strings.Replace(content, oldString, newString, replacements), replacements, nil
+}
+
+// lineEnding returns "\r\n" if most lines in content end with it, and "\n"
+// otherwise
+func lineEnding(content string) string {
+	crlf := strings.Count(content, "\r\n")
+	if crlf > 0 && crlf >= strings.Count(content, "\n")-crlf {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// toCRLF converts bare \n line endings in s to \r\n
+func toCRLF(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEditTool_Name(t *testing.T) {
+	tool := NewEditTool()
+	if tool.Name() != "edit" {
+		t.Errorf("Expected name 'edit', got '%s'", tool.Name())
+	}
+}
+
+func TestEditTool_Validate(t *testing.T) {
+	tool := NewEditTool()
+
+	tests := []struct {
+		name    string
+		params  string
+		wantErr bool
+	}{
+		{"valid params", `{"path": "a.go", "old_string": "a", "new_string": "b"}`, false},
+		{"empty new_string", `{"path": "a.go", "old_string": "a", "new_string": ""}`, false},
+		{"missing path", `{"old_string": "a", "new_string": "b"}`, true},
+		{"empty old_string", `{"path": "a.go", "old_string": "", "new_string": "b"}`, true},
+		{"same strings", `{"path": "a.go", "old_string": "a", "new_string": "a"}`, true},
+		{"invalid json", `{invalid}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tool.Validate(json.RawMessage(tt.params))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// runEdit writes content to a file, edits it and returns the result and the
+// file's new content
+func runEdit(t *testing.T, content string, params map[string]interface{}) (*EditResult, string, error) {
+	t.Helper()
+
+	testFile := filepath.Join(t.TempDir(), "test.go")
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	params["path"] = testFile
+	paramsJSON, _ := json.Marshal(params)
+
+	result, err := NewEditTool().Execute(context.Background(), paramsJSON)
+	data, readErr := os.ReadFile(testFile)
+	if readErr != nil {
+		t.Fatalf("Failed to read file: %v", readErr)
+	}
+	if err != nil {
+		return nil, string(data), err
+	}
+	return result.(*EditResult), string(data), nil
+}
+
+func TestEditTool_Execute(t *testing.T) {
+	content := "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"
+	result, data, err := runEdit(t, content, map[string]interface{}{
+		"old_string": `println("hello")`,
+		"new_string": `println("goodbye")`,
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	want := "package main\n\nfunc main() {\n\tprintln(\"goodbye\")\n}\n"
+	if data != want {
+		t.Errorf("File content mismatch.\nExpected: %q\nGot: %q", want, data)
+	}
+	if result.Replacements != 1 {
+		t.Errorf("Expected 1 replacement, got %d", result.Replacements)
+	}
+	if !strings.Contains(result.Diff, "-\tprintln(\"hello\")\n+\tprintln(\"goodbye\")\n") {
+		t.Errorf("Unexpected diff:\n%s", result.Diff)
+	}
+	if !strings.HasPrefix(result.Diff, "--- "+result.Path+"\n+++ "+result.Path+"\n@@ -1,5 +1,5 @@\n") {
+		t.Errorf("Unexpected diff header:\n%s", result.Diff)
+	}
+}
+
+func TestEditTool_Execute_Errors(t *testing.T) {
+	content := "a = 1\nb = 1\n"
+
+	_, data, err := runEdit(t, content, map[string]interface{}{"old_string": "c = 1", "new_string": "c = 2"})
+	if err == nil || !strings.Contains(err.Error(), "old_string not found") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+	if data != content {
+		t.Error("Expected file to be unchanged")
+	}
+
+	_, data, err = runEdit(t, content, map[string]interface{}{"old_string": "= 1", "new_string": "= 2"})
+	if err == nil || !strings.Contains(err.Error(), "appears 2 times") {
+		t.Errorf("Expected not unique error, got %v", err)
+	}
+	if data != content {
+		t.Error("Expected file to be unchanged")
+	}
+
+	_, err = NewEditTool().Execute(context.Background(), json.RawMessage(`{"path": "/nonexistent/file.go", "old_string": "a", "new_string": "b"}`))
+	if err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
+
+func TestEditTool_Execute_ReplaceAll(t *testing.T) {
+	result, data, err := runEdit(t, "a = 1\nb = 1\n", map[string]interface{}{
+		"old_string":  "= 1",
+		"new_string":  "= 2",
+		"replace_all": true,
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if data != "a = 2\nb = 2\n" {
+		t.Errorf("Unexpected content %q", data)
+	}
+	if result.Replacements != 2 {
+		t.Errorf("Expected 2 replacements, got %d", result.Replacements)
+	}
+}
+
+func TestEditTool_Execute_CRLF(t *testing.T) {
+	_, data, err := runEdit(t, "one\r\ntwo\r\nthree\r\n", map[string]interface{}{
+		"old_string": "one\ntwo\n",
+		"new_string": "one\n1.5\ntwo\n",
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if data != "one\r\n1.5\r\ntwo\r\nthree\r\n" {
+		t.Errorf("Expected CRLF line endings to be kept, got %q", data)
+	}
+}
+
+func TestEditTool_Execute_KeepsMode(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "run.sh")
+	if err := os.WriteFile(testFile, []byte("echo hi\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(testFile, 0750)
+
+	params, _ := json.Marshal(map[string]interface{}{"path": testFile, "old_string": "hi", "new_string": "bye"})
+	if _, err := NewEditTool().Execute(context.Background(), params); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	info, err := os.Stat(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("Expected mode 0750, got %o", info.Mode().Perm())
+	}
+	if _, err := os.Stat(testFile + ".tmp"); !os.IsNotExist(err) {
+		t.Error("Expected temporary file to be removed")
+	}
+}
@@ -0,0 +1,143 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AnthropicTool is a tool definition in the Anthropic Messages API format
+type AnthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// OpenAIFunction is a function definition in the OpenAI Chat Completions
+// format, as used in the legacy functions array
+type OpenAIFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// OpenAITool is a tool definition in the OpenAI Chat Completions tools array
+type OpenAITool struct {
+	Type     string         `json:"type"`
+	Function OpenAIFunction `json:"function"`
+}
+
+// MCPTool is a tool definition in the Model Context Protocol format
+type MCPTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// MCPToolList is the result of an MCP tools/list request
+type MCPToolList struct {
+	Tools []MCPTool `json:"tools"`
+}
+
+// exportedTool is a registered tool with its checked schema
+type exportedTool struct {
+	name        string
+	description string
+	schema      map[string]interface{}
+}
+
+// AnthropicTools returns the registered tools as an Anthropic tools array
+func (r *ToolRegistry) AnthropicTools() ([]AnthropicTool, error) {
+	exported, err := r.export()
+	if err != nil {
+		return nil, err
+	}
+
+	defs := make([]AnthropicTool, 0, len(exported))
+	for _, tool := range exported {
+		defs = append(defs, AnthropicTool{
+			Name:        tool.name,
+			Description: tool.description,
+			InputSchema: tool.schema,
+		})
+	}
+	return defs, nil
+}
+
+// OpenAIFunctions returns the registered tools as a legacy OpenAI functions array
+func (r *ToolRegistry) OpenAIFunctions() ([]OpenAIFunction, error) {
+	exported, err := r.export()
+	if err != nil {
+		return nil, err
+	}
+
+	defs := make([]OpenAIFunction, 0, len(exported))
+	for _, tool := range exported {
+		defs = append(defs, OpenAIFunction{
+			Name:        tool.name,
+			Description: tool.description,
+			Parameters:  tool.schema,
+		})
+	}
+	return defs, nil
+}
+
+// OpenAITools returns the registered tools as an OpenAI tools array
+func (r *ToolRegistry) OpenAITools() ([]OpenAITool, error) {
+	functions, err := r.OpenAIFunctions()
+	if err != nil {
+		return nil, err
+	}
+
+	defs := make([]OpenAITool, 0, len(functions))
+	for _, function := range functions {
+		defs = append(defs, OpenAITool{Type: "function", Function: function})
+	}
+	return defs, nil
+}
+
+// MCPTools returns the registered tools as an MCP tools/list result
+func (r *ToolRegistry) MCPTools() (*MCPToolList, error) {
+	exported, err := r.export()
+	if err != nil {
+		return nil, err
+	}
+
+	list := &MCPToolList{Tools: make([]MCPTool, 0, len(exported))}
+	for _, tool := range exported {
+		list.Tools = append(list.Tools, MCPTool{
+			Name:        tool.name,
+			Description: tool.description,
+			InputSchema: tool.schema,
+		})
+	}
+	return list, nil
+}
+
+// export returns the registered tools sorted by name, checking that each
+// schema is valid. Schemas are copied so callers cannot modify the tools.
+func (r *ToolRegistry) export() ([]exportedTool, error) {
+	registered := r.ListTools()
+	sort.Slice(registered, func(i, j int) bool {
+		return registered[i].Name() < registered[j].Name()
+	})
+
+	exported := make([]exportedTool, 0, len(registered))
+	for _, tool := range registered {
+		schema := tool.Schema()
+		if err := CheckSchema(schema); err != nil {
+			return nil, fmt.Errorf("tool %s has an invalid schema: %w", tool.Name(), err)
+		}
+
+		normalized, err := normalizeSchema(schema)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s has an invalid schema: %w", tool.Name(), err)
+		}
+
+		exported = append(exported, exportedTool{
+			name:        tool.Name(),
+			description: tool.Description(),
+			schema:      normalized,
+		})
+	}
+	return exported, nil
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// builtinRegistry returns a registry with all built-in tools
+func builtinRegistry(t *testing.T) *ToolRegistry {
+	t.Helper()
+	trash, err := NewTrash(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewTrash failed: %v", err)
+	}
+
+	registry := NewToolRegistry()
+	builtins := []Tool{
+		NewReadTool(),
+		NewWriteTool(),
+		NewEditTool(),
+		NewPatchTool(),
+		NewBatchEditTool(),
+		NewDeleteTool().WithTrash(trash),
+		NewTrashListTool(trash),
+		NewTrashRestoreTool(trash),
+		NewTrashEmptyTool(trash),
+		NewMoveTool(),
+		NewCopyTool(),
+		NewMkdirTool(),
+		NewListTool(),
+		NewGlobTool(),
+		NewGrepTool(),
+		NewSearchTool(),
+		NewShellTool(),
+		NewTaskListTool(),
+		NewFetchTool(),
+		NewDownloadTool(),
+	}
+	for _, tool := range builtins {
+		if err := registry.Register(tool); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+	return registry
+}
+
+// checkGolden compares the indented JSON encoding of value with a golden file
+func checkGolden(t *testing.T, name string, value interface{}) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to encode %s: %v", name, err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "export", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s does not match golden file; run go test ./tools -run %s -update to review changes", name, t.Name())
+	}
+}
+
+func TestBuiltinSchemasAreValid(t *testing.T) {
+	registry := builtinRegistry(t)
+	if registry.Count() != 20 {
+		t.Fatalf("Expected 20 built-in tools, got %d", registry.Count())
+	}
+
+	for _, tool := range registry.ListTools() {
+		if err := CheckSchema(tool.Schema()); err != nil {
+			t.Errorf("Tool %s has an invalid schema: %v", tool.Name(), err)
+		}
+	}
+}
+
+func TestExport_Anthropic(t *testing.T) {
+	defs, err := builtinRegistry(t).AnthropicTools()
+	if err != nil {
+		t.Fatalf("AnthropicTools failed: %v", err)
+	}
+	checkGolden(t, "anthropic.json", defs)
+}
+
+func TestExport_OpenAIFunctions(t *testing.T) {
+	defs, err := builtinRegistry(t).OpenAIFunctions()
+	if err != nil {
+		t.Fatalf("OpenAIFunctions failed: %v", err)
+	}
+	checkGolden(t, "openai_functions.json", defs)
+}
+
+func TestExport_OpenAITools(t *testing.T) {
+	defs, err := builtinRegistry(t).OpenAITools()
+	if err != nil {
+		t.Fatalf("OpenAITools failed: %v", err)
+	}
+	for _, def := range defs {
+		if def.Type != "function" {
+			t.Errorf("Expected type 'function', got '%s'", def.Type)
+		}
+	}
+	checkGolden(t, "openai_tools.json", defs)
+}
+
+func TestExport_MCP(t *testing.T) {
+	list, err := builtinRegistry(t).MCPTools()
+	if err != nil {
+		t.Fatalf("MCPTools failed: %v", err)
+	}
+	checkGolden(t, "mcp.json", list)
+}
+
+func TestExport_SortedAndCopied(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(&mockTool{name: "zeta"})
+	registry.Register(&mockTool{name: "alpha"})
+
+	defs, err := registry.AnthropicTools()
+	if err != nil {
+		t.Fatalf("AnthropicTools failed: %v", err)
+	}
+	if len(defs) != 2 || defs[0].Name != "alpha" || defs[1].Name != "zeta" {
+		t.Errorf("Expected tools sorted by name, got %+v", defs)
+	}
+
+	// Changing an exported schema does not affect the next export
+	defs[0].InputSchema["type"] = "string"
+	again, _ := registry.AnthropicTools()
+	if again[0].InputSchema["type"] != "object" {
+		t.Error("Expected exported schemas to be copies")
+	}
+}
+
+// badSchemaTool is a tool whose schema is not valid JSON Schema
+type badSchemaTool struct {
+	mockTool
+}
+
+func (b *badSchemaTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"count": map[string]interface{}{"type": "int"},
+		},
+	}
+}
+
+func TestExport_InvalidSchema(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(&badSchemaTool{mockTool{name: "bad"}})
+
+	_, err := registry.MCPTools()
+	if err == nil {
+		t.Fatal("Expected error for invalid schema")
+	}
+	if !strings.Contains(err.Error(), "tool bad") || !strings.Contains(err.Error(), "/properties/count/type") {
+		t.Errorf("Expected error naming the tool and location, got: %v", err)
+	}
+}
@@ -2,17 +2,55 @@ package tools
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
 
+const (
+	// defaultFetchMaxBytes is the longest body fetch reads into memory when
+	// max_bytes is not given
+	defaultFetchMaxBytes = 5 << 20
+
+	// fetchPreviewBytes is how much of a body saved with save_path is
+	// returned as content
+	fetchPreviewBytes = 2048
+)
+
+// textMediaTypes are media types outside text/* whose bodies are text
+var textMediaTypes = map[string]bool{
+	"application/json":                  true,
+	"application/xml":                   true,
+	"application/javascript":            true,
+	"application/x-javascript":          true,
+	"application/ecmascript":            true,
+	"application/x-ndjson":              true,
+	"application/yaml":                  true,
+	"application/x-yaml":                true,
+	"application/toml":                  true,
+	"application/graphql":               true,
+	"application/sql":                   true,
+	"application/x-sh":                  true,
+	"application/x-www-form-urlencoded": true,
+}
+
 // FetchTool implements HTTP/HTTPS request functionality
 type FetchTool struct {
-	client *http.Client
+	client    *http.Client
+	cache     *HTTPCache
+	policy    *NetworkPolicy
+	workspace *Workspace
 }
 
 // FetchParams defines the parameters for the Fetch tool
@@ -25,6 +63,9 @@ type FetchParams struct {
 	Timeout    int               `json:"timeout,omitempty"`     // seconds
 	MaxRetries int               `json:"max_retries,omitempty"`
 	Auth       *AuthConfig       `json:"auth,omitempty"`
+	MaxBytes   int64             `json:"max_bytes,omitempty"` // Longest body to read; unlimited with save_path unless set
+	Binary     string            `json:"binary,omitempty"`    // "refuse" or "base64"
+	SavePath   string            `json:"save_path,omitempty"` // File to stream the body to
 }
 
 // AuthConfig defines authentication configuration
@@ -46,21 +87,65 @@ type FetchResult struct {
 	Size        int64             `json:"size"`
 	Duration    int64             `json:"duration_ms"`
 	RedirectURL string            `json:"redirect_url,omitempty"`
+	Cached      bool              `json:"cached,omitempty"` // Answered from the cache, possibly after revalidating
+	Title       string            `json:"title,omitempty"` // Page title, for the html and text formats
+	Links       []FetchLink       `json:"links,omitempty"` // Links in the page, for the html format
+	Data        interface{}       `json:"data,omitempty"`  // Parsed value, for the json and xml formats
+	Truncated   bool              `json:"truncated,omitempty"` // The body was longer than max_bytes and was cut
+	Encoding    string            `json:"encoding,omitempty"`  // "base64" when content is an encoded binary body
+	Charset     string            `json:"charset,omitempty"`   // Charset the body was decoded from, when not UTF-8
+	SavedTo     string            `json:"saved_to,omitempty"`  // File the body was saved to; content is a preview
+	body        []byte            // Raw body, turned into content by finishResult
 }
 
-// NewFetchTool creates a new Fetch tool instance
+// XMLNode is an element of an XML document parsed by the xml format
+type XMLNode struct {
+	Name       string            `json:"name"`
+	Namespace  string            `json:"namespace,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Text       string            `json:"text,omitempty"` // Character data directly inside the element, trimmed
+	Children   []*XMLNode        `json:"children,omitempty"`
+}
+
+// NewFetchTool creates a new Fetch tool instance. It uses NewNetworkPolicy,
+// so requests to loopback and private addresses are blocked until
+// WithNetworkPolicy allows them.
 func NewFetchTool() *FetchTool {
-	return &FetchTool{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				if len(via) >= 10 {
-					return fmt.Errorf("stopped after 10 redirects")
-				}
-				return nil
-			},
-		},
+	t := &FetchTool{policy: defaultNetworkPolicy}
+	t.client = newToolClient(func() *NetworkPolicy { return t.policy })
+	return t
+}
+
+// WithNetworkPolicy replaces the policy that decides which URLs may be
+// requested; nil allows every URL
+func (t *FetchTool) WithNetworkPolicy(policy *NetworkPolicy) *FetchTool {
+	t.policy = policy
+	t.client.Transport = policy.Transport()
+	return t
+}
+
+// WithCache answers GET requests from cache when it holds a fresh response,
+// and stores new responses in it. Requests with authentication, conditional
+// headers or "Cache-Control: no-store" bypass the cache, and
+// "Cache-Control: no-cache" forces a stored response to be revalidated.
+func (t *FetchTool) WithCache(cache *HTTPCache) *FetchTool {
+	t.cache = cache
+	return t
+}
+
+// WithWorkspace confines save_path to ws; paths outside it are rejected
+func (t *FetchTool) WithWorkspace(ws *Workspace) *FetchTool {
+	t.workspace = ws
+	return t
+}
+
+// ChangedPaths returns the file the call saves the response to, if any
+func (t *FetchTool) ChangedPaths(params json.RawMessage) []string {
+	var p FetchParams
+	if err := json.Unmarshal(params, &p); err != nil || p.SavePath == "" {
+		return nil
 	}
+	return []string{p.SavePath}
 }
 
 // Name returns the tool's name
@@ -73,6 +158,11 @@ func (t *FetchTool) Description() string {
 	return "Fetch content from web resources with support for authentication, custom headers, and various HTTP methods"
 }
 
+// Risk returns the tool's risk class
+func (t *FetchTool) Risk() Risk {
+	return RiskNetwork
+}
+
 // Schema returns the JSON schema for the tool's parameters
 func (t *FetchTool) Schema() map[string]interface{} {
 	return map[string]interface{}{
@@ -90,6 +180,9 @@ func (t *FetchTool) Schema() map[string]interface{} {
 			"headers": map[string]interface{}{
 				"type":        "object",
 				"description": "Custom headers as key-value pairs",
+				"additionalProperties": map[string]interface{}{
+					"type": "string",
+				},
 			},
 			"body": map[string]interface{}{
 				"type":        "string",
@@ -97,7 +190,7 @@ func (t *FetchTool) Schema() map[string]interface{} {
 			},
 			"format": map[string]interface{}{
 				"type":        "string",
-				"description": "Expected response format: text, json, html, xml",
+				"description": "Response format: 'json' adds the parsed value as data, 'xml' adds the parsed element tree as data, 'html' converts the page to readable Markdown without navigation and other page chrome and lists its links, 'text' returns plain text, converting HTML pages. The raw body is returned when omitted",
 				"enum":        []string{"text", "json", "html", "xml"},
 			},
 			"timeout": map[string]interface{}{
@@ -112,8 +205,40 @@ func (t *FetchTool) Schema() map[string]interface{} {
 				"minimum":     0,
 				"maximum":     5,
 			},
+			"auth": map[string]interface{}{
+				"type":        "object",
+				"description": "Authentication: 'basic' uses user and pass, 'bearer' uses token, 'apikey' uses apikey and header (default: X-API-Key)",
+				"properties": map[string]interface{}{
+					"type": map[string]interface{}{
+						"type": "string",
+						"enum": []string{"basic", "bearer", "apikey"},
+					},
+					"user":   map[string]interface{}{"type": "string"},
+					"pass":   map[string]interface{}{"type": "string"},
+					"token":  map[string]interface{}{"type": "string"},
+					"apikey": map[string]interface{}{"type": "string"},
+					"header": map[string]interface{}{"type": "string"},
+				},
+				"required":             []string{"type"},
+				"additionalProperties": false,
+			},
+			"max_bytes": map[string]interface{}{
+				"type":        "integer",
+				"description": "Longest response body to read, in bytes; longer bodies are cut and the content ends with a truncation marker (default: 5242880, unlimited with save_path)",
+				"minimum":     1,
+			},
+			"binary": map[string]interface{}{
+				"type":        "string",
+				"description": "How to return binary responses such as images and archives, and text in unsupported charsets: 'refuse' returns an error suggesting the download tool, 'base64' returns the body base64-encoded (default: refuse)",
+				"enum":        []string{"refuse", "base64"},
+			},
+			"save_path": map[string]interface{}{
+				"type":        "string",
+				"description": "File to stream the response body to; the result then holds the headers and a preview of the body instead of all of it",
+			},
 		},
-		"required": []string{"url"},
+		"required":             []string{"url"},
+		"additionalProperties": false,
 	}
 }
 
@@ -147,6 +272,14 @@ func (t *FetchTool) Validate(params json.RawMessage) error {
 		return fmt.Errorf("max_retries must be between 0 and 5")
 	}
 
+	if p.MaxBytes < 0 {
+		return fmt.Errorf("max_bytes must be positive")
+	}
+
+	if p.Binary != "" && p.Binary != "refuse" && p.Binary != "base64" {
+		return fmt.Errorf("invalid binary mode: %s", p.Binary)
+	}
+
 	if p.Auth != nil {
 		if err := t.validateAuth(p.Auth); err != nil {
 			return err
@@ -198,9 +331,27 @@ func (t *FetchTool) Execute(ctx context.Context, params json.RawMessage) (interf
 	if p.Timeout == 0 {
 		p.Timeout = 30
 	}
+	if p.MaxBytes == 0 && p.SavePath == "" {
+		p.MaxBytes = defaultFetchMaxBytes
+	}
 
-	// Update client timeout
-	t.client.Timeout = time.Duration(p.Timeout) * time.Second
+	target, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if err := t.policy.CheckURL(target); err != nil {
+		return nil, err
+	}
+
+	// Ensure the output directory exists
+	if p.SavePath != "" {
+		if err := t.workspace.Check(p.SavePath); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(p.SavePath), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
 
 	// Execute with retries
 	maxAttempts := p.MaxRetries + 1
@@ -209,33 +360,49 @@ func (t *FetchTool) Execute(ctx context.Context, params json.RawMessage) (interf
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		result, err := t.executeRequest(ctx, p)
 		if err == nil {
+			if err := finishResult(result, p); err != nil {
+				return result, err
+			}
 			return result, nil
 		}
 
 		lastErr = err
 
-		// Don't retry on client errors (4xx) or context errors
-		if result != nil && result.StatusCode >= 400 && result.StatusCode < 500 {
+		// Don't retry on client errors (4xx) other than 429 Too Many
+		// Requests, context errors or blocked URLs
+		if result != nil && result.StatusCode >= 400 && result.StatusCode < 500 && result.StatusCode != http.StatusTooManyRequests {
 			return result, err
 		}
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
+		if errors.Is(err, ErrBlockedByPolicy) {
+			return nil, err
+		}
 
-		// Wait before retry (exponential backoff)
+		// Wait before retry, as long as the server asks or with jittered
+		// exponential backoff
 		if attempt < maxAttempts-1 {
-			backoff := time.Duration(1<<uint(attempt)) * time.Second
-			time.Sleep(backoff)
+			var retryAfter string
+			if result != nil {
+				retryAfter = result.Headers["Retry-After"]
+			}
+			if err := sleepContext(ctx, retryDelay(attempt, retryAfter)); err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	return nil, fmt.Errorf("failed after %d attempts: %w", maxAttempts, lastErr)
 }
 
-// executeRequest performs a single HTTP request
+// executeRequest performs a single HTTP request, limited to p.Timeout
 func (t *FetchTool) executeRequest(ctx context.Context, p FetchParams) (*FetchResult, error) {
 	startTime := time.Now()
 
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(p.Timeout)*time.Second)
+	defer cancel()
+
 	// Create request
 	var bodyReader io.Reader
 	if p.Body != "" {
@@ -257,49 +424,199 @@ func (t *FetchTool) executeRequest(ctx context.Context, p FetchParams) (*FetchRe
 		t.setAuthentication(req, p.Auth)
 	}
 
+	// Answer from the cache when it holds a fresh response, and make the
+	// request conditional when the stored response is stale
+	useCache := t.cache != nil && p.Auth == nil && p.SavePath == "" && cacheableRequest(req)
+	var cached *CachedResponse
+	if useCache {
+		cached = t.cache.Get(req)
+		if cached != nil && cached.Fresh(t.cache.now()) && !revalidationRequested(req) {
+			return cachedResult(cached, p, startTime), nil
+		}
+		if cached != nil && !cached.addValidators(req) {
+			cached = nil
+		}
+	}
+
 	// Execute request
+	requestTime := time.Now()
 	resp, err := t.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		return cachedResult(t.cache.Refresh(req, cached, resp, requestTime), p, startTime), nil
+	}
+
+	if t.cache != nil && !isSafeMethod(req.Method) && resp.StatusCode < 400 {
+		t.cache.Invalidate(req.URL.String())
+	}
+
+	if p.SavePath != "" && resp.StatusCode < 400 {
+		return saveBody(resp, p, startTime)
+	}
+
+	// Read response body, up to p.MaxBytes
+	body, truncated, err := readBody(resp.Body, p.MaxBytes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	duration := time.Since(startTime)
+	// Cut bodies are not stored, so a later call with a higher limit reads
+	// the whole body
+	if useCache && !truncated {
+		t.cache.Put(req, resp, body, requestTime)
+	}
+
+	result := newFetchResult(resp.StatusCode, resp.Header, body, resp.Request.URL.String(), p.URL)
+	result.Truncated = truncated
+	result.Duration = time.Since(startTime).Milliseconds()
+
+	// Check for errors based on status code
+	if resp.StatusCode >= 400 {
+		return result, &statusError{StatusCode: resp.StatusCode, RetryAfter: resp.Header.Get("Retry-After")}
+	}
+
+	return result, nil
+}
+
+// readBody reads up to limit bytes of r, or all of it when limit is 0, and
+// reports whether there was more
+func readBody(r io.Reader, limit int64) ([]byte, bool, error) {
+	if limit <= 0 {
+		body, err := io.ReadAll(r)
+		return body, false, err
+	}
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(body)) > limit {
+		return body[:limit], true, nil
+	}
+	return body, false, nil
+}
+
+// saveBody streams a response body to p.SavePath, up to p.MaxBytes when it
+// is set, and keeps the start of the body as a preview. The body is staged
+// in a temporary file, so an interrupted transfer leaves any existing file
+// untouched.
+func saveBody(resp *http.Response, p FetchParams, startTime time.Time) (*FetchResult, error) {
+	tmpFile := p.SavePath + ".tmp"
+	file, err := os.Create(tmpFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+
+	var src io.Reader = resp.Body
+	if p.MaxBytes > 0 {
+		src = io.LimitReader(resp.Body, p.MaxBytes)
+	}
+	preview := &prefixWriter{limit: fetchPreviewBytes}
+	written, err := io.Copy(io.MultiWriter(file, preview), src)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile)
+		return nil, fmt.Errorf("failed to save response: %w", err)
+	}
+
+	result := newFetchResult(resp.StatusCode, resp.Header, preview.buf, resp.Request.URL.String(), p.URL)
+	result.Size = written
+	result.SavedTo = p.SavePath
+	if p.MaxBytes > 0 && written == p.MaxBytes {
+		var next [1]byte
+		n, _ := io.ReadFull(resp.Body, next[:])
+		result.Truncated = n > 0
+	}
 
-	// Build result
+	if err := commitFile(tmpFile, p.SavePath); err != nil {
+		return nil, err
+	}
+	result.Duration = time.Since(startTime).Milliseconds()
+	return result, nil
+}
+
+// prefixWriter keeps the first limit bytes written to it and discards the
+// rest
+type prefixWriter struct {
+	buf   []byte
+	limit int
+}
+
+// Write keeps what fits of data
+func (w *prefixWriter) Write(data []byte) (int, error) {
+	if room := w.limit - len(w.buf); room > 0 {
+		w.buf = append(w.buf, data[:min(room, len(data))]...)
+	}
+	return len(data), nil
+}
+
+// newFetchResult builds the result for a response; finalURL is where the
+// response came from after redirects
+func newFetchResult(status int, header http.Header, body []byte, finalURL, requestURL string) *FetchResult {
 	result := &FetchResult{
-		StatusCode:  resp.StatusCode,
+		StatusCode:  status,
 		Headers:     make(map[string]string),
 		Content:     string(body),
-		ContentType: resp.Header.Get("Content-Type"),
+		ContentType: header.Get("Content-Type"),
 		Size:        int64(len(body)),
-		Duration:    duration.Milliseconds(),
+		body:        body,
 	}
 
 	// Copy headers
-	for key, values := range resp.Header {
+	for key, values := range header {
 		if len(values) > 0 {
 			result.Headers[key] = values[0]
 		}
 	}
 
 	// Check for redirects
-	if resp.Request.URL.String() != p.URL {
-		result.RedirectURL = resp.Request.URL.String()
+	if finalURL != requestURL {
+		result.RedirectURL = finalURL
 	}
 
-	// Check for errors based on status code
-	if resp.StatusCode >= 400 {
-		return result, fmt.Errorf("HTTP %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	return result
+}
+
+// cachedResult builds the result for a response from the cache
+func cachedResult(entry *CachedResponse, p FetchParams, startTime time.Time) *FetchResult {
+	result := newFetchResult(entry.StatusCode, entry.Header, entry.Body, entry.URL, p.URL)
+	result.Cached = true
+	result.Duration = time.Since(startTime).Milliseconds()
+	return result
+}
+
+// cacheableRequest reports whether a request may be answered from the
+// cache. Authenticated and conditional requests, and requests that forbid
+// storing, always go to the server.
+func cacheableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
 	}
+	for _, name := range []string{"Authorization", "If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "Range"} {
+		if req.Header.Get(name) != "" {
+			return false
+		}
+	}
+	_, noStore := parseCacheControl(req.Header.Get("Cache-Control"))["no-store"]
+	return !noStore
+}
 
-	return result, nil
+// revalidationRequested reports whether a request asks for stored responses
+// to be revalidated even when they are fresh
+func revalidationRequested(req *http.Request) bool {
+	directives := parseCacheControl(req.Header.Get("Cache-Control"))
+	_, noCache := directives["no-cache"]
+	return noCache || directives["max-age"] == "0" || strings.Contains(strings.ToLower(req.Header.Get("Pragma")), "no-cache")
+}
+
+// isSafeMethod reports whether a method leaves resources unchanged
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
 }
 
 // setAuthentication sets authentication headers on the request
@@ -317,3 +634,214 @@ func (t *FetchTool) setAuthentication(req *http.Request, auth *AuthConfig) {
 		req.Header.Set(headerName, auth.APIKey)
 	}
 }
+
+// finishResult turns the body of a successful response into its content.
+// Binary bodies are refused, or encoded when p.Binary is "base64"; text is
+// decoded from its charset and converted to p.Format. Bodies saved to a file
+// only get a preview, and bodies cut at p.MaxBytes end with a marker.
+func finishResult(result *FetchResult, p FetchParams) error {
+	body := result.body
+	if result.SavedTo == "" && p.MaxBytes > 0 && int64(len(body)) > p.MaxBytes {
+		// Cached bodies are stored whole
+		body = body[:p.MaxBytes]
+		result.Size = p.MaxBytes
+		result.Truncated = true
+	}
+
+	if isBinaryContent(result.ContentType, body) {
+		contentType := result.ContentType
+		if contentType == "" {
+			contentType = "no content type"
+		}
+		return rawBody(result, body, p, fmt.Errorf("response is binary (%s)", contentType))
+	}
+
+	// Saved bodies are previewed from their start, so they may be cut too
+	content, charset, err := decodeCharset(body, result.ContentType, result.Truncated || result.SavedTo != "")
+	if charset != "utf-8" {
+		result.Charset = charset
+	}
+	if err != nil {
+		return rawBody(result, body, p, fmt.Errorf("response is in an %w", err))
+	}
+	result.Content = content
+	if result.SavedTo != "" {
+		return nil
+	}
+
+	if err := formatResult(result, p); err != nil {
+		if result.Truncated {
+			return fmt.Errorf("%w (the response was cut at %d bytes; raise max_bytes to read all of it)", err, result.Size)
+		}
+		return err
+	}
+	if result.Truncated {
+		result.Content += truncationMarker(result.Size, result.Headers["Content-Length"])
+	}
+	return nil
+}
+
+// rawBody handles a body that cannot be returned as text: saved bodies get
+// no preview, and other bodies are base64-encoded when p.Binary is "base64"
+// and refused with reason otherwise
+func rawBody(result *FetchResult, body []byte, p FetchParams, reason error) error {
+	result.Content = ""
+	switch {
+	case result.SavedTo != "":
+		return nil
+	case p.Binary == "base64":
+		result.Content = base64.StdEncoding.EncodeToString(body)
+		result.Encoding = "base64"
+		return nil
+	}
+	return fmt.Errorf("%w; use the download tool to save it to a file, or set binary to \"base64\"", reason)
+}
+
+// isBinaryContent reports whether a body is binary, by its content type or,
+// for unknown and generic types such as application/octet-stream, by
+// sniffing its start
+func isBinaryContent(contentType string, body []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mediaType, "text/"), textMediaTypes[mediaType],
+		strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return false
+	case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"), strings.HasPrefix(mediaType, "font/"):
+		return true
+	}
+
+	sniff := body[:min(len(body), 8000)]
+	detected := http.DetectContentType(sniff)
+	if strings.HasPrefix(detected, "text/plain; charset=utf-16") {
+		return false
+	}
+	return looksBinary(sniff) || !strings.HasPrefix(detected, "text/")
+}
+
+// truncationMarker notes that content was cut after n bytes, with the full
+// size when the response declared it
+func truncationMarker(n int64, contentLength string) string {
+	if total, err := strconv.ParseInt(contentLength, 10, 64); err == nil && total > n {
+		return fmt.Sprintf("\n\n[truncated: showing the first %d of %d bytes; raise max_bytes or use save_path to get the rest]", n, total)
+	}
+	return fmt.Sprintf("\n\n[truncated: showing the first %d bytes; raise max_bytes or use save_path to get the rest]", n)
+}
+
+// formatResult converts the body of a successful response to the requested
+// format
+func formatResult(result *FetchResult, p FetchParams) error {
+	switch p.Format {
+	case "json":
+		var data interface{}
+		if err := json.Unmarshal([]byte(result.Content), &data); err != nil {
+			return fmt.Errorf("response is not valid JSON: %w", err)
+		}
+		result.Data = data
+
+	case "xml":
+		root, err := parseXMLTree(result.Content)
+		if err != nil {
+			return fmt.Errorf("response is not valid XML: %w", err)
+		}
+		result.Data = root
+
+	case "html", "text":
+		if p.Format == "text" && !isHTMLContent(result) {
+			return nil
+		}
+		base, err := url.Parse(p.URL)
+		if result.RedirectURL != "" {
+			base, err = url.Parse(result.RedirectURL)
+		}
+		if err != nil {
+			base = nil
+		}
+		doc := extractHTML(result.Content, base, p.Format == "text")
+		result.Content = doc.Text
+		result.Title = doc.Title
+		if p.Format == "html" {
+			result.Links = doc.Links
+		}
+	}
+	return nil
+}
+
+// isHTMLContent reports whether a response is an HTML page, by its content
+// type or, failing that, how the body starts
+func isHTMLContent(result *FetchResult) bool {
+	contentType := strings.ToLower(result.ContentType)
+	if strings.Contains(contentType, "html") {
+		return true
+	}
+	if contentType != "" && !strings.HasPrefix(contentType, "text/plain") && !strings.HasPrefix(contentType, "application/octet-stream") {
+		return false
+	}
+	start := strings.ToLower(strings.TrimSpace(result.Content))
+	if len(start) > 512 {
+		start = start[:512]
+	}
+	return strings.HasPrefix(start, "<!doctype html") || strings.HasPrefix(start, "<html")
+}
+
+// parseXMLTree parses an XML document into a tree of elements
+func parseXMLTree(content string) (*XMLNode, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	// The content is already UTF-8, whatever its declaration says
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var root *XMLNode
+	var stack []*XMLNode
+	var text []string
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			node := &XMLNode{Name: token.Name.Local, Namespace: token.Name.Space}
+			for _, attr := range token.Attr {
+				if node.Attributes == nil {
+					node.Attributes = make(map[string]string)
+				}
+				name := attr.Name.Local
+				if attr.Name.Space != "" {
+					name = attr.Name.Space + ":" + name
+				}
+				node.Attributes[name] = attr.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			} else if root != nil {
+				return nil, fmt.Errorf("multiple root elements")
+			} else {
+				root = node
+			}
+			stack = append(stack, node)
+			text = append(text, "")
+
+		case xml.EndElement:
+			last := len(stack) - 1
+			stack[last].Text = strings.TrimSpace(text[last])
+			stack, text = stack[:last], text[:last]
+
+		case xml.CharData:
+			if len(text) > 0 {
+				text[len(text)-1] += string(token)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
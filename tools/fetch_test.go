@@ -2,10 +2,14 @@ package tools
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -85,6 +89,21 @@ func TestFetchTool_Validate(t *testing.T) {
 			params:  `{"url": "https://example.com", "auth": {"type": "bearer"}}`,
 			wantErr: true,
 		},
+		{
+			name:    "negative max_bytes",
+			params:  `{"url": "https://example.com", "max_bytes": -1}`,
+			wantErr: true,
+		},
+		{
+			name:    "valid binary mode",
+			params:  `{"url": "https://example.com", "binary": "base64"}`,
+			wantErr: false,
+		},
+		{
+			name:    "invalid binary mode",
+			params:  `{"url": "https://example.com", "binary": "hex"}`,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -109,7 +128,7 @@ func TestFetchTool_Execute_GET(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -156,7 +175,7 @@ func TestFetchTool_Execute_POST(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -192,7 +211,7 @@ func TestFetchTool_Execute_WithHeaders(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -232,7 +251,7 @@ func TestFetchTool_Execute_BasicAuth(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -274,7 +293,7 @@ func TestFetchTool_Execute_BearerAuth(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -311,7 +330,7 @@ func TestFetchTool_Execute_APIKeyAuth(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -342,7 +361,7 @@ func TestFetchTool_Execute_404(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -369,7 +388,7 @@ func TestFetchTool_Execute_Timeout(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -393,7 +412,7 @@ func TestFetchTool_Execute_ResponseHeaders(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -426,7 +445,7 @@ func TestFetchTool_Execute_JSON(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -451,6 +470,122 @@ func TestFetchTool_Execute_JSON(t *testing.T) {
 	if jsonData["message"] != "success" {
 		t.Errorf("Expected message 'success', got '%v'", jsonData["message"])
 	}
+
+	data, ok := fetchResult.Data.(map[string]interface{})
+	if !ok || data["code"] != float64(200) {
+		t.Errorf("Expected the parsed value as data, got %#v", fetchResult.Data)
+	}
+}
+
+func TestFetchTool_Execute_InvalidJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"message": `))
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+	params, _ := json.Marshal(FetchParams{URL: server.URL, Format: "json", MaxRetries: 2})
+	result, err := tool.Execute(context.Background(), params)
+	if err == nil || !strings.Contains(err.Error(), "not valid JSON") {
+		t.Fatalf("Expected a not valid JSON error, got %v", err)
+	}
+	if result == nil || result.(*FetchResult).Content != `{"message": ` {
+		t.Errorf("Expected the raw body to be returned with the error, got %+v", result)
+	}
+}
+
+func TestFetchTool_Execute_XML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>News</title>
+  <entry id="1"><title>First</title></entry>
+  <entry id="2"><title>Second</title></entry>
+</feed>`))
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+	params, _ := json.Marshal(FetchParams{URL: server.URL, Format: "xml"})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	root, ok := result.(*FetchResult).Data.(*XMLNode)
+	if !ok {
+		t.Fatalf("Expected an XML tree as data, got %#v", result.(*FetchResult).Data)
+	}
+	if root.Name != "feed" || root.Namespace != "http://www.w3.org/2005/Atom" || len(root.Children) != 3 {
+		t.Fatalf("Expected a feed with 3 children, got %+v", root)
+	}
+	entry := root.Children[2]
+	if entry.Attributes["id"] != "2" || entry.Children[0].Text != "Second" {
+		t.Errorf("Expected the second entry, got %+v", entry)
+	}
+}
+
+func TestFetchTool_Execute_HTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>Guide</title></head><body>
+<nav><a href="/">Home</a></nav>
+<main><h1>Install</h1><p>See the <a href="/docs/setup">setup notes</a>.</p></main>
+<footer>Copyright</footer></body></html>`))
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+	params, _ := json.Marshal(FetchParams{URL: server.URL + "/guide", Format: "html"})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	fetchResult := result.(*FetchResult)
+	want := "# Install\n\nSee the [setup notes](" + server.URL + "/docs/setup)."
+	if fetchResult.Content != want {
+		t.Errorf("Expected content %q, got %q", want, fetchResult.Content)
+	}
+	if fetchResult.Title != "Guide" {
+		t.Errorf("Expected title 'Guide', got %q", fetchResult.Title)
+	}
+	if len(fetchResult.Links) != 1 || fetchResult.Links[0].URL != server.URL+"/docs/setup" {
+		t.Errorf("Expected the setup link, got %+v", fetchResult.Links)
+	}
+}
+
+func TestFetchTool_Execute_Text(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/page" {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<body><h1>Title</h1><p>Some <b>bold</b> text</p></body>`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("  <b>kept</b>  "))
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+	params, _ := json.Marshal(FetchParams{URL: server.URL + "/page", Format: "text"})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := result.(*FetchResult).Content; got != "Title\n\nSome bold text" {
+		t.Errorf("Expected plain text, got %q", got)
+	}
+
+	params, _ = json.Marshal(FetchParams{URL: server.URL + "/plain", Format: "text"})
+	result, err = tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if got := result.(*FetchResult).Content; got != "  <b>kept</b>  " {
+		t.Errorf("Expected a plain text body to be kept, got %q", got)
+	}
 }
 
 func TestFetchTool_Execute_Duration(t *testing.T) {
@@ -461,7 +596,7 @@ func TestFetchTool_Execute_Duration(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -497,7 +632,7 @@ func TestFetchTool_Execute_Redirect(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -529,7 +664,7 @@ func TestFetchTool_Execute_Size(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewFetchTool()
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
 	ctx := context.Background()
 
 	params := map[string]interface{}{
@@ -549,3 +684,218 @@ func TestFetchTool_Execute_Size(t *testing.T) {
 		t.Errorf("Expected size %d, got %d", expectedSize, fetchResult.Size)
 	}
 }
+
+func TestFetchTool_Execute_MaxBytes(t *testing.T) {
+	content := strings.Repeat("0123456789", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	cache, _ := newTestCache(t)
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy()).WithCache(cache)
+
+	result := fetch(t, tool, FetchParams{URL: server.URL, MaxBytes: 100})
+	if !result.Truncated || result.Size != 100 {
+		t.Errorf("Expected the body to be cut at 100 bytes, got %d bytes, truncated %v", result.Size, result.Truncated)
+	}
+	if !strings.HasPrefix(result.Content, content[:100]) || !strings.Contains(result.Content, "[truncated: showing the first 100 of 1000 bytes") {
+		t.Errorf("Expected the first 100 bytes and a truncation marker, got %q", result.Content)
+	}
+
+	// A cut body is not cached, so the whole body can still be read
+	result = fetch(t, tool, FetchParams{URL: server.URL})
+	if result.Cached || result.Truncated || result.Content != content {
+		t.Errorf("Expected the whole body from the server, got %d bytes, cached %v", result.Size, result.Cached)
+	}
+
+	// The limit also applies to a cached body
+	result = fetch(t, tool, FetchParams{URL: server.URL, MaxBytes: 10})
+	if !result.Cached || !result.Truncated || !strings.HasPrefix(result.Content, content[:10]+"\n\n[truncated") {
+		t.Errorf("Expected the cached body cut at 10 bytes, got %+v", result)
+	}
+}
+
+func TestFetchTool_Execute_MaxBytesFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"items": [1, 2, 3, 4, 5, 6, 7, 8, 9]}`))
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+	params, _ := json.Marshal(FetchParams{URL: server.URL, Format: "json", MaxBytes: 16})
+	_, err := tool.Execute(context.Background(), params)
+	if err == nil || !strings.Contains(err.Error(), "raise max_bytes") {
+		t.Errorf("Expected the JSON error to suggest raising max_bytes, got %v", err)
+	}
+}
+
+func TestFetchTool_Execute_Binary(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+		case "/sniffed":
+			w.Header().Set("Content-Type", "application/octet-stream")
+		case "/text":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write([]byte("plain text"))
+			return
+		}
+		_, _ = w.Write(png)
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+
+	for _, path := range []string{"/image", "/sniffed"} {
+		params, _ := json.Marshal(FetchParams{URL: server.URL + path})
+		result, err := tool.Execute(context.Background(), params)
+		if err == nil || !strings.Contains(err.Error(), "download tool") {
+			t.Errorf("%s: Expected binary content to be refused, got %v", path, err)
+		}
+		if result == nil || result.(*FetchResult).Content != "" || result.(*FetchResult).StatusCode != http.StatusOK {
+			t.Errorf("%s: Expected the headers without content, got %+v", path, result)
+		}
+	}
+
+	result := fetch(t, tool, FetchParams{URL: server.URL + "/image", Binary: "base64"})
+	if result.Encoding != "base64" || result.Content != base64.StdEncoding.EncodeToString(png) {
+		t.Errorf("Expected base64 content, got %q with encoding %q", result.Content, result.Encoding)
+	}
+
+	result = fetch(t, tool, FetchParams{URL: server.URL + "/text"})
+	if result.Content != "plain text" || result.Encoding != "" {
+		t.Errorf("Expected octet-stream text to be returned as text, got %q", result.Content)
+	}
+}
+
+func TestFetchTool_Execute_Charset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latin1":
+			w.Header().Set("Content-Type", "text/plain; charset=ISO-8859-1")
+			_, _ = w.Write([]byte("caf\xe9 \x93quoted\x94"))
+		case "/meta":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html><head><meta charset=\"windows-1252\"><title>Caf\xe9</title></head><body><p>na\xefve</p></body></html>"))
+		case "/xml":
+			w.Header().Set("Content-Type", "application/xml")
+			_, _ = w.Write([]byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><name>Jos\xe9</name>"))
+		}
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+
+	result := fetch(t, tool, FetchParams{URL: server.URL + "/latin1"})
+	if result.Content != "café “quoted”" || result.Charset != "windows-1252" {
+		t.Errorf("Expected decoded Latin-1 text, got %q from %q", result.Content, result.Charset)
+	}
+
+	result = fetch(t, tool, FetchParams{URL: server.URL + "/meta", Format: "html"})
+	if result.Title != "Café" || !strings.Contains(result.Content, "naïve") {
+		t.Errorf("Expected the meta charset to be honored, got title %q and content %q", result.Title, result.Content)
+	}
+
+	result = fetch(t, tool, FetchParams{URL: server.URL + "/xml", Format: "xml"})
+	if root, ok := result.Data.(*XMLNode); !ok || root.Text != "José" {
+		t.Errorf("Expected a decoded XML tree, got %+v", result.Data)
+	}
+
+	// Single byte charsets keep their last character when the body is cut
+	result = fetch(t, tool, FetchParams{URL: server.URL + "/latin1", MaxBytes: 4})
+	if !strings.HasPrefix(result.Content, "café") || !result.Truncated {
+		t.Errorf("Expected the cut body to end in é, got %q", result.Content)
+	}
+}
+
+func TestFetchTool_Execute_UnsupportedCharset(t *testing.T) {
+	body := []byte("\x82\xa0\x82\xa2")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=Shift_JIS")
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+
+	params, _ := json.Marshal(FetchParams{URL: server.URL})
+	result, err := tool.Execute(context.Background(), params)
+	if err == nil || !strings.Contains(err.Error(), "unsupported charset shift_jis") {
+		t.Errorf("Expected an error naming the charset, got %v", err)
+	}
+	if result == nil || result.(*FetchResult).Content != "" {
+		t.Errorf("Expected no mangled content, got %+v", result)
+	}
+
+	fetched := fetch(t, tool, FetchParams{URL: server.URL, Binary: "base64"})
+	if fetched.Encoding != "base64" || fetched.Content != base64.StdEncoding.EncodeToString(body) || fetched.Charset != "shift_jis" {
+		t.Errorf("Expected the raw bytes base64-encoded, got %+v", fetched)
+	}
+}
+
+func TestFetchTool_Execute_SavePath(t *testing.T) {
+	content := strings.Repeat("line of text\n", 1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/image" {
+			w.Header().Set("Content-Type", "image/png")
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+	dir := t.TempDir()
+	output := filepath.Join(dir, "nested", "page.txt")
+
+	result := fetch(t, tool, FetchParams{URL: server.URL, SavePath: output})
+	if got := readFile(t, output); got != content {
+		t.Errorf("Expected the whole body in the file, got %d bytes", len(got))
+	}
+	if result.SavedTo != output || result.Size != int64(len(content)) || result.Truncated {
+		t.Errorf("Expected the saved size and path, got %+v", result)
+	}
+	if len(result.Content) != fetchPreviewBytes || !strings.HasPrefix(content, result.Content) {
+		t.Errorf("Expected a %d byte preview, got %d bytes", fetchPreviewBytes, len(result.Content))
+	}
+
+	// Binary bodies are saved without a preview, and max_bytes still applies
+	image := filepath.Join(dir, "image.png")
+	result = fetch(t, tool, FetchParams{URL: server.URL + "/image", SavePath: image, MaxBytes: 100})
+	if info, err := os.Stat(image); err != nil || info.Size() != 100 {
+		t.Errorf("Expected 100 bytes saved, got %v, %v", info, err)
+	}
+	if result.Content != "" || !result.Truncated {
+		t.Errorf("Expected a truncated binary save without content, got %+v", result)
+	}
+}
+
+func TestFetchTool_Execute_SavePathInterrupted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Promise more than is sent, so the transfer fails part way
+		w.Header().Set("Content-Length", "1000")
+		_, _ = w.Write([]byte("partial"))
+	}))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "page.txt")
+	if err := os.WriteFile(output, []byte("original"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+	params, _ := json.Marshal(FetchParams{URL: server.URL, SavePath: output})
+	if _, err := tool.Execute(context.Background(), params); err == nil {
+		t.Fatal("Expected an interrupted transfer to fail")
+	}
+
+	if got := readFile(t, output); got != "original" {
+		t.Errorf("Expected the existing file to be kept, got %q", got)
+	}
+	if _, err := os.Stat(output + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Expected the temporary file to be removed, got %v", err)
+	}
+}
@@ -8,10 +8,13 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // GlobTool implements advanced pattern matching for finding files
-type GlobTool struct{}
+type GlobTool struct {
+	workspace *Workspace
+}
 
 // GlobParams defines the parameters for the Glob tool
 type GlobParams struct {
@@ -19,6 +22,8 @@ type GlobParams struct {
 	Path           string   `json:"path,omitempty"`                  // Base path (defaults to current dir)
 	CaseSensitive  bool     `json:"case_sensitive,omitempty"`        // Case-sensitive matching
 	FollowSymlinks bool     `json:"follow_symlinks,omitempty"`       // Follow symbolic links
+	Hidden         bool     `json:"hidden,omitempty"`                // Include names starting with a dot
+	NoIgnore       bool     `json:"no_ignore,omitempty"`             // Include paths excluded by .gitignore and .ignore
 	SortBy         string   `json:"sort_by,omitempty"`               // "modtime", "name", "size"
 	SortOrder      string   `json:"sort_order,omitempty"`            // "asc", "desc"
 	IncludeInfo    bool     `json:"include_info,omitempty"`          // Include file metadata
@@ -36,6 +41,12 @@ func NewGlobTool() *GlobTool {
 	return &GlobTool{}
 }
 
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *GlobTool) WithWorkspace(ws *Workspace) *GlobTool {
+	t.workspace = ws
+	return t
+}
+
 // Name returns the tool's name
 func (t *GlobTool) Name() string {
 	return "glob"
@@ -43,7 +54,12 @@ func (t *GlobTool) Name() string {
 
 // Description returns the tool's description
 func (t *GlobTool) Description() string {
-	return "Find files using advanced glob pattern matching with support for multiple patterns, sorting, and detailed file information"
+	return "Find files using doublestar glob patterns such as '**/*.{go,md}', skipping hidden files and paths excluded by .gitignore, with sorting and detailed file information"
+}
+
+// Risk returns the tool's risk class
+func (t *GlobTool) Risk() Risk {
+	return RiskReadOnly
 }
 
 // Schema returns the JSON schema for the tool's parameters
@@ -71,6 +87,14 @@ func (t *GlobTool) Schema() map[string]interface{} {
 				"type":        "boolean",
 				"description": "Follow symbolic links during search",
 			},
+			"hidden": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Include files and directories whose names start with a dot",
+			},
+			"no_ignore": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Include files excluded by .gitignore and .ignore files",
+			},
 			"sort_by": map[string]interface{}{
 				"type":        "string",
 				"description": "Sort results by: 'modtime', 'name', or 'size'",
@@ -86,7 +110,8 @@ func (t *GlobTool) Schema() map[string]interface{} {
 				"description": "Include detailed file metadata in results",
 			},
 		},
-		"required": []string{"patterns"},
+		"required":             []string{"patterns"},
+		"additionalProperties": false,
 	}
 }
 
@@ -128,12 +153,19 @@ func (t *GlobTool) Execute(ctx context.Context, params json.RawMessage) (interfa
 		}
 	}
 
+	if err := t.workspace.Check(p.Path); err != nil {
+		return nil, err
+	}
+
 	// Verify path exists
 	if _, err := os.Stat(p.Path); err != nil {
 		return nil, fmt.Errorf("path does not exist: %w", err)
 	}
 
-	// Collect matches from all patterns
+	// Group the patterns by the directory they start from, so each
+	// directory is walked once
+	var bases []string
+	patternsByBase := make(map[string][]string)
 	matchMap := make(map[string]FileInfo) // Use map to avoid duplicates
 
 	for _, pattern := range p.Patterns {
@@ -143,13 +175,47 @@ func (t *GlobTool) Execute(ctx context.Context, params json.RawMessage) (interfa
 			fullPattern = filepath.Join(p.Path, pattern)
 		}
 
-		matches, err := t.globPattern(fullPattern, p.CaseSensitive, p.FollowSymlinks, p.IncludeInfo)
-		if err != nil {
-			return nil, fmt.Errorf("failed to glob pattern '%s': %w", pattern, err)
+		base, rest := splitGlob(fullPattern)
+		if rest == "" {
+			// A plain path matches itself if it exists
+			if info, err := os.Stat(base); err == nil && t.workspace.Allows(base) {
+				matchMap[base] = globMatch(base, info, p.IncludeInfo)
+			}
+			continue
+		}
+		if _, ok := patternsByBase[base]; !ok {
+			bases = append(bases, base)
 		}
+		patternsByBase[base] = append(patternsByBase[base], rest)
+	}
 
-		for _, match := range matches {
-			matchMap[match.Path] = match
+	var mu sync.Mutex
+	for _, base := range bases {
+		opts := WalkOptions{
+			Patterns:       patternsByBase[base],
+			CaseSensitive:  p.CaseSensitive,
+			Hidden:         p.Hidden,
+			NoIgnore:       p.NoIgnore,
+			FollowSymlinks: p.FollowSymlinks,
+			IncludeDirs:    true,
+		}
+		// Patterns may reach outside the base path, so check every match
+		if t.workspace != nil {
+			opts.Filter = t.workspace.Allows
+		}
+
+		err := Walk(ctx, base, opts, func(entry WalkEntry) error {
+			match := globMatch(entry.Path, entry.Info, p.IncludeInfo)
+			mu.Lock()
+			matchMap[entry.Path] = match
+			mu.Unlock()
+			return nil
+		})
+		if os.IsNotExist(err) {
+			continue // Nothing can match below a missing directory
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to glob patterns %s: %w", strings.Join(patternsByBase[base], ", "), err)
 		}
 	}
 
@@ -176,109 +242,16 @@ func (t *GlobTool) Execute(ctx context.Context, params json.RawMessage) (interfa
 	}, nil
 }
 
-// globPattern performs pattern matching using filepath.Glob with ** support
-func (t *GlobTool) globPattern(pattern string, caseSensitive, followSymlinks, includeInfo bool) ([]FileInfo, error) {
-	var matches []FileInfo
-
-	// Check if pattern contains **
-	if strings.Contains(pattern, "**") {
-		// Use custom walking for ** patterns
-		return t.globRecursive(pattern, caseSensitive, followSymlinks, includeInfo)
+// globMatch describes a matching path, with its metadata if includeInfo is set
+func globMatch(path string, info os.FileInfo, includeInfo bool) FileInfo {
+	if includeInfo {
+		return newFileInfo(path, info)
 	}
-
-	// Use standard filepath.Glob for simple patterns
-	paths, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, err
+	return FileInfo{
+		Path:  path,
+		Name:  filepath.Base(path),
+		IsDir: info.IsDir(),
 	}
-
-	for _, path := range paths {
-		info, err := os.Stat(path)
-		if err != nil {
-			continue // Skip files we can't stat
-		}
-
-		fileInfo := FileInfo{
-			Path:  path,
-			Name:  filepath.Base(path),
-			IsDir: info.IsDir(),
-		}
-
-		if includeInfo {
-			fileInfo.Size = info.Size()
-			fileInfo.Permissions = info.Mode().String()
-			fileInfo.ModifiedTime = info.ModTime().Unix()
-		}
-
-		matches = append(matches, fileInfo)
-	}
-
-	return matches, nil
-}
-
-// globRecursive handles ** patterns by walking the directory tree
-func (t *GlobTool) globRecursive(pattern string, caseSensitive, followSymlinks, includeInfo bool) ([]FileInfo, error) {
-	var matches []FileInfo
-
-	// Split pattern on **
-	parts := strings.Split(pattern, "**")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid ** pattern: %s", pattern)
-	}
-
-	basePath := filepath.Clean(parts[0])
-	suffix := strings.TrimPrefix(parts[1], string(filepath.Separator))
-
-	err := filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip errors
-		}
-
-		// Skip symlinks if not following them
-		if !followSymlinks && info.Mode()&os.ModeSymlink != 0 {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		// Match suffix pattern
-		relPath, err := filepath.Rel(basePath, path)
-		if err != nil {
-			return nil
-		}
-
-		matched := false
-		if suffix == "" || suffix == "/" {
-			matched = true
-		} else {
-			matched, _ = filepath.Match(suffix, filepath.Base(path))
-			if !matched {
-				// Try matching the full relative path
-				matched, _ = filepath.Match(suffix, relPath)
-			}
-		}
-
-		if matched {
-			fileInfo := FileInfo{
-				Path:  path,
-				Name:  filepath.Base(path),
-				IsDir: info.IsDir(),
-			}
-
-			if includeInfo {
-				fileInfo.Size = info.Size()
-				fileInfo.Permissions = info.Mode().String()
-				fileInfo.ModifiedTime = info.ModTime().Unix()
-			}
-
-			matches = append(matches, fileInfo)
-		}
-
-		return nil
-	})
-
-	return matches, err
 }
 
 // sortMatches sorts the matches based on the specified criteria
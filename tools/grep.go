@@ -6,13 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"regexp"
-	"strings"
+	"sort"
+	"sync"
 )
 
 // GrepTool implements advanced pattern matching
-type GrepTool struct{}
+type GrepTool struct {
+	workspace *Workspace
+}
 
 // GrepParams defines the parameters for the Grep tool
 type GrepParams struct {
@@ -26,6 +28,8 @@ type GrepParams struct {
 	Count        bool     `json:"count,omitempty"`         // Only show counts
 	MaxMatches   int      `json:"max_matches,omitempty"`
 	OutputFormat string   `json:"output_format,omitempty"` // "text", "json", "csv"
+	Hidden       bool     `json:"hidden,omitempty"`        // Search names starting with a dot
+	NoIgnore     bool     `json:"no_ignore,omitempty"`     // Search paths excluded by .gitignore and .ignore
 }
 
 // GrepResult represents the result of a grep operation
@@ -56,6 +60,12 @@ func NewGrepTool() *GrepTool {
 	return &GrepTool{}
 }
 
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *GrepTool) WithWorkspace(ws *Workspace) *GrepTool {
+	t.workspace = ws
+	return t
+}
+
 // Name returns the tool's name
 func (t *GrepTool) Name() string {
 	return "grep"
@@ -66,6 +76,11 @@ func (t *GrepTool) Description() string {
 	return "Advanced pattern matching with capture groups, statistics, and flexible output formats"
 }
 
+// Risk returns the tool's risk class
+func (t *GrepTool) Risk() Risk {
+	return RiskReadOnly
+}
+
 // Schema returns the JSON schema for the tool's parameters
 func (t *GrepTool) Schema() map[string]interface{} {
 	return map[string]interface{}{
@@ -77,14 +92,22 @@ func (t *GrepTool) Schema() map[string]interface{} {
 			},
 			"files": map[string]interface{}{
 				"type":        "array",
-				"description": "Files or glob patterns to search",
+				"description": "Files, directories or doublestar glob patterns (e.g., 'src/**/*.go') to search",
 				"items": map[string]interface{}{
 					"type": "string",
 				},
 			},
 			"recursive": map[string]interface{}{
 				"type":        "boolean",
-				"description": "Search directories recursively",
+				"description": "Search the files in directories listed in files, at any depth",
+			},
+			"hidden": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Search files and directories whose names start with a dot",
+			},
+			"no_ignore": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Search files excluded by .gitignore and .ignore files",
 			},
 			"ignore_case": map[string]interface{}{
 				"type":        "boolean",
@@ -117,7 +140,8 @@ func (t *GrepTool) Schema() map[string]interface{} {
 				"enum":        []string{"text", "json", "csv"},
 			},
 		},
-		"required": []string{"pattern", "files"},
+		"required":             []string{"pattern", "files"},
+		"additionalProperties": false,
 	}
 }
 
@@ -181,48 +205,94 @@ func (t *GrepTool) Execute(ctx context.Context, params json.RawMessage) (interfa
 		return nil, fmt.Errorf("invalid regex pattern: %w", err)
 	}
 
-	// Expand file patterns
+	// Search files in the order given, expanding patterns and directories
+	// in place
 	var files []string
-	for _, filePattern := range p.Files {
-		if strings.Contains(filePattern, "*") {
-			// It's a glob pattern
-			matches, err := t.expandGlob(filePattern, p.Recursive)
-			if err != nil {
-				continue // Skip invalid patterns
-			}
-			files = append(files, matches...)
-		} else {
-			files = append(files, filePattern)
-		}
-	}
-
-	// Search files
-	var allMatches []GrepMatch
+	matchesByFile := make(map[string][]GrepMatch)
 	stats := &GrepStatistics{
 		PatternCounts: make(map[string]int),
 	}
 
-	filesMatched := make(map[string]bool)
-
-	for _, file := range files {
+	var mu sync.Mutex
+	search := func(file string) {
+		matches, err := t.grepFile(file, re, p)
+		mu.Lock()
+		defer mu.Unlock()
+		if _, seen := matchesByFile[file]; seen {
+			return
+		}
 		stats.FilesSearched++
+		if err != nil {
+			matches = nil // Skip files we can't read
+		}
+		files = append(files, file)
+		matchesByFile[file] = matches
+	}
 
-		matches, err := t.grepFile(file, re, p)
+	for _, filePattern := range p.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		base, rest := splitGlob(filePattern)
+		if rest == "" {
+			if err := t.workspace.Check(filePattern); err != nil {
+				return nil, err
+			}
+			info, err := os.Stat(filePattern)
+			if err != nil || !info.IsDir() {
+				search(filePattern)
+				continue
+			}
+			if !p.Recursive {
+				continue // Directories are only searched with recursive
+			}
+			rest = "**"
+		}
+
+		opts := WalkOptions{
+			Patterns:   []string{rest},
+			SkipBinary: true,
+			Hidden:     p.Hidden,
+			NoIgnore:   p.NoIgnore,
+		}
+		if t.workspace != nil {
+			opts.Filter = t.workspace.Allows
+		}
+		count := len(files)
+		err := Walk(ctx, base, opts, func(entry WalkEntry) error {
+			search(entry.Path)
+			return nil
+		})
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if os.IsNotExist(err) {
+			continue // Patterns below missing directories match nothing
+		}
 		if err != nil {
-			continue // Skip files we can't read
+			return nil, fmt.Errorf("failed to search %s: %w", filePattern, err)
 		}
 
-		if len(matches) > 0 {
-			filesMatched[file] = true
-			allMatches = append(allMatches, matches...)
+		// Walked files arrive in any order, so sort each pattern's files
+		sort.Strings(files[count:])
+	}
 
-			// Update pattern counts
-			for _, match := range matches {
-				if len(match.Captures) > 0 {
-					stats.PatternCounts[match.Captures[0]]++
-				} else {
-					stats.PatternCounts[match.Content]++
-				}
+	var allMatches []GrepMatch
+	for _, file := range files {
+		matches := matchesByFile[file]
+		if len(matches) == 0 {
+			continue
+		}
+		stats.FilesMatched++
+		allMatches = append(allMatches, matches...)
+
+		// Update pattern counts
+		for _, match := range matches {
+			if len(match.Captures) > 0 {
+				stats.PatternCounts[match.Captures[0]]++
+			} else {
+				stats.PatternCounts[match.Content]++
 			}
 		}
 
@@ -237,7 +307,6 @@ func (t *GrepTool) Execute(ctx context.Context, params json.RawMessage) (interfa
 		allMatches = allMatches[:p.MaxMatches]
 	}
 
-	stats.FilesMatched = len(filesMatched)
 	stats.TotalMatches = len(allMatches)
 
 	result := &GrepResult{
@@ -300,64 +369,3 @@ func (t *GrepTool) grepFile(filePath string, re *regexp.Regexp, p GrepParams) ([
 
 	return matches, scanner.Err()
 }
-
-// expandGlob expands a glob pattern to actual files
-func (t *GrepTool) expandGlob(pattern string, recursive bool) ([]string, error) {
-	// Handle ** patterns
-	if strings.Contains(pattern, "**") {
-		return t.expandRecursiveGlob(pattern)
-	}
-
-	// Use standard glob
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, err
-	}
-
-	// Filter out directories
-	var files []string
-	for _, match := range matches {
-		info, err := os.Stat(match)
-		if err != nil {
-			continue
-		}
-		if !info.IsDir() {
-			files = append(files, match)
-		}
-	}
-
-	return files, nil
-}
-
-// expandRecursiveGlob handles ** patterns
-func (t *GrepTool) expandRecursiveGlob(pattern string) ([]string, error) {
-	parts := strings.Split(pattern, "**")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid ** pattern")
-	}
-
-	basePath := filepath.Clean(parts[0])
-	suffix := strings.TrimPrefix(parts[1], string(filepath.Separator))
-
-	var files []string
-
-	err := filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
-			return nil
-		}
-
-		if suffix == "" {
-			files = append(files, path)
-			return nil
-		}
-
-		matched, _ := filepath.Match(suffix, filepath.Base(path))
-		if matched {
-			files = append(files, path)
-		}
-
-		return nil
-	})
-
-	return files, err
-}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -445,6 +446,37 @@ func TestGrepTool_Execute_GlobPattern(t *testing.T) {
 	}
 }
 
+func TestGrepTool_Execute_WalkErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "file.txt")
+	_ = os.WriteFile(file, []byte("test"), 0644)
+
+	tool := NewGrepTool()
+	ctx := context.Background()
+
+	// Patterns below a missing directory match nothing
+	paramsJSON, _ := json.Marshal(map[string]interface{}{
+		"pattern": "test",
+		"files":   []string{filepath.Join(tmpDir, "missing", "*.txt")},
+	})
+	result, err := tool.Execute(ctx, paramsJSON)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.(*GrepResult).Statistics.FilesSearched != 0 {
+		t.Errorf("Expected no files searched, got %d", result.(*GrepResult).Statistics.FilesSearched)
+	}
+
+	// Other walk errors are reported rather than returned as no matches
+	paramsJSON, _ = json.Marshal(map[string]interface{}{
+		"pattern": "test",
+		"files":   []string{filepath.Join(file, "*.txt")},
+	})
+	if _, err := tool.Execute(ctx, paramsJSON); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("Expected a walk error, got %v", err)
+	}
+}
+
 func TestGrepTool_Execute_NoMatches(t *testing.T) {
 	tmpDir := t.TempDir()
 	testFile := filepath.Join(tmpDir, "test.txt")
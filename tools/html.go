@@ -0,0 +1,810 @@
+package tools
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// htmlNode is an element or a run of text in a parsed HTML document
+type htmlNode struct {
+	tag      string // Lower-case element name; empty for text
+	attrs    map[string]string
+	text     string // Decoded text, for text nodes
+	children []*htmlNode
+	parent   *htmlNode
+}
+
+// voidElements never have content or an end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// rawTextElements hold text up to their end tag, without markup
+var rawTextElements = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true, "noscript": true, "xmp": true,
+}
+
+// closesParagraph lists the elements whose start tag ends an open <p>
+var closesParagraph = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "details": true, "div": true,
+	"dl": true, "fieldset": true, "figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true, "main": true, "nav": true,
+	"ol": true, "p": true, "pre": true, "section": true, "table": true, "ul": true,
+}
+
+// impliedEnds maps elements to those they close when opened, and the
+// elements that bound the search, such as a new <li> closing the previous
+// one in the same list
+var impliedEnds = map[string]struct{ closes, within []string }{
+	"li":     {[]string{"li"}, []string{"ul", "ol", "menu"}},
+	"dt":     {[]string{"dt", "dd"}, []string{"dl"}},
+	"dd":     {[]string{"dt", "dd"}, []string{"dl"}},
+	"tr":     {[]string{"tr", "td", "th"}, []string{"table", "thead", "tbody", "tfoot"}},
+	"td":     {[]string{"td", "th"}, []string{"tr", "table"}},
+	"th":     {[]string{"td", "th"}, []string{"tr", "table"}},
+	"thead":  {[]string{"thead", "tbody", "tr", "td", "th"}, []string{"table"}},
+	"tbody":  {[]string{"thead", "tbody", "tr", "td", "th"}, []string{"table"}},
+	"tfoot":  {[]string{"thead", "tbody", "tr", "td", "th"}, []string{"table"}},
+	"option": {[]string{"option"}, []string{"select", "datalist"}},
+}
+
+// parseHTML tokenizes an HTML document and builds its element tree. It is
+// forgiving in the way browsers are: unknown end tags are ignored, open
+// elements are closed by their parent's end tag, and common omitted end
+// tags, such as those of <p> and <li>, are implied.
+func parseHTML(s string) *htmlNode {
+	root := &htmlNode{tag: "#document"}
+	current := root
+
+	for i := 0; i < len(s); {
+		lt := strings.IndexByte(s[i:], '<')
+		if lt < 0 {
+			appendText(current, s[i:])
+			break
+		}
+		if lt > 0 {
+			appendText(current, s[i:i+lt])
+			i += lt
+		}
+
+		rest := s[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				return root
+			}
+			i += 4 + end + 3
+
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			// Doctypes, CDATA sections and processing instructions
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return root
+			}
+			i += end + 1
+
+		case len(rest) > 2 && rest[1] == '/' && isASCIILetter(rest[2]):
+			name, next := readTagName(s, i+2)
+			end := strings.IndexByte(s[next:], '>')
+			if end < 0 {
+				return root
+			}
+			i = next + end + 1
+			for n := current; n != root; n = n.parent {
+				if n.tag == name {
+					current = n.parent
+					break
+				}
+			}
+
+		case len(rest) > 1 && isASCIILetter(rest[1]):
+			node, selfClosing, next := readStartTag(s, i)
+			i = next
+			current = closeImplied(current, node.tag)
+			node.parent = current
+			current.children = append(current.children, node)
+
+			if rawTextElements[node.tag] && !selfClosing {
+				end := indexFold(s[i:], "</"+node.tag)
+				if end < 0 {
+					end = len(s) - i
+				}
+				text := s[i : i+end]
+				if node.tag == "title" || node.tag == "textarea" {
+					text = html.UnescapeString(text)
+				}
+				node.children = append(node.children, &htmlNode{text: text, parent: node})
+				i += end
+				if close := strings.IndexByte(s[i:], '>'); close >= 0 {
+					i += close + 1
+				}
+				continue
+			}
+			if !voidElements[node.tag] && !selfClosing {
+				current = node
+			}
+
+		default:
+			appendText(current, "<")
+			i++
+		}
+	}
+
+	return root
+}
+
+// closeImplied closes the elements that opening tag ends implicitly and
+// returns the new current element
+func closeImplied(current *htmlNode, tag string) *htmlNode {
+	if closesParagraph[tag] && current.tag == "p" {
+		current = current.parent
+	}
+
+	rule, ok := impliedEnds[tag]
+	if !ok {
+		return current
+	}
+	var closed *htmlNode
+	for n := current; n != nil && n.tag != "#document"; n = n.parent {
+		if containsString(rule.within, n.tag) {
+			break
+		}
+		if containsString(rule.closes, n.tag) {
+			closed = n
+		}
+	}
+	if closed != nil {
+		return closed.parent
+	}
+	return current
+}
+
+// readStartTag reads the start tag at s[i], which is '<', returning the
+// element, whether it ends with "/>", and the index after the tag
+func readStartTag(s string, i int) (*htmlNode, bool, int) {
+	name, i := readTagName(s, i+1)
+	node := &htmlNode{tag: name, attrs: map[string]string{}}
+
+	for i < len(s) {
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		switch {
+		case s[i] == '>':
+			return node, false, i + 1
+		case strings.HasPrefix(s[i:], "/>"):
+			return node, true, i + 2
+		case s[i] == '/':
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && !strings.HasPrefix(s[i:], "/>") {
+			i++
+		}
+		key := strings.ToLower(s[start:i])
+		if key == "" {
+			i++ // A stray '=' or similar
+			continue
+		}
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+
+		value := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isHTMLSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				quote := s[i]
+				end := strings.IndexByte(s[i+1:], quote)
+				if end < 0 {
+					end = len(s) - i - 1
+				}
+				value = s[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				start := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				value = s[start:i]
+			}
+		}
+		if _, seen := node.attrs[key]; !seen {
+			node.attrs[key] = html.UnescapeString(value)
+		}
+	}
+	return node, false, len(s)
+}
+
+// readTagName reads a tag name starting at s[i], returning it in lower case
+// and the index after it
+func readTagName(s string, i int) (string, int) {
+	start := i
+	for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	return strings.ToLower(s[start:i]), i
+}
+
+// appendText adds decoded text to n, merging it with a preceding text node
+func appendText(n *htmlNode, raw string) {
+	text := html.UnescapeString(raw)
+	if last := len(n.children) - 1; last >= 0 && n.children[last].tag == "" {
+		n.children[last].text += text
+		return
+	}
+	n.children = append(n.children, &htmlNode{text: text, parent: n})
+}
+
+// find returns the first element below n, in document order, for which
+// match is true
+func (n *htmlNode) find(match func(*htmlNode) bool) *htmlNode {
+	for _, child := range n.children {
+		if child.tag == "" {
+			continue
+		}
+		if match(child) {
+			return child
+		}
+		if found := child.find(match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findTag returns the first element below n with the given tag
+func (n *htmlNode) findTag(tag string) *htmlNode {
+	return n.find(func(c *htmlNode) bool { return c.tag == tag })
+}
+
+// textContent returns the text of n and everything below it, as written
+func (n *htmlNode) textContent() string {
+	if n.tag == "" {
+		return n.text
+	}
+	var b strings.Builder
+	for _, child := range n.children {
+		b.WriteString(child.textContent())
+	}
+	return b.String()
+}
+
+// FetchLink is a link found in a fetched HTML page
+type FetchLink struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// htmlDocument is the readable content extracted from an HTML page
+type htmlDocument struct {
+	Title string
+	Text  string // Markdown, or plain text
+	Links []FetchLink
+}
+
+// extractHTML converts an HTML page to readable Markdown, or to plain text
+// if plain is set. Scripts, styles, navigation, forms and other page chrome
+// are dropped, and when the page marks its main content with <main> or
+// <article>, only that is kept. Links are resolved against base, or the
+// page's <base> element, and collected in the order they appear.
+func extractHTML(body string, base *url.URL, plain bool) htmlDocument {
+	doc := parseHTML(body)
+
+	var result htmlDocument
+	if title := doc.findTag("title"); title != nil {
+		result.Title = collapseSpace(title.textContent())
+	}
+	if tag := doc.findTag("base"); tag != nil && base != nil {
+		if href, err := base.Parse(tag.attrs["href"]); err == nil {
+			base = href
+		}
+	}
+
+	content := doc.find(func(n *htmlNode) bool { return n.tag == "main" || n.attrs["role"] == "main" })
+	if content == nil {
+		content = doc.findTag("article")
+	}
+	chrome := content == nil
+	if content == nil {
+		if content = doc.findTag("body"); content == nil {
+			content = doc
+		}
+	}
+
+	r := &markdownRenderer{plain: plain, base: base, dropChrome: chrome, seen: map[string]bool{}}
+	r.blocks(content)
+	r.flushLine()
+
+	result.Text = strings.TrimSpace(r.out.String())
+	result.Links = r.links
+	return result
+}
+
+// skippedElements never hold readable content
+var skippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true, "template": true, "svg": true,
+	"canvas": true, "iframe": true, "object": true, "embed": true, "nav": true, "aside": true,
+	"form": true, "button": true, "input": true, "select": true, "textarea": true, "dialog": true,
+	"title": true, "meta": true, "link": true,
+}
+
+// chromeElements are page headers and footers, dropped unless they are
+// inside the main content
+var chromeElements = map[string]bool{"header": true, "footer": true}
+
+// skippedRoles are ARIA roles of page chrome
+var skippedRoles = map[string]bool{
+	"navigation": true, "banner": true, "contentinfo": true, "complementary": true,
+	"search": true, "dialog": true, "alert": true,
+}
+
+// boilerplateWords in a class or id mark page chrome such as sidebars and
+// cookie banners
+var boilerplateWords = map[string]bool{
+	"sidebar": true, "cookie": true, "cookies": true, "advert": true, "advertisement": true,
+	"ads": true, "promo": true, "social": true, "share": true, "sharing": true, "newsletter": true,
+	"breadcrumb": true, "breadcrumbs": true, "popup": true, "modal": true, "navbar": true,
+}
+
+// blockElements start on a new line
+var blockElements = map[string]bool{
+	"address": true, "article": true, "blockquote": true, "body": true, "caption": true,
+	"center": true, "dd": true, "details": true, "div": true, "dl": true, "dt": true,
+	"fieldset": true, "figcaption": true, "figure": true, "footer": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true, "html": true,
+	"li": true, "main": true, "menu": true, "ol": true, "p": true, "pre": true, "section": true,
+	"summary": true, "table": true, "ul": true,
+}
+
+// markdownRenderer writes an HTML tree as Markdown, a line at a time
+type markdownRenderer struct {
+	plain      bool     // Write plain text instead of Markdown
+	base       *url.URL // Resolves relative links
+	dropChrome bool     // Drop headers and footers
+
+	out      strings.Builder
+	line     strings.Builder // The line being written
+	blank    bool            // A blank line is due before the next line
+	prefixes []linePrefix
+	lists    int
+
+	links []FetchLink
+	seen  map[string]bool
+}
+
+// linePrefix starts the lines of a list item or block quote
+type linePrefix struct {
+	first string // Used on the first line, such as a list marker
+	rest  string // Used on the following lines
+	used  bool
+}
+
+// skip reports whether n is page chrome or hidden
+func (r *markdownRenderer) skip(n *htmlNode) bool {
+	if skippedElements[n.tag] || (r.dropChrome && chromeElements[n.tag]) {
+		return true
+	}
+	if _, hidden := n.attrs["hidden"]; hidden || n.attrs["aria-hidden"] == "true" {
+		return true
+	}
+	if skippedRoles[n.attrs["role"]] {
+		return true
+	}
+	if strings.Contains(strings.ReplaceAll(n.attrs["style"], " ", ""), "display:none") {
+		return true
+	}
+	for _, attr := range []string{n.attrs["class"], n.attrs["id"]} {
+		for _, word := range strings.FieldsFunc(strings.ToLower(attr), func(c rune) bool {
+			return c == ' ' || c == '-' || c == '_'
+		}) {
+			if boilerplateWords[word] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// blocks renders the children of n, grouping runs of inline content into
+// paragraphs
+func (r *markdownRenderer) blocks(n *htmlNode) {
+	var inline strings.Builder
+	flush := func() {
+		r.write(inline.String())
+		inline.Reset()
+	}
+
+	for _, child := range n.children {
+		if child.tag != "" && r.skip(child) {
+			continue
+		}
+		if child.tag != "" && blockElements[child.tag] {
+			flush()
+			r.block(child)
+			continue
+		}
+		inline.WriteString(r.inline(child))
+	}
+	flush()
+}
+
+// block renders a block element
+func (r *markdownRenderer) block(n *htmlNode) {
+	switch n.tag {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.blockBreak()
+		text := strings.TrimSpace(r.inlineChildren(n))
+		if !r.plain && text != "" {
+			level, _ := strconv.Atoi(n.tag[1:])
+			text = strings.Repeat("#", level) + " " + text
+		}
+		r.write(text)
+		r.blockBreak()
+
+	case "ul", "ol", "menu":
+		if r.lists > 0 {
+			r.flushLine()
+		} else {
+			r.blockBreak()
+		}
+		r.lists++
+		number := 1
+		if start, err := strconv.Atoi(n.attrs["start"]); err == nil {
+			number = start
+		}
+		for _, child := range n.children {
+			if child.tag == "" || r.skip(child) {
+				continue
+			}
+			if child.tag != "li" {
+				r.block(child)
+				continue
+			}
+			marker := "- "
+			if n.tag == "ol" {
+				marker = strconv.Itoa(number) + ". "
+				number++
+			}
+			r.listItem(child, marker)
+		}
+		r.lists--
+		if r.lists > 0 {
+			r.flushLine()
+		} else {
+			r.blockBreak()
+		}
+
+	case "li":
+		r.flushLine()
+		r.listItem(n, "- ")
+
+	case "blockquote":
+		r.blockBreak()
+		quote := "> "
+		if r.plain {
+			quote = "  "
+		}
+		r.prefixes = append(r.prefixes, linePrefix{first: quote, rest: quote})
+		r.blocks(n)
+		r.flushLine()
+		r.prefixes = r.prefixes[:len(r.prefixes)-1]
+		r.blockBreak()
+
+	case "pre":
+		r.blockBreak()
+		text := strings.Trim(n.textContent(), "\n")
+		if !r.plain {
+			r.emit("```")
+		}
+		for _, line := range strings.Split(text, "\n") {
+			r.emit(strings.TrimRight(line, " \t\r"))
+		}
+		if !r.plain {
+			r.emit("```")
+		}
+		r.blockBreak()
+
+	case "hr":
+		r.blockBreak()
+		if !r.plain {
+			r.write("---")
+		}
+		r.blockBreak()
+
+	case "table":
+		r.blockBreak()
+		r.table(n)
+		r.blockBreak()
+
+	case "dt":
+		r.flushLine()
+		text := strings.TrimSpace(r.inlineChildren(n))
+		if !r.plain && text != "" {
+			text = "**" + text + "**"
+		}
+		r.write(text)
+		r.flushLine()
+
+	case "dd":
+		r.flushLine()
+		r.prefixes = append(r.prefixes, linePrefix{first: "  ", rest: "  "})
+		r.blocks(n)
+		r.flushLine()
+		r.prefixes = r.prefixes[:len(r.prefixes)-1]
+
+	default:
+		r.blockBreak()
+		r.blocks(n)
+		r.blockBreak()
+	}
+}
+
+// listItem renders an item with the given marker on its first line
+func (r *markdownRenderer) listItem(n *htmlNode, marker string) {
+	r.prefixes = append(r.prefixes, linePrefix{first: marker, rest: strings.Repeat(" ", len(marker))})
+	r.blocks(n)
+	r.flushLine()
+	r.prefixes = r.prefixes[:len(r.prefixes)-1]
+}
+
+// table renders the rows of a table, with the first row as its header
+func (r *markdownRenderer) table(n *htmlNode) {
+	var rows [][]string
+	var collect func(*htmlNode)
+	collect = func(n *htmlNode) {
+		for _, child := range n.children {
+			switch child.tag {
+			case "tr":
+				var cells []string
+				for _, cell := range child.children {
+					if cell.tag == "td" || cell.tag == "th" {
+						text := collapseSpace(r.inlineChildren(cell))
+						cells = append(cells, strings.ReplaceAll(text, "|", `\|`))
+					}
+				}
+				if len(cells) > 0 {
+					rows = append(rows, cells)
+				}
+			case "thead", "tbody", "tfoot":
+				collect(child)
+			case "caption":
+				r.write(collapseSpace(r.inlineChildren(child)))
+				r.flushLine()
+			}
+		}
+	}
+	collect(n)
+
+	for i, cells := range rows {
+		if r.plain {
+			r.emit(strings.Join(cells, " | "))
+			continue
+		}
+		r.emit("| " + strings.Join(cells, " | ") + " |")
+		if i == 0 {
+			separator := make([]string, len(cells))
+			for j := range separator {
+				separator[j] = "---"
+			}
+			r.emit("| " + strings.Join(separator, " | ") + " |")
+		}
+	}
+}
+
+// inline renders inline content as a single string, which may contain line
+// breaks from <br>
+func (r *markdownRenderer) inline(n *htmlNode) string {
+	if n.tag == "" {
+		return collapseSpace(n.text)
+	}
+	if r.skip(n) {
+		return ""
+	}
+
+	switch n.tag {
+	case "br":
+		return "\n"
+	case "a":
+		text := r.inlineChildren(n)
+		href, ok := r.resolve(n.attrs["href"])
+		if !ok || strings.TrimSpace(text) == "" {
+			return text
+		}
+		label := collapseSpace(strings.TrimSpace(text))
+		if !r.seen[href] {
+			r.seen[href] = true
+			r.links = append(r.links, FetchLink{Text: label, URL: href})
+		}
+		if r.plain {
+			return text
+		}
+		return surround(text, "[", "]("+href+")")
+	case "img":
+		alt := collapseSpace(n.attrs["alt"])
+		if alt == "" {
+			return ""
+		}
+		if src, ok := r.resolve(n.attrs["src"]); ok && !r.plain {
+			return "![" + alt + "](" + src + ")"
+		}
+		return alt
+	case "strong", "b":
+		return r.emphasis(n, "**")
+	case "em", "i":
+		return r.emphasis(n, "*")
+	case "del", "s", "strike":
+		return r.emphasis(n, "~~")
+	case "code", "kbd", "samp", "tt":
+		return r.emphasis(n, "`")
+	}
+
+	text := r.inlineChildren(n)
+	if blockElements[n.tag] {
+		return " " + text + " "
+	}
+	return text
+}
+
+// inlineChildren renders the children of n as inline content
+func (r *markdownRenderer) inlineChildren(n *htmlNode) string {
+	var b strings.Builder
+	for _, child := range n.children {
+		b.WriteString(r.inline(child))
+	}
+	return b.String()
+}
+
+// emphasis wraps the content of n in marker, keeping surrounding spaces
+// outside it
+func (r *markdownRenderer) emphasis(n *htmlNode, marker string) string {
+	text := r.inlineChildren(n)
+	if r.plain || strings.TrimSpace(text) == "" {
+		return text
+	}
+	return surround(text, marker, marker)
+}
+
+// resolve makes href absolute, rejecting script links and links within the
+// page
+func (r *markdownRenderer) resolve(href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return "", false
+	}
+	if r.base == nil {
+		return href, true
+	}
+	resolved, err := r.base.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	return resolved.String(), true
+}
+
+// write adds inline text to the current line, starting new lines at line
+// breaks
+func (r *markdownRenderer) write(s string) {
+	for i, part := range strings.Split(s, "\n") {
+		if i > 0 {
+			r.flushLine()
+		}
+		if r.line.Len() == 0 {
+			part = strings.TrimLeft(part, " ")
+		}
+		r.line.WriteString(part)
+	}
+}
+
+// flushLine ends the current line, if it has any text
+func (r *markdownRenderer) flushLine() {
+	line := strings.TrimRight(r.line.String(), " ")
+	r.line.Reset()
+	if line != "" {
+		r.emit(line)
+	}
+}
+
+// blockBreak ends the current line and asks for a blank line before the
+// next one
+func (r *markdownRenderer) blockBreak() {
+	r.flushLine()
+	r.blank = true
+}
+
+// emit writes a complete line with the current prefixes
+func (r *markdownRenderer) emit(line string) {
+	if r.blank && r.out.Len() > 0 {
+		// Prefixes that have not started yet, such as the marker of a
+		// new list item, begin after the blank line
+		var blank strings.Builder
+		for _, prefix := range r.prefixes {
+			if prefix.used {
+				blank.WriteString(prefix.rest)
+			}
+		}
+		r.out.WriteString(strings.TrimRight(blank.String(), " "))
+		r.out.WriteByte('\n')
+	}
+	r.blank = false
+
+	for i := range r.prefixes {
+		prefix := &r.prefixes[i]
+		if prefix.used {
+			r.out.WriteString(prefix.rest)
+		} else {
+			r.out.WriteString(prefix.first)
+			prefix.used = true
+		}
+	}
+	r.out.WriteString(line)
+	r.out.WriteByte('\n')
+}
+
+// surround wraps the trimmed text in open and close, keeping its leading
+// and trailing spaces outside
+func surround(text, open, close string) string {
+	trimmed := strings.TrimSpace(text)
+	lead := text[:strings.Index(text, trimmed)]
+	trail := text[len(lead)+len(trimmed):]
+	return lead + open + trimmed + close + trail
+}
+
+// collapseSpace replaces runs of white space with single spaces
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, c := range s {
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(c)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// isHTMLSpace reports whether c is white space in HTML
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// isASCIILetter reports whether c is an ASCII letter
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// indexFold returns the index of the first case-insensitive instance of
+// substr in s, or -1
+func indexFold(s, substr string) int {
+	return strings.Index(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
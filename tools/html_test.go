@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseHTML(t *testing.T) {
+	doc := parseHTML(`<!DOCTYPE html><!-- <p>comment</p> --><ul id=list class='a b'><li>one<li>two &amp; <b>three</b></ul><p>x<div>y</div><script>if (a < b) { "</p>" }</script><img src="a.png"/>`)
+
+	list := doc.findTag("ul")
+	if list == nil || list.attrs["id"] != "list" || list.attrs["class"] != "a b" {
+		t.Fatalf("Expected a list with attributes, got %+v", list)
+	}
+	if len(list.children) != 2 {
+		t.Fatalf("Expected the first <li> to be closed by the second, got %d children", len(list.children))
+	}
+	if got := list.children[1].textContent(); got != "two & three" {
+		t.Errorf("Expected entities to be decoded, got %q", got)
+	}
+
+	if div := doc.findTag("div"); div == nil || div.parent.tag != "#document" {
+		t.Errorf("Expected <div> to close the open <p>, got %+v", div)
+	}
+	if script := doc.findTag("script"); script == nil || script.textContent() != `if (a < b) { "</p>" }` {
+		t.Errorf("Expected script content to be kept as raw text, got %+v", script)
+	}
+	if img := doc.findTag("img"); img == nil || img.attrs["src"] != "a.png" || len(img.children) != 0 {
+		t.Errorf("Expected a void <img>, got %+v", img)
+	}
+	if strings.Contains(doc.textContent(), "comment") {
+		t.Error("Expected comments to be dropped")
+	}
+}
+
+func TestParseHTML_Table(t *testing.T) {
+	doc := parseHTML(`<table><tr><th>A<th>B<tr><td>1<td>2</table>`)
+
+	table := doc.findTag("table")
+	if table == nil || len(table.children) != 2 {
+		t.Fatalf("Expected two rows, got %+v", table)
+	}
+	for _, row := range table.children {
+		if row.tag != "tr" || len(row.children) != 2 {
+			t.Errorf("Expected a row of two cells, got %+v", row)
+		}
+	}
+}
+
+func TestExtractHTML(t *testing.T) {
+	base, _ := url.Parse("https://example.com/docs/page")
+	doc := extractHTML(`<html><head><title>My &amp; Page</title><style>p { color: red }</style></head>
+<body>
+<nav><a href="/">Home</a></nav>
+<header>Site name</header>
+<h1>Hello <em>world</em></h1>
+<p>Some <b>bold </b>text and a <a href="other">link</a>, a <a href="#top">fragment</a>
+and <a href="https://example.org/">another</a>.<p>Line one<br>line two
+<ul><li>one<li>two<ul><li>nested</ul></ul>
+<ol start="3"><li>three</ol>
+<blockquote><p>quoted</p><p>more</p></blockquote>
+<pre><code>func main() {
+    x := 1
+}</code></pre>
+<table><tr><th>A<th>B<tr><td>1<td>2</table>
+<div class="cookie-banner">Accept cookies</div>
+<div hidden>Hidden</div>
+<footer>Copyright</footer>
+</body></html>`, base, false)
+
+	want := strings.Join([]string{
+		"# Hello *world*",
+		"",
+		"Some **bold** text and a [link](https://example.com/docs/other), a fragment and [another](https://example.org/).",
+		"",
+		"Line one",
+		"line two",
+		"",
+		"- one",
+		"- two",
+		"  - nested",
+		"",
+		"3. three",
+		"",
+		"> quoted",
+		">",
+		"> more",
+		"",
+		"```",
+		"func main() {",
+		"    x := 1",
+		"}",
+		"```",
+		"",
+		"| A | B |",
+		"| --- | --- |",
+		"| 1 | 2 |",
+	}, "\n")
+	if doc.Text != want {
+		t.Errorf("Expected Markdown:\n%s\ngot:\n%s", want, doc.Text)
+	}
+	if doc.Title != "My & Page" {
+		t.Errorf("Expected title 'My & Page', got %q", doc.Title)
+	}
+
+	wantLinks := []FetchLink{
+		{Text: "link", URL: "https://example.com/docs/other"},
+		{Text: "another", URL: "https://example.org/"},
+	}
+	if len(doc.Links) != len(wantLinks) {
+		t.Fatalf("Expected links %+v, got %+v", wantLinks, doc.Links)
+	}
+	for i, link := range wantLinks {
+		if doc.Links[i] != link {
+			t.Errorf("Expected link %+v, got %+v", link, doc.Links[i])
+		}
+	}
+}
+
+func TestExtractHTML_MainContent(t *testing.T) {
+	doc := extractHTML(`<body><nav>Menu</nav><div class="sidebar">Related</div>
+<article><header><h1>Title</h1></header><p>Body text</p><footer>By someone</footer></article>
+<div>Unrelated</div></body>`, nil, true)
+
+	want := "Title\n\nBody text\n\nBy someone"
+	if doc.Text != want {
+		t.Errorf("Expected only the article, %q, got %q", want, doc.Text)
+	}
+}
+
+func TestExtractHTML_BaseElement(t *testing.T) {
+	base, _ := url.Parse("https://example.com/a/b")
+	doc := extractHTML(`<head><base href="https://cdn.example.com/root/"></head><body><a href="x.html">X</a></body>`, base, false)
+
+	if len(doc.Links) != 1 || doc.Links[0].URL != "https://cdn.example.com/root/x.html" {
+		t.Errorf("Expected links to resolve against <base>, got %+v", doc.Links)
+	}
+}
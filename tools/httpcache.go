@@ -0,0 +1,412 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxHeuristicFreshness caps how long a response without explicit freshness
+// is fresh, based on how long ago it was last modified
+const maxHeuristicFreshness = 24 * time.Hour
+
+// HTTPCache is a private HTTP cache on disk, so repeated requests for the
+// same resource are answered locally. Responses are keyed by method and URL,
+// and by the request headers their Vary header names. Cache-Control and
+// Expires decide how long a response is fresh, and stale responses are
+// revalidated with If-None-Match and If-Modified-Since. Each method and URL
+// is a file in the cache directory; when the cache grows past its size
+// limit, the least recently used files are removed.
+type HTTPCache struct {
+	dir     string
+	maxSize int64
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// CachedResponse is a response stored in an HTTPCache
+type CachedResponse struct {
+	StatusCode   int               `json:"status_code"`
+	Header       http.Header       `json:"header"`
+	Body         []byte            `json:"body"`
+	URL          string            `json:"url"`            // Final URL, after redirects
+	Vary         map[string]string `json:"vary,omitempty"` // Request headers named by Vary, and their values
+	RequestTime  time.Time         `json:"request_time"`
+	ResponseTime time.Time         `json:"response_time"`
+}
+
+// DefaultHTTPCacheDir returns the default cache directory, ~/.agar/cache/http
+func DefaultHTTPCacheDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".agar", "cache", "http"), nil
+}
+
+// NewHTTPCache creates a cache in dir, which is created when the first
+// response is stored. An empty dir uses DefaultHTTPCacheDir.
+func NewHTTPCache(dir string) (*HTTPCache, error) {
+	if dir == "" {
+		defaultDir, err := DefaultHTTPCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = defaultDir
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache directory %s: %w", dir, err)
+	}
+	return &HTTPCache{dir: abs, now: time.Now}, nil
+}
+
+// WithMaxSize limits the total size of the cache. Responses larger than the
+// limit are not stored. Zero means no limit.
+func (c *HTTPCache) WithMaxSize(bytes int64) *HTTPCache {
+	c.maxSize = bytes
+	return c
+}
+
+// Dir returns the cache directory
+func (c *HTTPCache) Dir() string {
+	return c.dir
+}
+
+// Get returns the stored response to req, fresh or stale, or nil if there is
+// none with matching Vary headers
+func (c *HTTPCache) Get(req *http.Request) *CachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.entryPath(req.Method, req.URL.String())
+	variants, err := c.load(path)
+	if err != nil {
+		return nil
+	}
+	for _, variant := range variants {
+		if variant.matches(req) {
+			// The modification time orders files for eviction
+			now := c.now()
+			_ = os.Chtimes(path, now, now)
+			return variant
+		}
+	}
+	return nil
+}
+
+// Put stores resp, the response to req with the given body, if it may be
+// cached, and reports whether it was stored. requestTime is when req was
+// sent.
+func (c *HTTPCache) Put(req *http.Request, resp *http.Response, body []byte, requestTime time.Time) bool {
+	if !storable(req, resp.StatusCode, resp.Header) {
+		return false
+	}
+	if c.maxSize > 0 && int64(len(body)) > c.maxSize {
+		return false
+	}
+
+	entry := &CachedResponse{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		Body:         body,
+		URL:          resp.Request.URL.String(),
+		Vary:         varyValues(req, resp.Header),
+		RequestTime:  requestTime,
+		ResponseTime: c.now(),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.store(req, entry) == nil
+}
+
+// Refresh updates entry, a stored response to req, with the headers of a
+// 304 Not Modified response that revalidated it, and returns the updated
+// response
+func (c *HTTPCache) Refresh(req *http.Request, entry *CachedResponse, resp *http.Response, requestTime time.Time) *CachedResponse {
+	updated := *entry
+	updated.Header = entry.Header.Clone()
+	for key, values := range resp.Header {
+		if key == "Content-Length" {
+			continue
+		}
+		updated.Header[key] = values
+	}
+	updated.RequestTime = requestTime
+	updated.ResponseTime = c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if storable(req, updated.StatusCode, updated.Header) {
+		_ = c.store(req, &updated)
+	} else {
+		_ = os.Remove(c.entryPath(req.Method, req.URL.String()))
+	}
+	return &updated
+}
+
+// Invalidate removes the stored responses for rawURL, as a successful
+// request with an unsafe method such as POST does
+func (c *HTTPCache) Invalidate(rawURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		_ = os.Remove(c.entryPath(method, rawURL))
+	}
+}
+
+// Fresh reports whether the response can be used at now without
+// revalidating it
+func (r *CachedResponse) Fresh(now time.Time) bool {
+	return r.age(now) < r.lifetime()
+}
+
+// lifetime returns how long the response is fresh for: its max-age, its
+// Expires time, or a tenth of the time since it was last modified
+func (r *CachedResponse) lifetime() time.Duration {
+	directives := parseCacheControl(r.Header.Get("Cache-Control"))
+	if _, ok := directives["no-cache"]; ok {
+		return 0
+	}
+	if maxAge, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	date := r.date()
+	if expires := r.Header.Get("Expires"); expires != "" {
+		at, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return at.Sub(date)
+	}
+	if modified, err := http.ParseTime(r.Header.Get("Last-Modified")); err == nil {
+		heuristic := date.Sub(modified) / 10
+		if heuristic > maxHeuristicFreshness {
+			heuristic = maxHeuristicFreshness
+		}
+		return heuristic
+	}
+	return 0
+}
+
+// age returns how old the response is at now, counting the time it spent in
+// caches upstream
+func (r *CachedResponse) age(now time.Time) time.Duration {
+	age := r.ResponseTime.Sub(r.date())
+	if seconds, err := strconv.Atoi(r.Header.Get("Age")); err == nil {
+		upstream := time.Duration(seconds)*time.Second + r.ResponseTime.Sub(r.RequestTime)
+		if upstream > age {
+			age = upstream
+		}
+	}
+	if age < 0 {
+		age = 0
+	}
+	return age + now.Sub(r.ResponseTime)
+}
+
+// date returns when the response was generated, by its Date header or when
+// it was received
+func (r *CachedResponse) date() time.Time {
+	if date, err := http.ParseTime(r.Header.Get("Date")); err == nil {
+		return date
+	}
+	return r.ResponseTime
+}
+
+// addValidators makes req conditional on the response having changed, and
+// reports whether the response has validators to do so
+func (r *CachedResponse) addValidators(req *http.Request) bool {
+	etag := r.Header.Get("ETag")
+	modified := r.Header.Get("Last-Modified")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if modified != "" {
+		req.Header.Set("If-Modified-Since", modified)
+	}
+	return etag != "" || modified != ""
+}
+
+// matches reports whether the response was to a request with the same
+// values as req for the headers named by Vary
+func (r *CachedResponse) matches(req *http.Request) bool {
+	for name, value := range r.Vary {
+		if strings.Join(req.Header.Values(name), ", ") != value {
+			return false
+		}
+	}
+	return true
+}
+
+// store saves entry as the variant for its Vary headers and evicts the
+// least recently used files over the size limit; the caller must hold c.mu
+func (c *HTTPCache) store(req *http.Request, entry *CachedResponse) error {
+	path := c.entryPath(req.Method, req.URL.String())
+	variants, _ := c.load(path)
+
+	replaced := false
+	for i, variant := range variants {
+		if variant.matches(req) {
+			variants[i] = entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		variants = append(variants, entry)
+	}
+
+	data, err := json.Marshal(variants)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		return err
+	}
+	now := c.now()
+	_ = os.Chtimes(path, now, now)
+
+	c.evict(path)
+	return nil
+}
+
+// evict removes the least recently used files until the cache fits in
+// maxSize, keeping the file at keep; the caller must hold c.mu
+func (c *HTTPCache) evict(keep string) {
+	if c.maxSize <= 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+
+	type cacheFile struct {
+		path string
+		size int64
+		used time.Time
+	}
+	var files []cacheFile
+	var total int64
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cacheFile{filepath.Join(c.dir, entry.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].used.Before(files[j].used) })
+	for _, file := range files {
+		if total <= c.maxSize {
+			break
+		}
+		if file.path == keep {
+			continue
+		}
+		if os.Remove(file.path) == nil {
+			total -= file.size
+		}
+	}
+}
+
+// load reads the variants stored at path; the caller must hold c.mu
+func (c *HTTPCache) load(path string) ([]*CachedResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var variants []*CachedResponse
+	if err := json.Unmarshal(data, &variants); err != nil {
+		return nil, fmt.Errorf("invalid cache entry: %w", err)
+	}
+	return variants, nil
+}
+
+// entryPath returns the file that holds the responses for a method and URL
+func (c *HTTPCache) entryPath(method, rawURL string) string {
+	sum := sha256.Sum256([]byte(strings.ToUpper(method) + " " + rawURL))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// storable reports whether a response may be stored: it answers a GET,
+// neither side forbids storing it, and it can be reused without asking, or
+// revalidated later
+func storable(req *http.Request, status int, header http.Header) bool {
+	if req.Method != http.MethodGet || (status != http.StatusOK && status != http.StatusNonAuthoritativeInfo) {
+		return false
+	}
+	if _, ok := parseCacheControl(req.Header.Get("Cache-Control"))["no-store"]; ok {
+		return false
+	}
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return false
+	}
+	if strings.Contains(header.Get("Vary"), "*") {
+		return false
+	}
+
+	_, maxAge := directives["max-age"]
+	return maxAge || header.Get("Expires") != "" || header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+}
+
+// varyValues returns the values in req of the headers named by a response's
+// Vary header
+func varyValues(req *http.Request, header http.Header) map[string]string {
+	var values map[string]string
+	for _, vary := range header.Values("Vary") {
+		for _, name := range strings.Split(vary, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if values == nil {
+				values = make(map[string]string)
+			}
+			values[name] = strings.Join(req.Header.Values(name), ", ")
+		}
+	}
+	return values
+}
+
+// parseCacheControl returns the directives of a Cache-Control header, with
+// lower-case names and unquoted values
+func parseCacheControl(header string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+	}
+	return directives
+}
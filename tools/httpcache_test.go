@@ -0,0 +1,263 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestCache returns a cache in a temporary directory with a clock the
+// test can move forward
+func newTestCache(t *testing.T) (*HTTPCache, *time.Time) {
+	t.Helper()
+	cache, err := NewHTTPCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewHTTPCache failed: %v", err)
+	}
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+// fetch runs the fetch tool with params and returns its result
+func fetch(t *testing.T, tool *FetchTool, params FetchParams) *FetchResult {
+	t.Helper()
+	data, _ := json.Marshal(params)
+	result, err := tool.Execute(context.Background(), data)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	return result.(*FetchResult)
+}
+
+func TestFetchTool_Cache_MaxAge(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprintf(w, "response %d", n)
+	}))
+	defer server.Close()
+
+	cache, now := newTestCache(t)
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy()).WithCache(cache)
+
+	first := fetch(t, tool, FetchParams{URL: server.URL})
+	if first.Cached || first.Content != "response 1" {
+		t.Errorf("Expected a response from the server, got %+v", first)
+	}
+
+	second := fetch(t, tool, FetchParams{URL: server.URL})
+	if !second.Cached || second.Content != "response 1" || requests.Load() != 1 {
+		t.Errorf("Expected the cached response, got %+v after %d requests", second, requests.Load())
+	}
+
+	*now = now.Add(2 * time.Minute)
+	third := fetch(t, tool, FetchParams{URL: server.URL})
+	if third.Cached || third.Content != "response 2" {
+		t.Errorf("Expected a stale response without validators to be fetched again, got %+v", third)
+	}
+}
+
+func TestFetchTool_Cache_Revalidate(t *testing.T) {
+	var requests, notModified atomic.Int32
+	modified := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.URL.Path == "/etag" {
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		} else {
+			w.Header().Set("Last-Modified", modified)
+			if r.Header.Get("If-Modified-Since") == modified {
+				notModified.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	cache, _ := newTestCache(t)
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy()).WithCache(cache)
+
+	for _, path := range []string{"/etag", "/modified"} {
+		fetch(t, tool, FetchParams{URL: server.URL + path})
+		result := fetch(t, tool, FetchParams{URL: server.URL + path})
+		if !result.Cached || result.Content != "body" || result.StatusCode != http.StatusOK {
+			t.Errorf("%s: Expected the revalidated response from the cache, got %+v", path, result)
+		}
+	}
+	if requests.Load() != 4 || notModified.Load() != 2 {
+		t.Errorf("Expected 4 requests with 2 revalidations, got %d and %d", requests.Load(), notModified.Load())
+	}
+}
+
+func TestFetchTool_Cache_Bypass(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "no-store")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	cache, _ := newTestCache(t)
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy()).WithCache(cache)
+
+	tests := []struct {
+		name   string
+		params FetchParams
+	}{
+		{"no-store response", FetchParams{URL: server.URL + "/private"}},
+		{"authenticated", FetchParams{URL: server.URL + "/auth", Auth: &AuthConfig{Type: "bearer", Token: "secret"}}},
+		{"no-store request", FetchParams{URL: server.URL + "/header", Headers: map[string]string{"Cache-Control": "no-store"}}},
+		{"post", FetchParams{URL: server.URL + "/post", Method: "POST"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := requests.Load()
+			fetch(t, tool, tt.params)
+			if result := fetch(t, tool, tt.params); result.Cached {
+				t.Error("Expected the cache to be bypassed")
+			}
+			if requests.Load()-before != 2 {
+				t.Errorf("Expected 2 requests, got %d", requests.Load()-before)
+			}
+		})
+	}
+}
+
+func TestFetchTool_Cache_Vary(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte("lang " + r.Header.Get("Accept-Language")))
+	}))
+	defer server.Close()
+
+	cache, _ := newTestCache(t)
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy()).WithCache(cache)
+
+	for _, lang := range []string{"en", "fr", "en", "fr"} {
+		result := fetch(t, tool, FetchParams{URL: server.URL, Headers: map[string]string{"Accept-Language": lang}})
+		if result.Content != "lang "+lang {
+			t.Errorf("Expected the %s variant, got %q", lang, result.Content)
+		}
+	}
+	if requests.Load() != 2 {
+		t.Errorf("Expected one request per variant, got %d", requests.Load())
+	}
+}
+
+func TestFetchTool_Cache_Invalidate(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	cache, _ := newTestCache(t)
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy()).WithCache(cache)
+
+	fetch(t, tool, FetchParams{URL: server.URL})
+	fetch(t, tool, FetchParams{URL: server.URL, Method: "PUT", Body: "new"})
+	if result := fetch(t, tool, FetchParams{URL: server.URL}); result.Cached {
+		t.Error("Expected a PUT to invalidate the cached response")
+	}
+	if requests.Load() != 3 {
+		t.Errorf("Expected 3 requests, got %d", requests.Load())
+	}
+}
+
+func TestHTTPCache_Evict(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(strings.Repeat("x", 1000)))
+	}))
+	defer server.Close()
+
+	cache, now := newTestCache(t)
+	cache.WithMaxSize(5000)
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy()).WithCache(cache)
+
+	// Each entry is a little over 1000 bytes once encoded, so three fit
+	for _, path := range []string{"/a", "/b", "/c"} {
+		fetch(t, tool, FetchParams{URL: server.URL + path})
+		*now = now.Add(time.Second)
+	}
+	fetch(t, tool, FetchParams{URL: server.URL + "/a"}) // Used most recently
+	*now = now.Add(time.Second)
+	fetch(t, tool, FetchParams{URL: server.URL + "/d"})
+
+	for path, want := range map[string]bool{"/a": true, "/b": false, "/c": true, "/d": true} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if got := cache.Get(req) != nil; got != want {
+			t.Errorf("%s: Expected cached %v, got %v", path, want, got)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(cache.Dir(), "*.json"))
+	var total int64
+	for _, file := range files {
+		info, _ := os.Stat(file)
+		total += info.Size()
+	}
+	if total > 5000 {
+		t.Errorf("Expected the cache to fit in 5000 bytes, got %d", total)
+	}
+}
+
+func TestCachedResponse_Fresh(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	date := now.Format(http.TimeFormat)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		after  time.Duration
+		want   bool
+	}{
+		{"max-age", http.Header{"Cache-Control": {"max-age=60"}}, 30 * time.Second, true},
+		{"max-age expired", http.Header{"Cache-Control": {"max-age=60"}}, 90 * time.Second, false},
+		{"age counts", http.Header{"Cache-Control": {"public, max-age=60"}, "Age": {"50"}}, 20 * time.Second, false},
+		{"no-cache", http.Header{"Cache-Control": {"no-cache, max-age=60"}}, 0, false},
+		{"expires", http.Header{"Date": {date}, "Expires": {now.Add(time.Minute).Format(http.TimeFormat)}}, 30 * time.Second, true},
+		{"invalid expires", http.Header{"Expires": {"0"}}, 0, false},
+		{"heuristic", http.Header{"Date": {date}, "Last-Modified": {now.Add(-10 * time.Hour).Format(http.TimeFormat)}}, 30 * time.Minute, true},
+		{"heuristic expired", http.Header{"Date": {date}, "Last-Modified": {now.Add(-10 * time.Hour).Format(http.TimeFormat)}}, 2 * time.Hour, false},
+		{"no freshness", http.Header{"ETag": {`"x"`}}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := &CachedResponse{Header: tt.header, RequestTime: now, ResponseTime: now}
+			if got := response.Fresh(now.Add(tt.after)); got != tt.want {
+				t.Errorf("Fresh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// retryBaseDelay is the delay before the first retry, doubled for each
+	// retry after it
+	retryBaseDelay = time.Second
+
+	// maxRetryDelay caps the delay between retries, including delays a
+	// server asks for with Retry-After
+	maxRetryDelay = 30 * time.Second
+)
+
+// sharedTransport is the transport the network tools use without a network
+// policy, so connections are pooled across tools and calls
+var sharedTransport = newTransport(http.ProxyFromEnvironment, newDialer(nil))
+
+// defaultNetworkPolicy is the policy NewFetchTool and NewDownloadTool start
+// with; sharing it shares its transport
+var defaultNetworkPolicy = NewNetworkPolicy()
+
+// newDialer returns a dialer for the network tools. A non-nil control runs
+// on each address after DNS resolution, before connecting.
+func newDialer(control func(network, address string, c syscall.RawConn) error) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   control,
+	}
+}
+
+// newTransport returns a transport tuned for the network tools: pooled
+// keep-alive connections, HTTP/2 when the server supports it, and bounded
+// handshakes. Timeouts for whole requests come from their contexts.
+func newTransport(proxy func(*http.Request) (*url.URL, error), dialer *net.Dialer) *http.Transport {
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// newToolClient returns a client for a network tool that sends requests
+// through policy's transport and checks redirects against it. Redirects
+// read policy on each call, but the transport is taken once, so a tool that
+// replaces its policy must also replace the client's transport.
+func newToolClient(policy func() *NetworkPolicy) *http.Client {
+	return &http.Client{
+		Transport: policy().Transport(),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return policy().CheckRedirect(req, via)
+		},
+	}
+}
+
+// statusError is an HTTP response with an error status
+type statusError struct {
+	StatusCode int
+	RetryAfter string // The response's Retry-After header, if any
+}
+
+// Error describes the status
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// retryDelay returns how long to wait before retry number attempt, counting
+// from zero. A Retry-After header, in seconds or as an HTTP date, is
+// honored; otherwise the delay doubles with each attempt, with random
+// jitter so parallel callers spread out. Both are capped at maxRetryDelay.
+func retryDelay(attempt int, retryAfter string) time.Duration {
+	if delay, ok := parseRetryAfter(retryAfter, time.Now()); ok {
+		return min(delay, maxRetryDelay)
+	}
+
+	delay := maxRetryDelay
+	if attempt < 16 {
+		delay = min(retryBaseDelay<<attempt, maxRetryDelay)
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter returns the delay a Retry-After header asks for at now
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d, or until ctx is done, in which case it returns
+// ctx's error
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	for attempt := 0; attempt < 4; attempt++ {
+		full := retryBaseDelay << attempt
+		for i := 0; i < 20; i++ {
+			if delay := retryDelay(attempt, ""); delay < full/2 || delay > full {
+				t.Errorf("attempt %d: Expected a delay between %v and %v, got %v", attempt, full/2, full, delay)
+			}
+		}
+	}
+
+	if delay := retryDelay(100, ""); delay < maxRetryDelay/2 || delay > maxRetryDelay {
+		t.Errorf("Expected a late delay capped at %v, got %v", maxRetryDelay, delay)
+	}
+	if delay := retryDelay(0, "7"); delay != 7*time.Second {
+		t.Errorf("Expected Retry-After to be honored, got %v", delay)
+	}
+	if delay := retryDelay(0, "3600"); delay != maxRetryDelay {
+		t.Errorf("Expected Retry-After to be capped at %v, got %v", maxRetryDelay, delay)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"seconds", "120", 2 * time.Minute, true},
+		{"zero", "0", 0, true},
+		{"date", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{"past date", now.Add(-time.Hour).Format(http.TimeFormat), 0, true},
+		{"empty", "", 0, false},
+		{"negative", "-5", 0, false},
+		{"invalid", "soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFetchTool_Execute_RetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+	params, _ := json.Marshal(FetchParams{URL: server.URL, MaxRetries: 1})
+
+	start := time.Now()
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.(*FetchResult).Content != "ok" || requests.Load() != 2 {
+		t.Errorf("Expected a retry after 429, got %+v after %d requests", result, requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed > retryBaseDelay/2 {
+		t.Errorf("Expected Retry-After: 0 to retry at once, took %v", elapsed)
+	}
+}
+
+func TestFetchTool_Execute_CancelDuringBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+	params, _ := json.Marshal(FetchParams{URL: server.URL, MaxRetries: 3})
+
+	start := time.Now()
+	_, err := tool.Execute(ctx, params)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected cancellation to end the backoff, took %v", elapsed)
+	}
+}
+
+func TestFetchTool_Execute_Concurrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(1500 * time.Millisecond)
+		}
+		fmt.Fprint(w, r.URL.Query().Get("n"))
+	}))
+	defer server.Close()
+
+	// Calls with different timeouts share one tool; each call's timeout
+	// applies to it alone
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy())
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			params := FetchParams{URL: fmt.Sprintf("%s/fast?n=%d", server.URL, i), Timeout: 30}
+			if i%4 == 0 {
+				params = FetchParams{URL: server.URL + "/slow", Timeout: 1}
+			}
+			data, _ := json.Marshal(params)
+			result, err := tool.Execute(context.Background(), data)
+
+			if i%4 == 0 {
+				if err == nil {
+					t.Errorf("call %d: Expected a timeout", i)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("call %d: Execute failed: %v", i, err)
+				return
+			}
+			if got := result.(*FetchResult).Content; got != fmt.Sprint(i) {
+				t.Errorf("call %d: Expected content %d, got %q", i, i, got)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestDownloadTool_Execute_Concurrent(t *testing.T) {
+	var seen sync.Map
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first request for each file is throttled, to exercise retries
+		if _, retried := seen.LoadOrStore(r.URL.Path, true); !retried {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, r.URL.Path)
+	}))
+	defer server.Close()
+
+	tool := NewDownloadTool().WithNetworkPolicy(localPolicy())
+	dir := t.TempDir()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			output := filepath.Join(dir, fmt.Sprintf("file-%d", i))
+			params, _ := json.Marshal(DownloadParams{
+				URL:        fmt.Sprintf("%s/file-%d", server.URL, i),
+				OutputPath: output,
+				Timeout:    10 + i,
+			})
+			if _, err := tool.Execute(context.Background(), params); err != nil {
+				t.Errorf("download %d failed: %v", i, err)
+				return
+			}
+			if got, want := readFile(t, output), fmt.Sprintf("/file-%d", i); got != want {
+				t.Errorf("download %d: Expected content %q, got %q", i, want, got)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestNetworkPolicy_Transport_Shared(t *testing.T) {
+	policy := localPolicy()
+	if policy.Transport() != policy.Transport() {
+		t.Error("Expected a policy to reuse its transport")
+	}
+	if NewFetchTool().client.Transport != NewDownloadTool().client.Transport {
+		t.Error("Expected the default tools to share a transport")
+	}
+	var none *NetworkPolicy
+	if none.Transport() != sharedTransport {
+		t.Error("Expected a nil policy to use the shared transport")
+	}
+}
@@ -8,18 +8,23 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // ListTool implements directory listing functionality
-type ListTool struct{}
+type ListTool struct {
+	workspace *Workspace
+}
 
 // ListParams defines the parameters for the List tool
 type ListParams struct {
 	Path      string   `json:"path"`
 	Pattern   string   `json:"pattern,omitempty"`
 	Recursive bool     `json:"recursive,omitempty"`
-	Include   []string `json:"include,omitempty"` // File extensions to include
-	Exclude   []string `json:"exclude,omitempty"` // File extensions to exclude
+	Include   []string `json:"include,omitempty"`   // File extensions to include
+	Exclude   []string `json:"exclude,omitempty"`   // File extensions to exclude
+	Hidden    *bool    `json:"hidden,omitempty"`    // Include names starting with a dot; defaults to true
+	NoIgnore  *bool    `json:"no_ignore,omitempty"` // Include paths excluded by .gitignore and .ignore; defaults to true
 }
 
 // ListResult represents the result of a list operation
@@ -34,6 +39,12 @@ func NewListTool() *ListTool {
 	return &ListTool{}
 }
 
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *ListTool) WithWorkspace(ws *Workspace) *ListTool {
+	t.workspace = ws
+	return t
+}
+
 // Name returns the tool's name
 func (t *ListTool) Name() string {
 	return "list"
@@ -44,6 +55,11 @@ func (t *ListTool) Description() string {
 	return "List directory contents with filtering support including pattern matching, recursive listing, and file metadata"
 }
 
+// Risk returns the tool's risk class
+func (t *ListTool) Risk() Risk {
+	return RiskReadOnly
+}
+
 // Schema returns the JSON schema for the tool's parameters
 func (t *ListTool) Schema() map[string]interface{} {
 	return map[string]interface{}{
@@ -75,8 +91,17 @@ func (t *ListTool) Schema() map[string]interface{} {
 					"type": "string",
 				},
 			},
+			"hidden": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Include files and directories whose names start with a dot (default: true)",
+			},
+			"no_ignore": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Include files excluded by .gitignore and .ignore files (default: true)",
+			},
 		},
-		"required": []string{"path"},
+		"required":             []string{"path"},
+		"additionalProperties": false,
 	}
 }
 
@@ -101,6 +126,10 @@ func (t *ListTool) Execute(ctx context.Context, params json.RawMessage) (interfa
 		return nil, fmt.Errorf("invalid parameters: %w", err)
 	}
 
+	if err := t.workspace.Check(p.Path); err != nil {
+		return nil, err
+	}
+
 	// Check if path exists
 	info, err := os.Stat(p.Path)
 	if err != nil {
@@ -114,14 +143,7 @@ func (t *ListTool) Execute(ctx context.Context, params json.RawMessage) (interfa
 		return nil, fmt.Errorf("path is not a directory: %s", p.Path)
 	}
 
-	var files []FileInfo
-
-	if p.Recursive {
-		files, err = t.listRecursive(p.Path, p.Pattern, p.Include, p.Exclude)
-	} else {
-		files, err = t.listDirectory(p.Path, p.Pattern, p.Include, p.Exclude)
-	}
-
+	files, err := t.listDirectory(ctx, p)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list directory: %w", err)
 	}
@@ -138,114 +160,56 @@ func (t *ListTool) Execute(ctx context.Context, params json.RawMessage) (interfa
 	}, nil
 }
 
-// listDirectory lists files in a single directory
-func (t *ListTool) listDirectory(path, pattern string, include, exclude []string) ([]FileInfo, error) {
-	entries, err := os.ReadDir(path)
-	if err != nil {
-		return nil, err
-	}
-
-	var files []FileInfo
-
-	for _, entry := range entries {
-		fullPath := filepath.Join(path, entry.Name())
-
-		// Skip if doesn't match pattern
-		if pattern != "" {
-			matched, err := filepath.Match(pattern, entry.Name())
-			if err != nil {
-				return nil, fmt.Errorf("invalid pattern: %w", err)
-			}
-			if !matched {
-				continue
-			}
+// listDirectory lists the entries of p.Path, and those below it if
+// p.Recursive is set
+func (t *ListTool) listDirectory(ctx context.Context, p ListParams) ([]FileInfo, error) {
+	if p.Pattern != "" {
+		if _, err := filepath.Match(p.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
 		}
-
-		// Skip if not in include list
-		if len(include) > 0 && !entry.IsDir() {
-			if !matchesExtensions(entry.Name(), include) {
-				continue
-			}
-		}
-
-		// Skip if in exclude list
-		if len(exclude) > 0 && !entry.IsDir() {
-			if matchesExtensions(entry.Name(), exclude) {
-				continue
-			}
-		}
-
-		info, err := entry.Info()
-		if err != nil {
-			continue // Skip files we can't stat
-		}
-
-		fileInfo := FileInfo{
-			Path:         fullPath,
-			Name:         entry.Name(),
-			Size:         info.Size(),
-			IsDir:        entry.IsDir(),
-			Permissions:  info.Mode().String(),
-			ModifiedTime: info.ModTime().Unix(),
-		}
-
-		files = append(files, fileInfo)
 	}
 
-	return files, nil
-}
+	// Unlike the search tools, list shows everything unless asked not to
+	opts := WalkOptions{
+		Hidden:      p.Hidden == nil || *p.Hidden,
+		NoIgnore:    p.NoIgnore == nil || *p.NoIgnore,
+		IncludeDirs: true,
+	}
+	if !p.Recursive {
+		opts.MaxDepth = 1
+	}
+	// Skip entries outside the workspace
+	if t.workspace != nil {
+		opts.Filter = t.workspace.Allows
+	}
 
-// listRecursive lists files recursively
-func (t *ListTool) listRecursive(path, pattern string, include, exclude []string) ([]FileInfo, error) {
+	var mu sync.Mutex
 	var files []FileInfo
 
-	err := filepath.Walk(path, func(walkPath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip files with errors
-		}
-
-		// Skip the root directory itself
-		if walkPath == path {
-			return nil
-		}
-
-		name := filepath.Base(walkPath)
+	err := Walk(ctx, p.Path, opts, func(entry WalkEntry) error {
+		name := filepath.Base(entry.Path)
+		isDir := entry.Info.IsDir()
 
 		// Skip if doesn't match pattern
-		if pattern != "" {
-			matched, err := filepath.Match(pattern, name)
-			if err != nil {
-				return fmt.Errorf("invalid pattern: %w", err)
-			}
-			if !matched {
+		if p.Pattern != "" {
+			if matched, _ := filepath.Match(p.Pattern, name); !matched {
 				return nil
 			}
 		}
 
 		// Skip if not in include list
-		if len(include) > 0 && !info.IsDir() {
-			if !matchesExtensions(name, include) {
-				return nil
-			}
+		if len(p.Include) > 0 && !isDir && !matchesExtensions(name, p.Include) {
+			return nil
 		}
 
 		// Skip if in exclude list
-		if len(exclude) > 0 && !info.IsDir() {
-			if matchesExtensions(name, exclude) {
-				return nil
-			}
-		}
-
-		fileInfo := FileInfo{
-			Path:         walkPath,
-			Name:         name,
-			Size:         info.Size(),
-			IsDir:        info.IsDir(),
-			Permissions:  info.Mode().String(),
-			ModifiedTime: info.ModTime().Unix(),
+		if len(p.Exclude) > 0 && !isDir && matchesExtensions(name, p.Exclude) {
+			return nil
 		}
 
-		files = append(files, fileInfo)
+		mu.Lock()
+		files = append(files, newFileInfo(entry.Path, entry.Info))
+		mu.Unlock()
 		return nil
 	})
 
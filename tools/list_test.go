@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -278,6 +279,43 @@ func TestListTool_Execute_RecursiveWithPattern(t *testing.T) {
 	}
 }
 
+func TestListTool_Execute_HiddenAndIgnored(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.Mkdir(filepath.Join(tmpDir, ".git"), 0755)
+	_ = os.Mkdir(filepath.Join(tmpDir, "build"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("build/\n"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("SECRET=1"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main"), 0644)
+
+	tool := NewListTool()
+	ctx := context.Background()
+
+	names := func(params map[string]interface{}) []string {
+		t.Helper()
+		paramsJSON, _ := json.Marshal(params)
+		result, err := tool.Execute(ctx, paramsJSON)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		var names []string
+		for _, file := range result.(*ListResult).Files {
+			names = append(names, file.Name)
+		}
+		return names
+	}
+
+	// Everything but .git is listed by default
+	got := names(map[string]interface{}{"path": tmpDir})
+	if want := []string{".env", ".gitignore", "build", "main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	got = names(map[string]interface{}{"path": tmpDir, "hidden": false, "no_ignore": false})
+	if want := []string{"main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected hidden and ignored entries to be skipped, got %v", got)
+	}
+}
+
 func TestListTool_Execute_NonExistentPath(t *testing.T) {
 	tool := NewListTool()
 	ctx := context.Background()
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// MatchPath reports whether name matches a slash-separated glob pattern.
+// Besides the path.Match syntax, a "**" segment matches zero or more
+// directories, so "**/.env" matches ".env" and "a/b/.env", and ".git/**"
+// matches ".git" and everything below it. Braces list alternatives, so
+// "*.{go,md}" matches "main.go" and "README.md". name is converted to slashes
+// first. A malformed pattern matches nothing.
+func MatchPath(pattern, name string) bool {
+	return compilePattern(pattern, false).match(name)
+}
+
+// pathPattern is a MatchPath pattern split into segments, with its brace
+// alternatives expanded
+type pathPattern struct {
+	alternatives [][]string
+	fold         bool // Match case-insensitively
+}
+
+// compilePattern prepares pattern for repeated matching. If fold is set,
+// matching ignores case.
+func compilePattern(pattern string, fold bool) pathPattern {
+	if fold {
+		pattern = strings.ToLower(pattern)
+	}
+	compiled := pathPattern{fold: fold}
+	for _, alternative := range expandBraces(pattern) {
+		compiled.alternatives = append(compiled.alternatives, splitPath(alternative))
+	}
+	return compiled
+}
+
+// match reports whether name matches the pattern
+func (p pathPattern) match(name string) bool {
+	name = filepath.ToSlash(name)
+	if p.fold {
+		name = strings.ToLower(name)
+	}
+	segments := splitPath(name)
+	for _, alternative := range p.alternatives {
+		if matchSegments(alternative, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesBelow reports whether the pattern could match a path below the
+// directory dir, so that directories that cannot contain a match are skipped
+func (p pathPattern) matchesBelow(dir string) bool {
+	dir = filepath.ToSlash(dir)
+	if p.fold {
+		dir = strings.ToLower(dir)
+	}
+	segments := splitPath(dir)
+	for _, alternative := range p.alternatives {
+		if matchPrefix(alternative, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandBraces expands the first top-level brace group in pattern into one
+// pattern per comma-separated alternative, recursively. Unbalanced braces
+// are left as they are.
+func expandBraces(pattern string) []string {
+	start, depth := -1, 0
+	var commas []int
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			if depth == 0 {
+				start = i
+				commas = commas[:0]
+			}
+			depth++
+		case ',':
+			if depth == 1 {
+				commas = append(commas, i)
+			}
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+
+			prefix, suffix := pattern[:start], pattern[i+1:]
+			var expanded []string
+			from := start + 1
+			for _, comma := range append(commas, i) {
+				expanded = append(expanded, expandBraces(prefix+pattern[from:comma]+suffix)...)
+				from = comma + 1
+			}
+			return expanded
+		}
+	}
+	return []string{pattern}
+}
+
+// splitPath splits a slash-separated path into segments, keeping a leading
+// empty segment for absolute paths
+func splitPath(p string) []string {
+	if p == "" {
+		return nil
+	}
+	segments := strings.Split(p, "/")
+
+	// Drop empty segments from doubled or trailing slashes
+	cleaned := segments[:1]
+	for _, segment := range segments[1:] {
+		if segment != "" {
+			cleaned = append(cleaned, segment)
+		}
+	}
+	return cleaned
+}
+
+// matchSegments matches path segments against pattern segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse consecutive ** segments
+			for len(pattern) > 1 && pattern[1] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchPrefix reports whether some path below dir could match pattern
+func matchPrefix(pattern, dir []string) bool {
+	for ; len(dir) > 0; dir = dir[1:] {
+		if len(pattern) == 0 {
+			return false
+		}
+		if pattern[0] == "**" {
+			return true
+		}
+		if ok, err := path.Match(pattern[0], dir[0]); err != nil || !ok {
+			return false
+		}
+		pattern = pattern[1:]
+	}
+	return len(pattern) > 0
+}
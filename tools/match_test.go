@@ -0,0 +1,35 @@
+package tools
+
+import "testing"
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "cmd/agar/main.go", true},
+		{"**/.env", ".env", true},
+		{"**/.env", "config/.env", true},
+		{"**/.env", "config/.env.example", false},
+		{".git/**", ".git", true},
+		{".git/**", ".git/objects/ab/cdef", true},
+		{".git/**", "src/.git/config", false},
+		{"docs/**/*.md", "docs/a/b/c.md", true},
+		{"docs/**/*.md", "docs/c.md", true},
+		{"docs/**/*.md", "src/c.md", false},
+		{"/etc/**", "/etc/passwd", true},
+		{"/etc/**", "etc/passwd", false},
+		{"a/**/**/b", "a/b", true},
+		{"[", "[", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchPath(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchPath(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
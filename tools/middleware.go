@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Executor runs a tool with the given parameters and reports the outcome
+type Executor func(ctx context.Context, tool Tool, params json.RawMessage) *ToolResult
+
+// Middleware wraps an Executor to add behavior around tool execution, such
+// as logging, timeouts or policy checks
+type Middleware func(next Executor) Executor
+
+// Chain wraps executor with middleware. The first middleware is the
+// outermost, so it sees every call first and every result last.
+func Chain(executor Executor, middleware ...Middleware) Executor {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		executor = middleware[i](executor)
+	}
+	return executor
+}
+
+// runTool is the innermost Executor; it calls the tool and wraps the outcome
+func runTool(ctx context.Context, tool Tool, params json.RawMessage) *ToolResult {
+	data, err := tool.Execute(ctx, params)
+	if err != nil {
+		return &ToolResult{Success: false, Data: data, Error: err.Error()}
+	}
+	return &ToolResult{Success: true, Data: data}
+}
+
+// Validation checks params against the tool's schema and the tool's own
+// Validate before calling next. The registry always runs it closest to the
+// tool; it is exported for building chains with Chain.
+func Validation() Middleware {
+	return func(next Executor) Executor {
+		return func(ctx context.Context, tool Tool, params json.RawMessage) *ToolResult {
+			if err := validateTool(tool, params); err != nil {
+				return &ToolResult{Success: false, Error: err.Error()}
+			}
+			return next(ctx, tool, params)
+		}
+	}
+}
+
+// Timeout limits how long a tool may run. defaultTimeout applies to every
+// tool not listed in perTool; a zero duration means no limit. When the limit
+// is reached the call returns an error result right away, even if the tool
+// ignores its context and keeps running in the background.
+func Timeout(defaultTimeout time.Duration, perTool map[string]time.Duration) Middleware {
+	return func(next Executor) Executor {
+		return func(ctx context.Context, tool Tool, params json.RawMessage) *ToolResult {
+			timeout := defaultTimeout
+			if d, ok := perTool[tool.Name()]; ok {
+				timeout = d
+			}
+			if timeout <= 0 {
+				return next(ctx, tool, params)
+			}
+
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			done := make(chan *ToolResult, 1)
+			go func() {
+				// Panics in this goroutine cannot reach an outer Recovery
+				done <- Chain(next, Recovery())(ctx, tool, params)
+			}()
+
+			select {
+			case result := <-done:
+				if !result.Success && ctx.Err() == context.DeadlineExceeded {
+					result.Error = fmt.Sprintf("tool %s timed out after %s: %s", tool.Name(), timeout, result.Error)
+				}
+				return result
+			case <-ctx.Done():
+				if ctx.Err() == context.DeadlineExceeded {
+					return &ToolResult{Success: false, Error: fmt.Sprintf("tool %s timed out after %s", tool.Name(), timeout)}
+				}
+				return &ToolResult{Success: false, Error: fmt.Sprintf("tool %s cancelled: %v", tool.Name(), ctx.Err())}
+			}
+		}
+	}
+}
+
+// Recovery turns a panic in a tool into an error result instead of crashing
+// the program
+func Recovery() Middleware {
+	return func(next Executor) Executor {
+		return func(ctx context.Context, tool Tool, params json.RawMessage) (result *ToolResult) {
+			defer func() {
+				if r := recover(); r != nil {
+					result = &ToolResult{Success: false, Error: fmt.Sprintf("tool %s panicked: %v", tool.Name(), r)}
+				}
+			}()
+			return next(ctx, tool, params)
+		}
+	}
+}
+
+// Logging records every tool call and its outcome with logger, or
+// slog.Default() if logger is nil. Calls are logged at debug level and
+// results at info level, or warn level when they fail.
+func Logging(logger *slog.Logger) Middleware {
+	return func(next Executor) Executor {
+		return func(ctx context.Context, tool Tool, params json.RawMessage) *ToolResult {
+			log := logger
+			if log == nil {
+				log = slog.Default()
+			}
+
+			log.DebugContext(ctx, "tool call", "tool", tool.Name(), "params", string(params))
+
+			start := time.Now()
+			result := next(ctx, tool, params)
+			duration := time.Since(start)
+
+			if result.Success {
+				log.InfoContext(ctx, "tool succeeded", "tool", tool.Name(), "duration", duration)
+			} else {
+				log.WarnContext(ctx, "tool failed", "tool", tool.Name(), "duration", duration, "error", result.Error)
+			}
+			return result
+		}
+	}
+}
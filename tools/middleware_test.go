@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// funcTool is a tool that runs a function, for middleware tests
+type funcTool struct {
+	mockTool
+	fn func(ctx context.Context) (interface{}, error)
+}
+
+func (f *funcTool) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	return f.fn(ctx)
+}
+
+func TestRegistryExecute_MiddlewareOrder(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(&mockTool{name: "test"})
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Executor) Executor {
+			return func(ctx context.Context, tool Tool, params json.RawMessage) *ToolResult {
+				order = append(order, name+" before")
+				result := next(ctx, tool, params)
+				order = append(order, name+" after")
+				return result
+			}
+		}
+	}
+	registry.Use(trace("first"), trace("second"))
+
+	result := registry.Execute(context.Background(), "test", nil)
+	if !result.Success || result.Data != "mock result" {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+
+	expected := []string{"first before", "second before", "second after", "first after"}
+	if strings.Join(order, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("Expected order %v, got %v", expected, order)
+	}
+}
+
+func TestRegistryExecute_MiddlewareSeesValidationFailures(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(NewReadTool())
+
+	var seen *ToolResult
+	registry.Use(func(next Executor) Executor {
+		return func(ctx context.Context, tool Tool, params json.RawMessage) *ToolResult {
+			seen = next(ctx, tool, params)
+			return seen
+		}
+	})
+
+	result := registry.Execute(context.Background(), "read", json.RawMessage(`{"path": 1}`))
+	if result.Success || seen != result {
+		t.Errorf("Expected middleware to see the validation failure, got %+v", seen)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	slow := &funcTool{mockTool: mockTool{name: "slow"}, fn: func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+	stuck := &funcTool{mockTool: mockTool{name: "stuck"}, fn: func(ctx context.Context) (interface{}, error) {
+		time.Sleep(time.Second)
+		return "late", nil
+	}}
+	fast := &funcTool{mockTool: mockTool{name: "fast"}, fn: func(ctx context.Context) (interface{}, error) {
+		return "ok", nil
+	}}
+
+	registry := NewToolRegistry()
+	registry.Register(slow)
+	registry.Register(stuck)
+	registry.Register(fast)
+	registry.Use(Timeout(20*time.Millisecond, map[string]time.Duration{"fast": 0}))
+
+	result := registry.Execute(context.Background(), "slow", nil)
+	if result.Success || !strings.Contains(result.Error, "tool slow timed out after 20ms") {
+		t.Errorf("Expected timeout error, got %+v", result)
+	}
+
+	// A tool that ignores its context does not hold up the caller
+	start := time.Now()
+	result = registry.Execute(context.Background(), "stuck", nil)
+	if result.Success || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Expected stuck tool to time out promptly, got %+v after %s", result, time.Since(start))
+	}
+
+	result = registry.Execute(context.Background(), "fast", nil)
+	if !result.Success || result.Data != "ok" {
+		t.Errorf("Expected tool without a limit to succeed, got %+v", result)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	panicky := &funcTool{mockTool: mockTool{name: "panicky"}, fn: func(ctx context.Context) (interface{}, error) {
+		panic("boom")
+	}}
+
+	registry := NewToolRegistry()
+	registry.Register(panicky)
+	registry.Use(Recovery())
+
+	result := registry.Execute(context.Background(), "panicky", nil)
+	if result.Success || result.Error != "tool panicky panicked: boom" {
+		t.Errorf("Expected panic to become an error result, got %+v", result)
+	}
+
+	// Panics inside a timeout are recovered in the timeout goroutine
+	timed := NewToolRegistry()
+	timed.Register(panicky)
+	timed.Use(Timeout(time.Second, nil))
+	if result := timed.Execute(context.Background(), "panicky", nil); result.Success || !strings.Contains(result.Error, "boom") {
+		t.Errorf("Expected panic under timeout to be recovered, got %+v", result)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	registry := NewToolRegistry()
+	registry.Register(&mockTool{name: "test"})
+	registry.Register(&funcTool{mockTool: mockTool{name: "failing"}, fn: func(ctx context.Context) (interface{}, error) {
+		return nil, context.DeadlineExceeded
+	}})
+	registry.Use(Logging(logger))
+
+	registry.Execute(context.Background(), "test", json.RawMessage(`{"a":1}`))
+	registry.Execute(context.Background(), "failing", nil)
+
+	output := buf.String()
+	for _, want := range []string{
+		`msg="tool call" tool=test params="{\"a\":1}"`,
+		`level=INFO msg="tool succeeded" tool=test duration=`,
+		`level=WARN msg="tool failed" tool=failing`,
+		`error="context deadline exceeded"`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected log output to contain %s, got:\n%s", want, output)
+		}
+	}
+}
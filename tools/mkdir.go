@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// MkdirTool implements directory creation
+type MkdirTool struct {
+	workspace *Workspace
+}
+
+// MkdirParams defines the parameters for the Mkdir tool
+type MkdirParams struct {
+	Path    string `json:"path"`
+	Parents *bool  `json:"parents,omitempty"` // Create missing parent directories; defaults to true
+	Mode    string `json:"mode,omitempty"`    // Octal permissions, such as "0755"
+	DryRun  bool   `json:"dry_run,omitempty"`
+}
+
+// MkdirResult represents the result of a mkdir operation
+type MkdirResult struct {
+	Path    string    `json:"path"`
+	DryRun  bool      `json:"dry_run,omitempty"`
+	Created []string  `json:"created"` // Directories created, outermost first
+	File    *FileInfo `json:"file,omitempty"`
+	Message string    `json:"message"`
+}
+
+// NewMkdirTool creates a new Mkdir tool instance
+func NewMkdirTool() *MkdirTool {
+	return &MkdirTool{}
+}
+
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *MkdirTool) WithWorkspace(ws *Workspace) *MkdirTool {
+	t.workspace = ws
+	return t
+}
+
+// Name returns the tool's name
+func (t *MkdirTool) Name() string {
+	return "mkdir"
+}
+
+// Description returns the tool's description
+func (t *MkdirTool) Description() string {
+	return "Create a directory and any missing parents with the given permissions; an existing directory is left as it is, and files are never replaced"
+}
+
+// Risk returns the tool's risk class
+func (t *MkdirTool) Risk() Risk {
+	return RiskMutating
+}
+
+// ChangedPaths returns the outermost directory that does not exist yet, so
+// that every directory the tool creates is covered
+func (t *MkdirTool) ChangedPaths(params json.RawMessage) []string {
+	var p MkdirParams
+	if err := json.Unmarshal(params, &p); err != nil || p.Path == "" {
+		return nil
+	}
+	missing, err := missingDirs(p.Path)
+	if err != nil || len(missing) == 0 {
+		return []string{p.Path}
+	}
+	return missing[:1]
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *MkdirTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Path of the directory to create",
+			},
+			"parents": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Create missing parent directories (default: true)",
+			},
+			"mode": map[string]interface{}{
+				"type":        "string",
+				"description": "Octal permissions for the new directory (default: '0755')",
+				"pattern":     "^0?[0-7]{3}$",
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "List the directories that would be created without creating them",
+			},
+		},
+		"required":             []string{"path"},
+		"additionalProperties": false,
+	}
+}
+
+// Validate checks if the parameters are valid
+func (t *MkdirTool) Validate(params json.RawMessage) error {
+	var p MkdirParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+
+	if _, err := parseDirMode(p.Mode); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Execute runs the tool with the given parameters
+func (t *MkdirTool) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p MkdirParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if err := t.workspace.Check(p.Path); err != nil {
+		return nil, err
+	}
+
+	mode, err := parseDirMode(p.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := missingDirs(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MkdirResult{Path: p.Path, DryRun: p.DryRun, Created: created}
+
+	if len(created) == 0 {
+		result.Created = []string{}
+		if info, err := os.Stat(p.Path); err == nil {
+			fileInfo := newFileInfo(p.Path, info)
+			result.File = &fileInfo
+		}
+		result.Message = fmt.Sprintf("Directory %s already exists", p.Path)
+		return result, nil
+	}
+
+	if len(created) > 1 && p.Parents != nil && !*p.Parents {
+		return nil, fmt.Errorf("parent directory %s does not exist; set parents to create it", created[0])
+	}
+
+	if p.DryRun {
+		result.Message = fmt.Sprintf("Would create %d director(ies)", len(created))
+		return result, nil
+	}
+
+	for i, dir := range created {
+		perm := os.FileMode(0755)
+		if i == len(created)-1 {
+			perm = mode
+		}
+		if err := os.Mkdir(dir, perm); err != nil && !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+	// Apply the mode exactly, regardless of the umask
+	if err := os.Chmod(p.Path, mode); err != nil {
+		return nil, fmt.Errorf("failed to set directory mode: %w", err)
+	}
+
+	if info, err := os.Stat(p.Path); err == nil {
+		fileInfo := newFileInfo(p.Path, info)
+		result.File = &fileInfo
+	}
+	result.Message = fmt.Sprintf("Created %d director(ies)", len(created))
+	return result, nil
+}
+
+// missingDirs returns path and those of its parents that do not exist yet,
+// outermost first. It fails if path or a parent exists but is not a directory.
+func missingDirs(path string) ([]string, error) {
+	var missing []string
+	for dir := filepath.Clean(path); ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return nil, fmt.Errorf("path %s exists and is not a directory", dir)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("cannot access path: %w", err)
+		}
+		missing = append([]string{dir}, missing...)
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	return missing, nil
+}
+
+// parseDirMode parses octal permissions such as "0755", defaulting to 0755
+func parseDirMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0755, nil
+	}
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > 0777 {
+		return 0, fmt.Errorf("invalid mode %q: use octal permissions such as '0755'", mode)
+	}
+	return os.FileMode(value), nil
+}
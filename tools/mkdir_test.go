@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMkdirTool_Name(t *testing.T) {
+	tool := NewMkdirTool()
+	if tool.Name() != "mkdir" {
+		t.Errorf("Expected name 'mkdir', got '%s'", tool.Name())
+	}
+}
+
+func TestMkdirTool_Validate(t *testing.T) {
+	tool := NewMkdirTool()
+
+	tests := []struct {
+		name    string
+		params  string
+		wantErr bool
+	}{
+		{
+			name:    "valid params",
+			params:  `{"path": "dir"}`,
+			wantErr: false,
+		},
+		{
+			name:    "valid with options",
+			params:  `{"path": "dir", "parents": false, "mode": "0700", "dry_run": true}`,
+			wantErr: false,
+		},
+		{
+			name:    "missing path",
+			params:  `{}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid mode",
+			params:  `{"path": "dir", "mode": "rwx"}`,
+			wantErr: true,
+		},
+		{
+			name:    "mode out of range",
+			params:  `{"path": "dir", "mode": "1777"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			params:  `{invalid}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tool.Validate(json.RawMessage(tt.params))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMkdirTool_Execute(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a", "b", "c")
+
+	tool := NewMkdirTool()
+	params, _ := json.Marshal(MkdirParams{Path: path, Mode: "0700"})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	mkdirResult := result.(*MkdirResult)
+	if len(mkdirResult.Created) != 3 || mkdirResult.Created[0] != filepath.Join(dir, "a") {
+		t.Errorf("Expected 3 directories created outermost first, got %v", mkdirResult.Created)
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() || info.Mode().Perm() != 0700 {
+		t.Errorf("Expected a directory with mode 0700, got %v, %v", info, err)
+	}
+
+	// Creating it again succeeds without changes
+	result, err = tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute on existing directory failed: %v", err)
+	}
+	if created := result.(*MkdirResult).Created; len(created) != 0 {
+		t.Errorf("Expected nothing to be created, got %v", created)
+	}
+}
+
+func TestMkdirTool_Execute_NoParents(t *testing.T) {
+	dir := t.TempDir()
+	parents := false
+
+	tool := NewMkdirTool()
+	params, _ := json.Marshal(MkdirParams{Path: filepath.Join(dir, "a", "b"), Parents: &parents})
+	if _, err := tool.Execute(context.Background(), params); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected a missing parent error, got %v", err)
+	}
+
+	params, _ = json.Marshal(MkdirParams{Path: filepath.Join(dir, "a"), Parents: &parents})
+	if _, err := tool.Execute(context.Background(), params); err != nil {
+		t.Errorf("Expected a single directory to be created, got %v", err)
+	}
+}
+
+func TestMkdirTool_Execute_ExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"file": "x"})
+
+	tool := NewMkdirTool()
+	params, _ := json.Marshal(MkdirParams{Path: filepath.Join(dir, "file", "sub")})
+	if _, err := tool.Execute(context.Background(), params); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("Expected a not a directory error, got %v", err)
+	}
+}
+
+func TestMkdirTool_Execute_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a", "b")
+
+	tool := NewMkdirTool()
+	params, _ := json.Marshal(MkdirParams{Path: path, DryRun: true})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if created := result.(*MkdirResult).Created; len(created) != 2 {
+		t.Errorf("Expected 2 directories to be reported, got %v", created)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be created, got %v", err)
+	}
+}
+
+func TestMkdirTool_ChangedPaths(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewMkdirTool()
+
+	paths := tool.ChangedPaths(json.RawMessage(`{"path": "` + filepath.Join(dir, "a", "b") + `"}`))
+	if len(paths) != 1 || paths[0] != filepath.Join(dir, "a") {
+		t.Errorf("Expected the outermost new directory, got %v", paths)
+	}
+}
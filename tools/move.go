@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// MoveTool implements moving and renaming files and directories
+type MoveTool struct {
+	workspace *Workspace
+}
+
+// MoveParams defines the parameters for the Move tool
+type MoveParams struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Overwrite   bool   `json:"overwrite,omitempty"`
+	DryRun      bool   `json:"dry_run,omitempty"`
+}
+
+// MoveResult represents the result of a move operation
+type MoveResult struct {
+	Source      string   `json:"source"`
+	Destination string   `json:"destination"`
+	DryRun      bool     `json:"dry_run,omitempty"`
+	Overwrote   bool     `json:"overwrote,omitempty"`
+	File        FileInfo `json:"file"` // The moved file or directory at its new path
+	Message     string   `json:"message"`
+}
+
+// NewMoveTool creates a new Move tool instance
+func NewMoveTool() *MoveTool {
+	return &MoveTool{}
+}
+
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *MoveTool) WithWorkspace(ws *Workspace) *MoveTool {
+	t.workspace = ws
+	return t
+}
+
+// Name returns the tool's name
+func (t *MoveTool) Name() string {
+	return "move"
+}
+
+// Description returns the tool's description
+func (t *MoveTool) Description() string {
+	return "Move or rename a file or directory, across file systems if needed, without overwriting unless asked; supports dry-run mode"
+}
+
+// Risk returns the tool's risk class
+func (t *MoveTool) Risk() Risk {
+	return RiskMutating
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *MoveTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"source": map[string]interface{}{
+				"type":        "string",
+				"description": "Path of the file or directory to move",
+			},
+			"destination": map[string]interface{}{
+				"type":        "string",
+				"description": "New path; parent directories are created as needed",
+			},
+			"overwrite": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Replace an existing file at the destination (default: false); directories are never replaced",
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Check the move without performing it",
+			},
+		},
+		"required":             []string{"source", "destination"},
+		"additionalProperties": false,
+	}
+}
+
+// Validate checks if the parameters are valid
+func (t *MoveTool) Validate(params json.RawMessage) error {
+	var p MoveParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.Source == "" {
+		return fmt.Errorf("source is required")
+	}
+
+	if p.Destination == "" {
+		return fmt.Errorf("destination is required")
+	}
+
+	return nil
+}
+
+// Execute runs the tool with the given parameters
+func (t *MoveTool) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p MoveParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	for _, path := range []string{p.Source, p.Destination} {
+		if err := t.workspace.Check(path); err != nil {
+			return nil, err
+		}
+	}
+	if t.workspace.IsRoot(p.Source) {
+		return nil, fmt.Errorf("path %s is a workspace root and cannot be moved", p.Source)
+	}
+
+	info, err := os.Lstat(p.Source)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access source: %w", err)
+	}
+
+	if err := checkTransfer(p.Source, p.Destination, info.IsDir(), p.Overwrite); err != nil {
+		return nil, err
+	}
+
+	existing, err := os.Lstat(p.Destination)
+	overwrote := err == nil
+	if overwrote && existing.IsDir() {
+		return nil, fmt.Errorf("destination %s is a directory and cannot be overwritten", p.Destination)
+	}
+	if overwrote && info.IsDir() {
+		return nil, fmt.Errorf("cannot replace file %s with a directory", p.Destination)
+	}
+
+	result := &MoveResult{
+		Source:      p.Source,
+		Destination: p.Destination,
+		DryRun:      p.DryRun,
+		Overwrote:   overwrote,
+		File:        newFileInfo(p.Destination, info),
+	}
+
+	if p.DryRun {
+		result.Message = fmt.Sprintf("Would move %s to %s", p.Source, p.Destination)
+		return result, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.Destination), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := movePath(ctx, p.Source, p.Destination); err != nil {
+		return nil, fmt.Errorf("failed to move %s: %w", p.Source, err)
+	}
+
+	if moved, err := os.Lstat(p.Destination); err == nil {
+		result.File = newFileInfo(p.Destination, moved)
+	}
+	result.Message = fmt.Sprintf("Moved %s to %s", p.Source, p.Destination)
+	return result, nil
+}
+
+// movePath renames src to dst, replacing a file at dst. If they are on
+// different file systems, src is copied next to dst, renamed into place and
+// then removed; a failed copy is cleaned up and leaves src in place.
+func movePath(ctx context.Context, src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	staging, err := os.MkdirTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	staged := filepath.Join(staging, filepath.Base(dst))
+	if _, err := copyTree(ctx, src, staged, copyOptions{}); err != nil {
+		return err
+	}
+	if err := os.Rename(staged, dst); err != nil {
+		return err
+	}
+	return os.RemoveAll(src)
+}
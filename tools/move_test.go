@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoveTool_Name(t *testing.T) {
+	tool := NewMoveTool()
+	if tool.Name() != "move" {
+		t.Errorf("Expected name 'move', got '%s'", tool.Name())
+	}
+}
+
+func TestMoveTool_Validate(t *testing.T) {
+	tool := NewMoveTool()
+
+	tests := []struct {
+		name    string
+		params  string
+		wantErr bool
+	}{
+		{
+			name:    "valid params",
+			params:  `{"source": "a.txt", "destination": "b.txt"}`,
+			wantErr: false,
+		},
+		{
+			name:    "valid with options",
+			params:  `{"source": "a.txt", "destination": "b.txt", "overwrite": true, "dry_run": true}`,
+			wantErr: false,
+		},
+		{
+			name:    "missing source",
+			params:  `{"destination": "b.txt"}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing destination",
+			params:  `{"source": "a.txt"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			params:  `{invalid}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tool.Validate(json.RawMessage(tt.params))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMoveTool_Execute(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"src/a.txt": "a", "src/sub/b.txt": "b"})
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "nested", "dst")
+
+	tool := NewMoveTool()
+	params, _ := json.Marshal(MoveParams{Source: src, Destination: dst})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	moveResult := result.(*MoveResult)
+	if moveResult.File.Path != dst || !moveResult.File.IsDir {
+		t.Errorf("Expected the moved directory to be reported, got %+v", moveResult.File)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("Expected the source to be gone, got %v", err)
+	}
+	if got := readFile(t, filepath.Join(dst, "sub", "b.txt")); got != "b" {
+		t.Errorf("Expected nested file to be moved, got %q", got)
+	}
+}
+
+func TestMoveTool_Execute_Overwrite(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "new", "b.txt": "old", "dir/c.txt": "c"})
+	src := filepath.Join(dir, "a.txt")
+	dst := filepath.Join(dir, "b.txt")
+
+	tool := NewMoveTool()
+	params, _ := json.Marshal(MoveParams{Source: src, Destination: dst})
+	if _, err := tool.Execute(context.Background(), params); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected an already exists error, got %v", err)
+	}
+	if got := readFile(t, src); got != "new" {
+		t.Errorf("Expected the source to be kept, got %q", got)
+	}
+
+	params, _ = json.Marshal(MoveParams{Source: src, Destination: filepath.Join(dir, "dir"), Overwrite: true})
+	if _, err := tool.Execute(context.Background(), params); err == nil || !strings.Contains(err.Error(), "is a directory") {
+		t.Errorf("Expected directories to never be overwritten, got %v", err)
+	}
+
+	params, _ = json.Marshal(MoveParams{Source: src, Destination: dst, Overwrite: true})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute with overwrite failed: %v", err)
+	}
+	if !result.(*MoveResult).Overwrote {
+		t.Error("Expected overwrote to be reported")
+	}
+	if got := readFile(t, dst); got != "new" {
+		t.Errorf("Expected the destination to be replaced, got %q", got)
+	}
+}
+
+func TestMoveTool_Execute_IntoItself(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"src/a.txt": "a"})
+	src := filepath.Join(dir, "src")
+
+	tool := NewMoveTool()
+	params, _ := json.Marshal(MoveParams{Source: src, Destination: filepath.Join(src, "sub", "src")})
+	if _, err := tool.Execute(context.Background(), params); err == nil || !strings.Contains(err.Error(), "into itself") {
+		t.Errorf("Expected an into itself error, got %v", err)
+	}
+}
+
+func TestMoveTool_Execute_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": "a"})
+	src := filepath.Join(dir, "a.txt")
+	dst := filepath.Join(dir, "b.txt")
+
+	tool := NewMoveTool()
+	params, _ := json.Marshal(MoveParams{Source: src, Destination: dst, DryRun: true})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !result.(*MoveResult).DryRun {
+		t.Error("Expected a dry run result")
+	}
+	if got := readFile(t, src); got != "a" {
+		t.Errorf("Expected the source to be kept, got %q", got)
+	}
+	if got := readFile(t, dst); got != "<missing>" {
+		t.Errorf("Expected nothing to be moved, got %q", got)
+	}
+}
+
+func TestMoveTool_Execute_WorkspaceRoot(t *testing.T) {
+	dir := t.TempDir()
+	ws, err := NewWorkspace(dir)
+	if err != nil {
+		t.Fatalf("NewWorkspace failed: %v", err)
+	}
+
+	tool := NewMoveTool().WithWorkspace(ws)
+	params, _ := json.Marshal(MoveParams{Source: dir, Destination: filepath.Join(dir, "moved")})
+	if _, err := tool.Execute(context.Background(), params); err == nil {
+		t.Error("Expected moving the workspace root to be rejected")
+	}
+}
@@ -0,0 +1,333 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// ErrBlockedByPolicy is returned, wrapped, for requests a NetworkPolicy
+// refuses
+var ErrBlockedByPolicy = errors.New("blocked by network policy")
+
+// reservedPrefixes are special-purpose ranges that net/netip has no
+// predicate for and that never hold public servers
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "This" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),   // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),     // Reserved, and broadcast
+	netip.MustParsePrefix("2001:db8::/32"),   // Documentation
+	netip.MustParsePrefix("fec0::/10"),       // Deprecated site-local
+	netip.MustParsePrefix("100::/64"),        // Discard
+	netip.MustParsePrefix("192.0.2.0/24"),    // Documentation
+	netip.MustParsePrefix("198.51.100.0/24"), // Documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // Documentation
+}
+
+// NetworkPolicy decides which URLs the network tools may request, to keep a
+// model from reaching cloud metadata endpoints, admin ports on localhost or
+// services on the local network. It allows only some schemes, matches hosts
+// against allow and deny globs, and blocks loopback, private, link-local and
+// other non-public addresses. Addresses are checked when connecting, after
+// DNS resolution, so a public name that resolves to a private address is
+// blocked too, and every redirect is checked the same way as the first
+// request. A nil *NetworkPolicy allows every request.
+type NetworkPolicy struct {
+	schemes        []string
+	allowHosts     []string
+	denyHosts      []string
+	allowLocalhost bool
+	allowPrivate   bool
+	proxy          func(*http.Request) (*url.URL, error)
+
+	transportOnce sync.Once
+	transport     *policyTransport
+}
+
+// NewNetworkPolicy creates a policy that allows http and https requests to
+// public addresses on any host, through the proxy from the environment
+func NewNetworkPolicy() *NetworkPolicy {
+	return &NetworkPolicy{
+		schemes: []string{"http", "https"},
+		proxy:   http.ProxyFromEnvironment,
+	}
+}
+
+// AllowSchemes replaces the URL schemes requests may use
+func (p *NetworkPolicy) AllowSchemes(schemes ...string) *NetworkPolicy {
+	p.schemes = nil
+	for _, scheme := range schemes {
+		p.schemes = append(p.schemes, strings.ToLower(scheme))
+	}
+	return p
+}
+
+// AllowHosts adds host globs, such as "*.example.com"; once any are given,
+// requests must be to a host that matches one. A glob matches host names
+// and IP addresses but not ports, and "*" matches across dots.
+func (p *NetworkPolicy) AllowHosts(patterns ...string) *NetworkPolicy {
+	p.allowHosts = append(p.allowHosts, lowerAll(patterns)...)
+	return p
+}
+
+// DenyHosts adds host globs that requests may never be to, even when they
+// match an allowed glob
+func (p *NetworkPolicy) DenyHosts(patterns ...string) *NetworkPolicy {
+	p.denyHosts = append(p.denyHosts, lowerAll(patterns)...)
+	return p
+}
+
+// AllowLocalhost allows loopback addresses, such as the servers tests start
+// with net/http/httptest
+func (p *NetworkPolicy) AllowLocalhost() *NetworkPolicy {
+	p.allowLocalhost = true
+	return p
+}
+
+// AllowPrivateNetworks allows every address, including loopback, private
+// and link-local ones
+func (p *NetworkPolicy) AllowPrivateNetworks() *NetworkPolicy {
+	p.allowPrivate = true
+	return p
+}
+
+// UseProxy replaces how requests choose a proxy, such as http.ProxyURL for
+// a fixed one; the default, http.ProxyFromEnvironment, uses HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY, and nil connects directly. Since a proxy
+// resolves names itself, a request is only handed to one after its host
+// has been resolved and every address checked. Connections made to the
+// proxy to send a proxied request are not checked, as it is configured by
+// the application rather than the model; direct requests to the proxy's
+// host are checked like any other. Set it before the policy is given to a
+// tool.
+func (p *NetworkPolicy) UseProxy(proxy func(*http.Request) (*url.URL, error)) *NetworkPolicy {
+	p.proxy = proxy
+	return p
+}
+
+// CheckURL returns an error wrapping ErrBlockedByPolicy if u has a scheme
+// or host the policy does not allow, or is an IP address it blocks. Host
+// names are checked again after they are resolved, when connecting.
+func (p *NetworkPolicy) CheckURL(u *url.URL) error {
+	if p == nil {
+		return nil
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if !containsString(p.schemes, scheme) {
+		return fmt.Errorf("%w: scheme %q is not allowed", ErrBlockedByPolicy, u.Scheme)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("%w: %s has no host", ErrBlockedByPolicy, u.Redacted())
+	}
+	if pattern, ok := matchHost(p.denyHosts, host); ok {
+		return fmt.Errorf("%w: host %s matches denied pattern %s", ErrBlockedByPolicy, host, pattern)
+	}
+	if len(p.allowHosts) > 0 {
+		if _, ok := matchHost(p.allowHosts, host); !ok {
+			return fmt.Errorf("%w: host %s is not in the allowed hosts", ErrBlockedByPolicy, host)
+		}
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.CheckAddr(addr)
+	}
+	return nil
+}
+
+// CheckAddr returns an error wrapping ErrBlockedByPolicy if the policy
+// blocks connections to addr
+func (p *NetworkPolicy) CheckAddr(addr netip.Addr) error {
+	if p == nil || p.allowPrivate {
+		return nil
+	}
+
+	addr = addr.Unmap()
+	if addr.IsLoopback() {
+		if p.allowLocalhost {
+			return nil
+		}
+		return fmt.Errorf("%w: %s is a loopback address", ErrBlockedByPolicy, addr)
+	}
+
+	reason := ""
+	switch {
+	case addr.IsPrivate():
+		reason = "a private address"
+	case addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast():
+		reason = "a link-local address"
+	case addr.IsUnspecified():
+		reason = "an unspecified address"
+	case addr.IsMulticast() || addr.IsInterfaceLocalMulticast():
+		reason = "a multicast address"
+	default:
+		for _, prefix := range reservedPrefixes {
+			if prefix.Contains(addr) {
+				reason = "a reserved address"
+				break
+			}
+		}
+	}
+	if reason != "" {
+		return fmt.Errorf("%w: %s is %s", ErrBlockedByPolicy, addr, reason)
+	}
+	return nil
+}
+
+// CheckRedirect checks each redirect against the policy, for use as
+// http.Client.CheckRedirect
+func (p *NetworkPolicy) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if err := p.CheckURL(req.URL); err != nil {
+		return fmt.Errorf("redirect to %s: %w", req.URL.Redacted(), err)
+	}
+	return nil
+}
+
+// Transport returns the HTTP transport for requests under the policy. It
+// refuses to connect to addresses the policy blocks, checking the address
+// being dialed, after DNS resolution, so it also covers names that resolve
+// to blocked addresses and names whose records change between lookups.
+// Requests sent through a proxy are checked before they are handed to it;
+// see UseProxy. The transport is created once per policy, so tools sharing
+// a policy share its connections. A nil policy returns the shared
+// transport, which uses the proxy from the environment.
+func (p *NetworkPolicy) Transport() http.RoundTripper {
+	if p == nil {
+		return sharedTransport
+	}
+
+	p.transportOnce.Do(func() {
+		checked := newDialer(func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: cannot parse address %s", ErrBlockedByPolicy, address)
+			}
+			return p.CheckAddr(addrPort.Addr())
+		})
+
+		p.transport = &policyTransport{
+			policy:  p,
+			direct:  newTransport(nil, checked),
+			proxied: newTransport(proxyFromContext, newDialer(nil)),
+		}
+	})
+	return p.transport
+}
+
+// proxyURLKey is the context key holding the proxy a request was checked for
+type proxyURLKey struct{}
+
+// proxyFromContext returns the proxy policyTransport chose for req
+func proxyFromContext(req *http.Request) (*url.URL, error) {
+	proxyURL, ok := req.Context().Value(proxyURLKey{}).(*url.URL)
+	if !ok {
+		return nil, fmt.Errorf("%w: no proxy chosen for %s", ErrBlockedByPolicy, req.URL.Redacted())
+	}
+	return proxyURL, nil
+}
+
+// policyTransport sends requests under a policy. Direct requests use a
+// transport that checks every address it dials. Proxied requests use one
+// that only ever dials the proxy, so the proxy's own address is exempt from
+// the checks for those requests and no others.
+type policyTransport struct {
+	policy  *NetworkPolicy
+	direct  *http.Transport
+	proxied *http.Transport
+}
+
+// RoundTrip sends req directly or through the proxy the policy chooses
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var proxyURL *url.URL
+	if t.policy.proxy != nil {
+		var err error
+		if proxyURL, err = t.policy.checkProxied(req); err != nil {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, err
+		}
+	}
+	if proxyURL == nil {
+		return t.direct.RoundTrip(req)
+	}
+
+	return t.proxied.RoundTrip(req.WithContext(context.WithValue(req.Context(), proxyURLKey{}, proxyURL)))
+}
+
+// CloseIdleConnections closes idle connections of both transports
+func (t *policyTransport) CloseIdleConnections() {
+	t.direct.CloseIdleConnections()
+	t.proxied.CloseIdleConnections()
+}
+
+// checkProxied returns the proxy for req, if any, once its URL and every
+// address its host resolves to pass the policy. The dialer only sees the
+// proxy's address, so this is the only check a proxied request gets.
+func (p *NetworkPolicy) checkProxied(req *http.Request) (*url.URL, error) {
+	proxyURL, err := p.proxy(req)
+	if err != nil || proxyURL == nil {
+		return proxyURL, err
+	}
+
+	if err := p.CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := p.checkHost(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+
+	return proxyURL, nil
+}
+
+// checkHost resolves host and checks each of its addresses
+func (p *NetworkPolicy) checkHost(ctx context.Context, host string) error {
+	if p.allowPrivate {
+		return nil
+	}
+	if _, err := netip.ParseAddr(host); err == nil {
+		return nil // Checked by CheckURL
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("%w: cannot resolve %s to check it before using the proxy: %v", ErrBlockedByPolicy, host, err)
+	}
+	for _, addr := range addrs {
+		if err := p.CheckAddr(addr); err != nil {
+			return fmt.Errorf("%w (resolved from %s)", err, host)
+		}
+	}
+	return nil
+}
+
+// matchHost returns the first pattern that host matches
+func matchHost(patterns []string, host string) (string, bool) {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, host); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// lowerAll returns the strings in lower case
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
@@ -0,0 +1,214 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// localPolicy lets the network tools reach httptest servers on loopback
+func localPolicy() *NetworkPolicy {
+	return NewNetworkPolicy().AllowLocalhost()
+}
+
+func TestNetworkPolicy_CheckURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *NetworkPolicy
+		url     string
+		wantErr bool
+	}{
+		{"public host", NewNetworkPolicy(), "https://example.com/docs", false},
+		{"public address", NewNetworkPolicy(), "http://93.184.216.34/", false},
+		{"scheme", NewNetworkPolicy(), "ftp://example.com/file", true},
+		{"file scheme", NewNetworkPolicy(), "file:///etc/passwd", true},
+		{"allowed scheme", NewNetworkPolicy().AllowSchemes("https"), "https://example.com/", false},
+		{"removed scheme", NewNetworkPolicy().AllowSchemes("https"), "http://example.com/", true},
+		{"metadata endpoint", NewNetworkPolicy(), "http://169.254.169.254/latest/meta-data/", true},
+		{"loopback", NewNetworkPolicy(), "http://127.0.0.1:8080/", true},
+		{"ipv6 loopback", NewNetworkPolicy(), "http://[::1]/", true},
+		{"mapped loopback", NewNetworkPolicy(), "http://[::ffff:127.0.0.1]/", true},
+		{"private", NewNetworkPolicy(), "http://10.1.2.3/", true},
+		{"ipv6 private", NewNetworkPolicy(), "http://[fd00::1]/", true},
+		{"carrier-grade nat", NewNetworkPolicy(), "http://100.64.0.1/", true},
+		{"unspecified", NewNetworkPolicy(), "http://0.0.0.0:8080/", true},
+		{"localhost allowed", localPolicy(), "http://127.0.0.1:8080/", false},
+		{"localhost allows only loopback", localPolicy(), "http://192.168.1.1/", true},
+		{"private allowed", NewNetworkPolicy().AllowPrivateNetworks(), "http://192.168.1.1/", false},
+		{"denied host", NewNetworkPolicy().DenyHosts("*.internal"), "https://db.corp.internal/", true},
+		{"denied host case", NewNetworkPolicy().DenyHosts("Example.com"), "https://EXAMPLE.com./", true},
+		{"allowed host", NewNetworkPolicy().AllowHosts("*.github.com", "github.com"), "https://api.github.com/", false},
+		{"allowed bare host", NewNetworkPolicy().AllowHosts("*.github.com", "github.com"), "https://github.com/", false},
+		{"not allowed host", NewNetworkPolicy().AllowHosts("*.github.com"), "https://example.com/", true},
+		{"deny beats allow", NewNetworkPolicy().AllowHosts("*.github.com").DenyHosts("gist.github.com"), "https://gist.github.com/", true},
+		{"nil policy", nil, "file:///etc/passwd", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatalf("invalid test url: %v", err)
+			}
+			err = tt.policy.CheckURL(u)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrBlockedByPolicy) {
+				t.Errorf("Expected ErrBlockedByPolicy, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFetchTool_Execute_BlocksLoopbackByDefault(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	// Both the address and a name that resolves to it are blocked, and
+	// blocked requests are not retried
+	localhost := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	for _, target := range []string{server.URL, localhost} {
+		tool := NewFetchTool()
+		params, _ := json.Marshal(FetchParams{URL: target, MaxRetries: 3})
+
+		start := time.Now()
+		_, err := tool.Execute(context.Background(), params)
+		if !errors.Is(err, ErrBlockedByPolicy) {
+			t.Errorf("%s: Expected ErrBlockedByPolicy, got %v", target, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: Expected no retries, took %v", target, elapsed)
+		}
+	}
+	if requests.Load() != 0 {
+		t.Errorf("Expected the server not to be reached, got %d requests", requests.Load())
+	}
+}
+
+func TestFetchTool_Execute_Proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	tool := NewFetchTool().WithNetworkPolicy(NewNetworkPolicy().UseProxy(http.ProxyURL(proxyURL)))
+
+	// The proxy is on loopback, but is configured rather than requested
+	result := fetch(t, tool, FetchParams{URL: "http://1.1.1.1/page"})
+	if result.Content != "via proxy" || len(proxied) != 1 || proxied[0] != "http://1.1.1.1/page" {
+		t.Errorf("Expected the request to go through the proxy, got %q after %v", result.Content, proxied)
+	}
+
+	// Names are resolved and checked before the proxy is given them
+	params, _ := json.Marshal(FetchParams{URL: "http://localhost:8080/admin"})
+	if _, err := tool.Execute(context.Background(), params); !errors.Is(err, ErrBlockedByPolicy) {
+		t.Errorf("Expected ErrBlockedByPolicy, got %v", err)
+	}
+	if len(proxied) != 1 {
+		t.Errorf("Expected the blocked request not to reach the proxy, got %v", proxied)
+	}
+}
+
+func TestFetchTool_Execute_DirectRequestToProxyHost(t *testing.T) {
+	var requests atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	// Like NO_PROXY, the proxy's own host is requested directly. A name is
+	// used so the request is checked when connecting rather than up front.
+	proxyURL, _ := url.Parse(strings.Replace(proxy.URL, "127.0.0.1", "localhost", 1))
+	tool := NewFetchTool().WithNetworkPolicy(NewNetworkPolicy().UseProxy(func(req *http.Request) (*url.URL, error) {
+		if req.URL.Host == proxyURL.Host {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}))
+
+	fetch(t, tool, FetchParams{URL: "http://1.1.1.1/page"})
+
+	// Having used the proxy does not exempt its address from direct requests
+	params, _ := json.Marshal(FetchParams{URL: proxyURL.String() + "/admin"})
+	if _, err := tool.Execute(context.Background(), params); !errors.Is(err, ErrBlockedByPolicy) {
+		t.Errorf("Expected ErrBlockedByPolicy, got %v", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected only the proxied request to reach the proxy, got %d requests", requests.Load())
+	}
+}
+
+func TestFetchTool_Execute_BlocksRedirects(t *testing.T) {
+	var reached atomic.Int32
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached.Add(1)
+	}))
+	defer target.Close()
+
+	localhost := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/metadata":
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+		case "/scheme":
+			http.Redirect(w, r, "ftp://example.com/file", http.StatusFound)
+		default:
+			http.Redirect(w, r, localhost, http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	tool := NewFetchTool().WithNetworkPolicy(localPolicy().DenyHosts("localhost"))
+	for _, path := range []string{"/metadata", "/scheme", "/denied"} {
+		params, _ := json.Marshal(FetchParams{URL: server.URL + path})
+		_, err := tool.Execute(context.Background(), params)
+		if !errors.Is(err, ErrBlockedByPolicy) || !strings.Contains(err.Error(), "redirect") {
+			t.Errorf("%s: Expected a blocked redirect, got %v", path, err)
+		}
+	}
+	if reached.Load() != 0 {
+		t.Errorf("Expected the redirect target not to be reached, got %d requests", reached.Load())
+	}
+}
+
+func TestDownloadTool_Execute_BlocksLoopbackByDefault(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "file")
+	params, _ := json.Marshal(DownloadParams{URL: server.URL, OutputPath: output})
+
+	if _, err := NewDownloadTool().Execute(context.Background(), params); !errors.Is(err, ErrBlockedByPolicy) {
+		t.Errorf("Expected ErrBlockedByPolicy, got %v", err)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("Expected the server not to be reached, got %d requests", requests.Load())
+	}
+
+	if _, err := NewDownloadTool().WithNetworkPolicy(localPolicy()).Execute(context.Background(), params); err != nil {
+		t.Errorf("Expected the download to be allowed with localhost opted in, got %v", err)
+	}
+	if got := readFile(t, output); got != "data" {
+		t.Errorf("Expected content 'data', got %q", got)
+	}
+}
@@ -0,0 +1,564 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PatchTool implements applying unified diffs
+type PatchTool struct {
+	workspace *Workspace
+}
+
+// PatchParams defines the parameters for the Patch tool
+type PatchParams struct {
+	Patch      string `json:"patch"`
+	WorkingDir string `json:"working_dir,omitempty"` // Directory paths in the patch are relative to
+	Strip      *int   `json:"strip,omitempty"`       // Leading path components to remove, like patch -p
+	Fuzz       int    `json:"fuzz,omitempty"`        // Context lines that may be ignored at each end of a hunk
+	DryRun     bool   `json:"dry_run,omitempty"`
+	Partial    bool   `json:"partial,omitempty"` // Apply the hunks that match even if others fail
+}
+
+// PatchResult represents the result of applying a patch
+type PatchResult struct {
+	Files        []PatchFileResult `json:"files"`
+	Applied      bool              `json:"applied"` // Whether any file was changed
+	DryRun       bool              `json:"dry_run,omitempty"`
+	HunksApplied int               `json:"hunks_applied"`
+	HunksFailed  int               `json:"hunks_failed"`
+	Message      string            `json:"message"`
+}
+
+// PatchFileResult reports how the patch for one file applied
+type PatchFileResult struct {
+	Path      string            `json:"path"`
+	OldPath   string            `json:"old_path,omitempty"` // Set for renames
+	Operation string            `json:"operation"`          // "create", "modify", "delete", "rename"
+	Hunks     []PatchHunkResult `json:"hunks,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// PatchHunkResult reports how one hunk applied
+type PatchHunkResult struct {
+	Hunk    int    `json:"hunk"` // 1-based index within the file
+	Header  string `json:"header"`
+	Applied bool   `json:"applied"`
+	Line    int    `json:"line,omitempty"`   // Line of the original file where the hunk applied
+	Offset  int    `json:"offset,omitempty"` // Lines between where the hunk said it applies and where it did
+	Fuzz    int    `json:"fuzz,omitempty"`   // Context lines ignored at each end to make the hunk match
+	Error   string `json:"error,omitempty"`
+}
+
+// NewPatchTool creates a new Patch tool instance
+func NewPatchTool() *PatchTool {
+	return &PatchTool{}
+}
+
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *PatchTool) WithWorkspace(ws *Workspace) *PatchTool {
+	t.workspace = ws
+	return t
+}
+
+// Name returns the tool's name
+func (t *PatchTool) Name() string {
+	return "patch"
+}
+
+// Description returns the tool's description
+func (t *PatchTool) Description() string {
+	return "Apply a unified diff to one or more files, including creating, deleting and renaming files, with per-hunk results, fuzzy matching and a dry-run mode"
+}
+
+// Risk returns the tool's risk class
+func (t *PatchTool) Risk() Risk {
+	return RiskMutating
+}
+
+// ChangedPaths returns the files named in the patch, resolved against the
+// working directory
+func (t *PatchTool) ChangedPaths(params json.RawMessage) []string {
+	var p PatchParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+	patches, err := t.parse(p)
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, fp := range patches {
+		for _, path := range []string{fp.OldPath, fp.NewPath} {
+			if path != "" {
+				paths = append(paths, t.resolve(p.WorkingDir, path))
+			}
+		}
+	}
+	return paths
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *PatchTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"patch": map[string]interface{}{
+				"type":        "string",
+				"description": "Unified diff to apply, such as the output of 'diff -u' or 'git diff'",
+				"minLength":   1,
+			},
+			"working_dir": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory that paths in the patch are relative to (default: current directory)",
+			},
+			"strip": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of leading path components to remove from file names, like patch -p (default: remove git's a/ and b/ prefixes)",
+				"minimum":     0,
+			},
+			"fuzz": map[string]interface{}{
+				"type":        "integer",
+				"description": "Number of context lines that may be ignored at the start and end of a hunk when it does not match exactly (default: 0)",
+				"minimum":     0,
+				"maximum":     3,
+			},
+			"dry_run": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Report how the patch would apply without changing any files",
+			},
+			"partial": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Apply the hunks that match even if others fail; by default no file is changed unless every hunk applies",
+			},
+		},
+		"required":             []string{"patch"},
+		"additionalProperties": false,
+	}
+}
+
+// Validate checks if the parameters are valid
+func (t *PatchTool) Validate(params json.RawMessage) error {
+	var p PatchParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.Patch == "" {
+		return fmt.Errorf("patch is required")
+	}
+
+	if p.Strip != nil && *p.Strip < 0 {
+		return fmt.Errorf("strip must be non-negative")
+	}
+
+	if p.Fuzz < 0 || p.Fuzz > 3 {
+		return fmt.Errorf("fuzz must be between 0 and 3")
+	}
+
+	if _, err := t.parse(p); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parse parses the patch in p
+func (t *PatchTool) parse(p PatchParams) ([]FilePatch, error) {
+	strip := -1
+	if p.Strip != nil {
+		strip = *p.Strip
+	}
+
+	patches, err := ParsePatch(p.Patch, strip)
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("invalid patch: no file changes found")
+	}
+	return patches, nil
+}
+
+// patchedFile is the outcome of patching one file, before anything is written
+type patchedFile struct {
+	result  *PatchFileResult
+	path    string // Path to write, "" to only remove
+	remove  string // Path to remove, for deletions and renames
+	content string
+	perm    os.FileMode
+	failed  int // Number of hunks that failed
+}
+
+// Execute runs the tool with the given parameters
+func (t *PatchTool) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p PatchParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	patches, err := t.parse(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work out every file's new content before touching the disk. Contents are
+	// shared between file patches so a file can be patched more than once.
+	contents := make(map[string]*string)
+	var files []*patchedFile
+	var failures []string
+	changed := 0
+	result := &PatchResult{DryRun: p.DryRun}
+
+	for _, fp := range patches {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		file := t.patchFile(fp, p, contents)
+		files = append(files, file)
+		result.Files = append(result.Files, *file.result)
+
+		if file.result.Error != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", file.result.Path, file.result.Error))
+		}
+		for _, hunk := range file.result.Hunks {
+			if hunk.Applied {
+				result.HunksApplied++
+				continue
+			}
+			result.HunksFailed++
+			if file.result.Error == "" {
+				failures = append(failures, fmt.Sprintf("%s: hunk %d: %s", file.result.Path, hunk.Hunk, hunk.Error))
+			}
+		}
+		if file.changes() {
+			changed++
+		}
+	}
+
+	switch {
+	case len(failures) > 0 && !p.Partial:
+		result.Message = "No files were changed: " + strings.Join(failures, "; ")
+		return result, fmt.Errorf("patch failed, no files were changed: %s", strings.Join(failures, "; "))
+	case p.DryRun:
+		result.Message = fmt.Sprintf("Would apply %d hunk(s) to %d file(s)", result.HunksApplied, changed)
+		if len(failures) > 0 {
+			result.Message += "; would fail: " + strings.Join(failures, "; ")
+		}
+		return result, nil
+	}
+
+	if err := t.write(files); err != nil {
+		return result, err
+	}
+
+	result.Applied = changed > 0
+	result.Message = fmt.Sprintf("Applied %d hunk(s) to %d file(s)", result.HunksApplied, changed)
+	if len(failures) > 0 {
+		result.Message += "; failed: " + strings.Join(failures, "; ")
+	}
+	return result, nil
+}
+
+// patchFile applies one file's hunks in memory
+func (t *PatchTool) patchFile(fp FilePatch, p PatchParams, contents map[string]*string) *patchedFile {
+	file := &patchedFile{result: &PatchFileResult{}}
+	res := file.result
+
+	switch {
+	case fp.OldPath == "":
+		res.Operation, res.Path = "create", fp.NewPath
+	case fp.NewPath == "":
+		res.Operation, res.Path = "delete", fp.OldPath
+	case fp.OldPath != fp.NewPath:
+		res.Operation, res.Path, res.OldPath = "rename", fp.NewPath, fp.OldPath
+	default:
+		res.Operation, res.Path = "modify", fp.NewPath
+	}
+
+	fail := func(format string, args ...interface{}) *patchedFile {
+		res.Error = fmt.Sprintf(format, args...)
+		res.Hunks = nil
+		for i, hunk := range fp.Hunks {
+			res.Hunks = append(res.Hunks, PatchHunkResult{Hunk: i + 1, Header: hunk.Header(), Error: "file could not be patched"})
+		}
+		file.failed = len(fp.Hunks)
+		return file
+	}
+
+	if fp.Binary {
+		return fail("binary patches are not supported")
+	}
+
+	source := t.resolve(p.WorkingDir, fp.OldPath)
+	target := t.resolve(p.WorkingDir, fp.NewPath)
+	for _, path := range []string{source, target} {
+		if path == "" {
+			continue
+		}
+		if err := t.workspace.Check(path); err != nil {
+			return fail("%v", err)
+		}
+	}
+
+	// Read the current content
+	content := ""
+	file.perm = 0644
+	if fp.Mode != 0 {
+		file.perm = fp.Mode
+	}
+	if source != "" {
+		current, ok := contents[source]
+		info, err := os.Stat(source)
+		switch {
+		case ok && current == nil:
+			return fail("%s was already deleted or renamed by this patch", fp.OldPath)
+		case ok:
+			content = *current
+		case err != nil:
+			return fail("cannot access %s: %v", fp.OldPath, err)
+		case info.IsDir():
+			return fail("%s is a directory", fp.OldPath)
+		default:
+			data, err := os.ReadFile(source)
+			if err != nil {
+				return fail("failed to read %s: %v", fp.OldPath, err)
+			}
+			content = string(data)
+		}
+		if err == nil {
+			file.perm = info.Mode().Perm()
+		}
+	}
+	if target != "" && target != source {
+		current, ok := contents[target]
+		_, err := os.Lstat(target)
+		if (ok && current != nil) || (!ok && err == nil) {
+			return fail("%s already exists", fp.NewPath)
+		}
+	}
+
+	patched, hunks := applyHunks(content, fp.Hunks, p.Fuzz)
+	res.Hunks = hunks
+	for _, hunk := range hunks {
+		if !hunk.Applied {
+			file.failed++
+		}
+	}
+
+	if fp.NewPath == "" && file.failed == 0 && patched != "" {
+		return fail("%s has content the patch does not remove", fp.OldPath)
+	}
+
+	file.content = patched
+	file.path = target
+	if source != "" && source != target {
+		file.remove = source
+	}
+
+	// Record the outcome so later file patches see it
+	if file.changes() {
+		if file.remove != "" {
+			contents[file.remove] = nil
+		}
+		if target != "" {
+			contents[target] = &file.content
+		}
+	}
+	return file
+}
+
+// resolve returns a patch path relative to the working directory
+func (t *PatchTool) resolve(workingDir, path string) string {
+	if path == "" || filepath.IsAbs(path) || workingDir == "" {
+		return path
+	}
+	return filepath.Join(workingDir, path)
+}
+
+// changes reports whether the file is changed when the patch is written.
+// Creations, deletions and renames only happen if all their hunks apply.
+func (f *patchedFile) changes() bool {
+	if f.result.Error != "" {
+		return false
+	}
+	if f.result.Operation == "modify" {
+		return len(f.result.Hunks) > f.failed
+	}
+	return f.failed == 0
+}
+
+// write stages every changed file in a temporary file, then renames them
+// into place and removes deleted files. Nothing is changed if staging fails.
+func (t *PatchTool) write(files []*patchedFile) error {
+	type staged struct {
+		tmp, path string
+	}
+	var stagedFiles []staged
+	var removals []string
+
+	cleanup := func() {
+		for _, s := range stagedFiles {
+			os.Remove(s.tmp)
+		}
+	}
+
+	for _, file := range files {
+		if !file.changes() {
+			continue
+		}
+
+		if file.path != "" {
+			if err := os.MkdirAll(filepath.Dir(file.path), 0755); err != nil {
+				cleanup()
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			tmp, err := stageFile(file.path, []byte(file.content), file.perm)
+			if err != nil {
+				cleanup()
+				return fmt.Errorf("failed to stage %s: %w", file.path, err)
+			}
+			stagedFiles = append(stagedFiles, staged{tmp, file.path})
+		}
+		if file.remove != "" {
+			removals = append(removals, file.remove)
+		}
+	}
+
+	for i, s := range stagedFiles {
+		if err := commitFile(s.tmp, s.path); err != nil {
+			for _, rest := range stagedFiles[i+1:] {
+				os.Remove(rest.tmp)
+			}
+			return fmt.Errorf("failed to write %s: %w", s.path, err)
+		}
+	}
+	for _, path := range removals {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// applyHunks applies hunks to content in order, returning the new content and
+// a report for each hunk. Hunks that do not match are skipped.
+func applyHunks(content string, hunks []Hunk, fuzz int) (string, []PatchHunkResult) {
+	lines := splitLines(content)
+	crlf := lineEnding(content) == "\r\n"
+
+	var out []string
+	var results []PatchHunkResult
+	pos := 0    // Next line of the original content to copy
+	offset := 0 // How far earlier hunks were from where they said they apply
+
+	for i, hunk := range hunks {
+		res := PatchHunkResult{Hunk: i + 1, Header: hunk.Header()}
+		oldLines, newLines, lead, trail := hunkSides(hunk, crlf)
+
+		found := -1
+		for f := 0; f <= fuzz && found < 0; f++ {
+			dropLead, dropTrail := min(f, lead), min(f, trail)
+			if f > 0 && dropLead == min(f-1, lead) && dropTrail == min(f-1, trail) {
+				continue // No more context to drop
+			}
+
+			pattern := oldLines[dropLead : len(oldLines)-dropTrail]
+			expected := hunk.OldStart - 1 + offset + dropLead
+			if hunk.OldLines == 0 {
+				expected = hunk.OldStart + offset
+			}
+
+			at := findLines(lines, pattern, expected, pos)
+			if at < 0 {
+				continue
+			}
+
+			found = at
+			out = append(out, lines[pos:at]...)
+			out = append(out, newLines[dropLead:len(newLines)-dropTrail]...)
+			pos = at + len(pattern)
+			offset += at - expected
+
+			res.Applied = true
+			res.Line = at - dropLead + 1
+			res.Offset = at - expected
+			res.Fuzz = f
+		}
+
+		if found < 0 {
+			res.Error = fmt.Sprintf("hunk does not match the file near line %d", max(hunk.OldStart+offset, 1))
+		}
+		results = append(results, res)
+	}
+
+	out = append(out, lines[pos:]...)
+	return strings.Join(out, ""), results
+}
+
+// hunkSides returns the lines a hunk expects and the lines it leaves, and how
+// many context lines it has at its start and end. Lines are converted to
+// \r\n endings for files that use them.
+func hunkSides(hunk Hunk, crlf bool) (oldLines, newLines []string, lead, trail int) {
+	for _, line := range hunk.Lines {
+		text := line.Text
+		if crlf && strings.HasSuffix(text, "\n") && !strings.HasSuffix(text, "\r\n") {
+			text = strings.TrimSuffix(text, "\n") + "\r\n"
+		}
+		if line.Kind != '+' {
+			oldLines = append(oldLines, text)
+		}
+		if line.Kind != '-' {
+			newLines = append(newLines, text)
+		}
+	}
+
+	for lead < len(hunk.Lines) && hunk.Lines[lead].Kind == ' ' {
+		lead++
+	}
+	for trail < len(hunk.Lines)-lead && hunk.Lines[len(hunk.Lines)-1-trail].Kind == ' ' {
+		trail++
+	}
+	return oldLines, newLines, lead, trail
+}
+
+// findLines returns the index of pattern in lines at or after from, searching
+// outwards from expected, or -1 if it is not found
+func findLines(lines, pattern []string, expected, from int) int {
+	last := len(lines) - len(pattern)
+	if last < from {
+		return -1
+	}
+	expected = min(max(expected, from), last)
+
+	for distance := 0; expected-distance >= from || expected+distance <= last; distance++ {
+		for _, at := range []int{expected - distance, expected + distance} {
+			if at < from || at > last {
+				continue
+			}
+			if linesEqual(lines[at:at+len(pattern)], pattern) {
+				return at
+			}
+			if distance == 0 {
+				break
+			}
+		}
+	}
+	return -1
+}
+
+// linesEqual reports whether two slices of lines are equal
+func linesEqual(a, b []string) bool {
+	for i := range b {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
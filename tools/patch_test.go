@@ -0,0 +1,319 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPatchTool_Name(t *testing.T) {
+	tool := NewPatchTool()
+	if tool.Name() != "patch" {
+		t.Errorf("Expected name 'patch', got '%s'", tool.Name())
+	}
+}
+
+func TestPatchTool_Validate(t *testing.T) {
+	tool := NewPatchTool()
+
+	tests := []struct {
+		name    string
+		params  string
+		wantErr bool
+	}{
+		{"valid params", `{"patch": "--- a\n+++ a\n@@ -1 +1 @@\n-a\n+b\n"}`, false},
+		{"missing patch", `{}`, true},
+		{"no file changes", `{"patch": "just some text"}`, true},
+		{"malformed patch", `{"patch": "--- a\n+++ a\n@@ -1,2 +1,2 @@\n a\n"}`, true},
+		{"fuzz too large", `{"patch": "--- a\n+++ a\n@@ -1 +1 @@\n-a\n+b\n", "fuzz": 4}`, true},
+		{"negative strip", `{"patch": "--- a\n+++ a\n@@ -1 +1 @@\n-a\n+b\n", "strip": -1}`, true},
+		{"invalid json", `{invalid}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tool.Validate(json.RawMessage(tt.params))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// writeFiles creates files under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFile returns a file's content, or "<missing>" if it does not exist
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "<missing>"
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// runPatch applies a patch in dir
+func runPatch(t *testing.T, dir, patch string, extra map[string]interface{}) (*PatchResult, error) {
+	t.Helper()
+	params := map[string]interface{}{"patch": patch, "working_dir": dir}
+	for k, v := range extra {
+		params[k] = v
+	}
+	paramsJSON, _ := json.Marshal(params)
+
+	result, err := NewPatchTool().Execute(context.Background(), paramsJSON)
+	if result == nil {
+		return nil, err
+	}
+	return result.(*PatchResult), err
+}
+
+const multiFilePatch = `diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-// old
++// new
+ func main() {}
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/docs/old.md b/docs/new.md
+similarity index 80%
+rename from docs/old.md
+rename to docs/new.md
+--- a/docs/old.md
++++ b/docs/new.md
+@@ -1,2 +1,2 @@
+ # Title
+-old text
++new text
+`
+
+func TestPatchTool_Execute_MultiFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":     "package main\n// old\nfunc main() {}\n",
+		"gone.txt":    "bye\n",
+		"docs/old.md": "# Title\nold text\n",
+	})
+
+	result, err := runPatch(t, dir, multiFilePatch, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if !result.Applied || result.HunksApplied != 4 || result.HunksFailed != 0 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	operations := []string{"modify", "create", "delete", "rename"}
+	for i, file := range result.Files {
+		if file.Operation != operations[i] {
+			t.Errorf("File %d: expected operation %s, got %s", i, operations[i], file.Operation)
+		}
+	}
+
+	want := map[string]string{
+		"main.go":     "package main\n// new\nfunc main() {}\n",
+		"new.txt":     "hello\nworld\n",
+		"gone.txt":    "<missing>",
+		"docs/old.md": "<missing>",
+		"docs/new.md": "# Title\nnew text\n",
+	}
+	for name, content := range want {
+		if got := readFile(t, filepath.Join(dir, name)); got != content {
+			t.Errorf("%s: expected %q, got %q", name, content, got)
+		}
+	}
+}
+
+func TestPatchTool_Execute_AllOrNothing(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":     "package main\n// changed locally\nfunc main() {}\n",
+		"gone.txt":    "bye\n",
+		"docs/old.md": "# Title\nold text\n",
+	})
+
+	result, err := runPatch(t, dir, multiFilePatch, nil)
+	if err == nil {
+		t.Fatal("Expected error when a hunk fails")
+	}
+	if !strings.Contains(err.Error(), "main.go: hunk 1: hunk does not match the file near line 1") {
+		t.Errorf("Expected error to name the failing hunk, got %v", err)
+	}
+	if result == nil || result.Applied || result.HunksFailed != 1 || result.HunksApplied != 3 {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	if hunk := result.Files[0].Hunks[0]; hunk.Applied || hunk.Error == "" {
+		t.Errorf("Expected a failed hunk report, got %+v", hunk)
+	}
+
+	// Nothing changed
+	if got := readFile(t, filepath.Join(dir, "new.txt")); got != "<missing>" {
+		t.Error("Expected new.txt not to be created")
+	}
+	if got := readFile(t, filepath.Join(dir, "gone.txt")); got != "bye\n" {
+		t.Error("Expected gone.txt to be kept")
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("Temporary file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestPatchTool_Execute_Partial(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":     "package main\n// changed locally\nfunc main() {}\n",
+		"gone.txt":    "bye\n",
+		"docs/old.md": "# Title\nold text\n",
+	})
+
+	result, err := runPatch(t, dir, multiFilePatch, map[string]interface{}{"partial": true})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if !result.Applied || result.HunksFailed != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if got := readFile(t, filepath.Join(dir, "new.txt")); got != "hello\nworld\n" {
+		t.Errorf("Expected new.txt to be created, got %q", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "main.go")); got != "package main\n// changed locally\nfunc main() {}\n" {
+		t.Errorf("Expected main.go to be unchanged, got %q", got)
+	}
+}
+
+func TestPatchTool_Execute_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":     "package main\n// old\nfunc main() {}\n",
+		"gone.txt":    "bye\n",
+		"docs/old.md": "# Title\nold text\n",
+	})
+
+	result, err := runPatch(t, dir, multiFilePatch, map[string]interface{}{"dry_run": true})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Applied || !result.DryRun || result.HunksApplied != 4 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if got := readFile(t, filepath.Join(dir, "main.go")); got != "package main\n// old\nfunc main() {}\n" {
+		t.Errorf("Expected main.go to be unchanged, got %q", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "new.txt")); got != "<missing>" {
+		t.Error("Expected new.txt not to be created")
+	}
+}
+
+func TestPatchTool_Execute_OffsetAndFuzz(t *testing.T) {
+	dir := t.TempDir()
+	var lines []string
+	for _, line := range []string{"header", "added", "lines", "a", "b", "c", "d", "e", "f", "g"} {
+		lines = append(lines, line+"\n")
+	}
+	writeFiles(t, dir, map[string]string{"f.txt": strings.Join(lines, "")})
+
+	// The hunk says line 1 but the file has three extra lines first
+	patch := "--- f.txt\n+++ f.txt\n@@ -1,7 +1,7 @@\n a\n b\n c\n-d\n+D\n e\n f\n g\n"
+	result, err := runPatch(t, dir, patch, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	hunk := result.Files[0].Hunks[0]
+	if hunk.Line != 4 || hunk.Offset != 3 || hunk.Fuzz != 0 {
+		t.Errorf("Expected the hunk to apply at line 4 with offset 3, got %+v", hunk)
+	}
+
+	// Mismatched outer context only applies with fuzz
+	patch = "--- f.txt\n+++ f.txt\n@@ -4,7 +4,7 @@\n x\n b\n c\n-D\n+d\n e\n f\n y\n"
+	if _, err := runPatch(t, dir, patch, nil); err == nil {
+		t.Fatal("Expected the hunk to fail without fuzz")
+	}
+	result, err = runPatch(t, dir, patch, map[string]interface{}{"fuzz": 1})
+	if err != nil {
+		t.Fatalf("Execute with fuzz failed: %v", err)
+	}
+	if hunk := result.Files[0].Hunks[0]; hunk.Fuzz != 1 {
+		t.Errorf("Expected fuzz 1, got %+v", hunk)
+	}
+	if got := readFile(t, filepath.Join(dir, "f.txt")); got != strings.Join(lines, "") {
+		t.Errorf("Expected the file to be restored, got %q", got)
+	}
+}
+
+func TestPatchTool_Execute_KeepsModeAndLineEndings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.bat")
+	os.WriteFile(path, []byte("echo one\r\necho two\r\n"), 0755)
+	os.Chmod(path, 0750)
+
+	patch := "--- run.bat\n+++ run.bat\n@@ -1,2 +1,2 @@\n echo one\n-echo two\n+echo three\n"
+	if _, err := runPatch(t, dir, patch, nil); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if got := readFile(t, path); got != "echo one\r\necho three\r\n" {
+		t.Errorf("Expected CRLF line endings to be kept, got %q", got)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("Expected mode 0750, got %o", info.Mode().Perm())
+	}
+}
+
+func TestPatchTool_Execute_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"exists.txt": "x\n", "gone.txt": "bye\nextra\n"})
+
+	tests := []struct {
+		name    string
+		patch   string
+		wantErr string
+	}{
+		{"create existing", "--- /dev/null\n+++ exists.txt\n@@ -0,0 +1 @@\n+y\n", "exists.txt already exists"},
+		{"modify missing", "--- missing.txt\n+++ missing.txt\n@@ -1 +1 @@\n-a\n+b\n", "cannot access missing.txt"},
+		{"delete leaves content", "--- gone.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-bye\n", "has content the patch does not remove"},
+		{"binary", "diff --git a/img.png b/img.png\nGIT binary patch\nliteral 0\n", "binary patches are not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runPatch(t, dir, tt.patch, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -10,7 +10,9 @@ import (
 )
 
 // ReadTool implements file reading functionality
-type ReadTool struct{}
+type ReadTool struct {
+	workspace *Workspace
+}
 
 // ReadParams defines the parameters for the Read tool
 type ReadParams struct {
@@ -34,6 +36,12 @@ func NewReadTool() *ReadTool {
 	return &ReadTool{}
 }
 
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *ReadTool) WithWorkspace(ws *Workspace) *ReadTool {
+	t.workspace = ws
+	return t
+}
+
 // Name returns the tool's name
 func (t *ReadTool) Name() string {
 	return "read"
@@ -44,6 +52,11 @@ func (t *ReadTool) Description() string {
 	return "Read files from the local filesystem with support for text and binary formats, line range selection, and encoding detection"
 }
 
+// Risk returns the tool's risk class
+func (t *ReadTool) Risk() Risk {
+	return RiskReadOnly
+}
+
 // Schema returns the JSON schema for the tool's parameters
 func (t *ReadTool) Schema() map[string]interface{} {
 	return map[string]interface{}{
@@ -69,7 +82,8 @@ func (t *ReadTool) Schema() map[string]interface{} {
 				"minimum":     0,
 			},
 		},
-		"required": []string{"path"},
+		"required":             []string{"path"},
+		"additionalProperties": false,
 	}
 }
 
@@ -111,6 +125,10 @@ func (t *ReadTool) Execute(ctx context.Context, params json.RawMessage) (interfa
 		p.Format = "auto"
 	}
 
+	if err := t.workspace.Check(p.Path); err != nil {
+		return nil, err
+	}
+
 	// Check if file exists
 	info, err := os.Stat(p.Path)
 	if err != nil {
@@ -1,14 +1,17 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 )
 
 // ToolRegistry manages a collection of tools
 type ToolRegistry struct {
-	tools map[string]Tool
-	mu    sync.RWMutex
+	tools      map[string]Tool
+	middleware []Middleware
+	mu         sync.RWMutex
 }
 
 // NewToolRegistry creates a new tool registry
@@ -90,3 +93,52 @@ func (r *ToolRegistry) Count() int {
 
 	return len(r.tools)
 }
+
+// Use adds middleware to the chain run by Execute. Middleware runs in the
+// order it was added, the first being the outermost. Validation always runs
+// last, just before the tool.
+func (r *ToolRegistry) Use(middleware ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Validate checks params against the named tool's schema, then runs the
+// tool's own Validate for checks a schema cannot express
+func (r *ToolRegistry) Validate(name string, params json.RawMessage) error {
+	tool, err := r.Get(name)
+	if err != nil {
+		return err
+	}
+
+	return validateTool(tool, params)
+}
+
+// Execute runs the named tool through the middleware chain, validating the
+// params first. Empty params are treated as an empty object.
+func (r *ToolRegistry) Execute(ctx context.Context, name string, params json.RawMessage) *ToolResult {
+	tool, err := r.Get(name)
+	if err != nil {
+		return &ToolResult{Success: false, Error: err.Error()}
+	}
+
+	if len(params) == 0 {
+		params = json.RawMessage(`{}`)
+	}
+
+	r.mu.RLock()
+	middleware := append(append([]Middleware(nil), r.middleware...), Validation())
+	r.mu.RUnlock()
+
+	return Chain(runTool, middleware...)(ctx, tool, params)
+}
+
+// validateTool checks params against the tool's schema and its Validate
+func validateTool(tool Tool, params json.RawMessage) error {
+	if err := ValidateParams(tool.Schema(), params); err != nil {
+		return fmt.Errorf("invalid parameters for %s: %w", tool.Name(), err)
+	}
+
+	return tool.Validate(params)
+}
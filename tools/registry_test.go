@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -153,3 +154,32 @@ func TestConcurrency(t *testing.T) {
 		t.Errorf("Expected 10 tools, got %d", registry.Count())
 	}
 }
+
+func TestRegistryExecute(t *testing.T) {
+	registry := NewToolRegistry()
+	registry.Register(NewShellTool())
+
+	result := registry.Execute(context.Background(), "missing", nil)
+	if result.Success || !strings.Contains(result.Error, "not found") {
+		t.Errorf("Expected not found error, got %+v", result)
+	}
+
+	// The schema is checked before the tool's own Validate
+	result = registry.Execute(context.Background(), "shell", json.RawMessage(`{"command": "echo", "timeout": 0}`))
+	if result.Success || result.Error != "invalid parameters for shell: timeout: must be >= 1, got 0" {
+		t.Errorf("Expected schema error, got %+v", result)
+	}
+
+	// The tool's Validate still runs for checks the schema cannot express
+	if err := registry.Validate("shell", json.RawMessage(`{"command": "mkfs /dev/sda"}`)); err == nil {
+		t.Error("Expected dangerous command to be rejected")
+	}
+
+	result = registry.Execute(context.Background(), "shell", json.RawMessage(`{"command": "echo", "args": ["hi"]}`))
+	if !result.Success {
+		t.Fatalf("Expected success, got %s", result.Error)
+	}
+	if output := result.Data.(*ShellResult).Stdout; strings.TrimSpace(output) != "hi" {
+		t.Errorf("Expected output 'hi', got %q", output)
+	}
+}
@@ -0,0 +1,28 @@
+package tools
+
+// Risk classifies what a tool can do, for deciding whether a call needs the
+// user's approval
+type Risk string
+
+const (
+	RiskReadOnly    Risk = "read_only"   // Only reads local state
+	RiskMutating    Risk = "mutating"    // Creates or modifies files
+	RiskDestructive Risk = "destructive" // Deletes data or runs arbitrary commands
+	RiskNetwork     Risk = "network"     // Talks to other hosts
+)
+
+// RiskClassifier is implemented by tools that declare their risk class
+type RiskClassifier interface {
+	Risk() Risk
+}
+
+// RiskOf returns the risk class a tool declares. Tools that do not implement
+// RiskClassifier are treated as mutating, so they are not run unchecked.
+func RiskOf(tool Tool) Risk {
+	if classifier, ok := tool.(RiskClassifier); ok {
+		if risk := classifier.Risk(); risk != "" {
+			return risk
+		}
+	}
+	return RiskMutating
+}
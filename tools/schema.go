@@ -0,0 +1,470 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// schemaTypes are the JSON Schema primitive types
+var schemaTypes = map[string]bool{
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"object":  true,
+	"array":   true,
+	"null":    true,
+}
+
+// CheckSchema reports whether schema is a valid JSON Schema for tool
+// parameters. The root must describe an object, as model APIs require.
+// Errors name the location of the problem, such as /properties/path/type.
+func CheckSchema(schema map[string]interface{}) error {
+	node, err := normalizeSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	if node["type"] != "object" {
+		return fmt.Errorf("/type: tool schema must have type \"object\"")
+	}
+
+	return checkSchemaNode("", node)
+}
+
+// normalizeSchema converts a schema built from Go values, such as []string
+// for required, into the generic form produced by decoding JSON
+func normalizeSchema(schema map[string]interface{}) (map[string]interface{}, error) {
+	if schema == nil {
+		return nil, fmt.Errorf("schema is nil")
+	}
+
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema is not JSON encodable: %w", err)
+	}
+
+	var node map[string]interface{}
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("schema is not a JSON object: %w", err)
+	}
+	return node, nil
+}
+
+// checkSchemaNode validates the keywords of a single schema object at path
+func checkSchemaNode(path string, node map[string]interface{}) error {
+	types, err := schemaNodeTypes(path, node)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range []string{"title", "description", "format"} {
+		if value, ok := node[key]; ok {
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("%s/%s: must be a string", path, key)
+			}
+		}
+	}
+
+	if value, ok := node["properties"]; ok {
+		properties, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s/properties: must be an object", path)
+		}
+		for _, name := range sortedKeys(properties) {
+			child, ok := properties[name].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s/properties/%s: must be a schema object", path, name)
+			}
+			if err := checkSchemaNode(path+"/properties/"+name, child); err != nil {
+				return err
+			}
+		}
+	}
+
+	if value, ok := node["required"]; ok {
+		required, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s/required: must be an array", path)
+		}
+		properties, _ := node["properties"].(map[string]interface{})
+		seen := make(map[string]bool, len(required))
+		for i, item := range required {
+			name, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("%s/required/%d: must be a string", path, i)
+			}
+			if seen[name] {
+				return fmt.Errorf("%s/required/%d: duplicate property %q", path, i, name)
+			}
+			seen[name] = true
+			if _, defined := properties[name]; !defined {
+				return fmt.Errorf("%s/required/%d: property %q is not defined in properties", path, i, name)
+			}
+		}
+	}
+
+	if value, ok := node["additionalProperties"]; ok {
+		switch additional := value.(type) {
+		case bool:
+		case map[string]interface{}:
+			if err := checkSchemaNode(path+"/additionalProperties", additional); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s/additionalProperties: must be a boolean or a schema object", path)
+		}
+	}
+
+	if value, ok := node["items"]; ok {
+		items, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s/items: must be a schema object", path)
+		}
+		if err := checkSchemaNode(path+"/items", items); err != nil {
+			return err
+		}
+	}
+
+	if value, ok := node["enum"]; ok {
+		values, ok := value.([]interface{})
+		if !ok || len(values) == 0 {
+			return fmt.Errorf("%s/enum: must be a non-empty array", path)
+		}
+		for i, item := range values {
+			if len(types) > 0 && !matchesAnyType(item, types) {
+				return fmt.Errorf("%s/enum/%d: value %v does not match type %s", path, i, item, strings.Join(types, ", "))
+			}
+		}
+	}
+
+	if value, ok := node["default"]; ok && len(types) > 0 && !matchesAnyType(value, types) {
+		return fmt.Errorf("%s/default: value %v does not match type %s", path, value, strings.Join(types, ", "))
+	}
+
+	for _, key := range []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum"} {
+		if value, ok := node[key]; ok {
+			if _, ok := value.(float64); !ok {
+				return fmt.Errorf("%s/%s: must be a number", path, key)
+			}
+		}
+	}
+	if min, ok := node["minimum"].(float64); ok {
+		if max, ok := node["maximum"].(float64); ok && min > max {
+			return fmt.Errorf("%s/minimum: %v is greater than maximum %v", path, min, max)
+		}
+	}
+
+	for _, pair := range [][2]string{{"minLength", "maxLength"}, {"minItems", "maxItems"}, {"minProperties", "maxProperties"}} {
+		for _, key := range pair {
+			if value, ok := node[key]; ok {
+				if n, ok := value.(float64); !ok || n < 0 || n != math.Trunc(n) {
+					return fmt.Errorf("%s/%s: must be a non-negative integer", path, key)
+				}
+			}
+		}
+		min, hasMin := node[pair[0]].(float64)
+		max, hasMax := node[pair[1]].(float64)
+		if hasMin && hasMax && min > max {
+			return fmt.Errorf("%s/%s: %v is greater than %s %v", path, pair[0], min, pair[1], max)
+		}
+	}
+
+	if value, ok := node["pattern"]; ok {
+		pattern, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s/pattern: must be a string", path)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%s/pattern: invalid regular expression: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// schemaNodeTypes returns the types allowed by a schema node's type keyword
+func schemaNodeTypes(path string, node map[string]interface{}) ([]string, error) {
+	value, ok := node["type"]
+	if !ok {
+		return nil, nil
+	}
+
+	switch t := value.(type) {
+	case string:
+		if !schemaTypes[t] {
+			return nil, fmt.Errorf("%s/type: unknown type %q", path, t)
+		}
+		return []string{t}, nil
+
+	case []interface{}:
+		if len(t) == 0 {
+			return nil, fmt.Errorf("%s/type: must not be empty", path)
+		}
+		types := make([]string, 0, len(t))
+		seen := make(map[string]bool, len(t))
+		for i, item := range t {
+			name, ok := item.(string)
+			if !ok || !schemaTypes[name] {
+				return nil, fmt.Errorf("%s/type/%d: unknown type %v", path, i, item)
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("%s/type/%d: duplicate type %q", path, i, name)
+			}
+			seen[name] = true
+			types = append(types, name)
+		}
+		return types, nil
+	}
+
+	return nil, fmt.Errorf("%s/type: must be a string or an array of strings", path)
+}
+
+// matchesAnyType reports whether a decoded JSON value is one of types
+func matchesAnyType(value interface{}, types []string) bool {
+	for _, t := range types {
+		if matchesType(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesType reports whether a decoded JSON value has the given schema type
+func matchesType(value interface{}, t string) bool {
+	switch t {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidationError describes a parameter value that does not match a schema
+type ValidationError struct {
+	Path    string // Location of the value, such as "patterns[0]"; empty for the root
+	Message string
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidateParams checks params against a tool schema. It covers type,
+// required, enum, numeric bounds, string length and pattern, items and
+// additionalProperties. Every violation is reported as a *ValidationError;
+// when there are several they are joined with errors.Join.
+func ValidateParams(schema map[string]interface{}, params json.RawMessage) error {
+	node, err := normalizeSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	if len(bytes.TrimSpace(params)) == 0 {
+		params = json.RawMessage(`{}`)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(params, &value); err != nil {
+		return &ValidationError{Message: fmt.Sprintf("invalid JSON: %v", err)}
+	}
+
+	var errs []error
+	validateValue("", node, value, &errs)
+	return errors.Join(errs...)
+}
+
+// validateValue checks a decoded JSON value against a schema node, appending
+// any violations to errs
+func validateValue(path string, node map[string]interface{}, value interface{}, errs *[]error) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if types, _ := schemaNodeTypes(path, node); len(types) > 0 && !matchesAnyType(value, types) {
+		fail("expected %s, got %s", strings.Join(types, " or "), jsonTypeName(value))
+		return
+	}
+
+	if values, ok := node["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range values {
+			if reflect.DeepEqual(value, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of %s", formatEnum(values))
+			return
+		}
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if min, ok := node["minimum"].(float64); ok && v < min {
+			fail("must be >= %v, got %v", min, v)
+		}
+		if max, ok := node["maximum"].(float64); ok && v > max {
+			fail("must be <= %v, got %v", max, v)
+		}
+		if min, ok := node["exclusiveMinimum"].(float64); ok && v <= min {
+			fail("must be > %v, got %v", min, v)
+		}
+		if max, ok := node["exclusiveMaximum"].(float64); ok && v >= max {
+			fail("must be < %v, got %v", max, v)
+		}
+
+	case string:
+		length := float64(utf8.RuneCountInString(v))
+		if min, ok := node["minLength"].(float64); ok && length < min {
+			fail("must be at least %v characters", min)
+		}
+		if max, ok := node["maxLength"].(float64); ok && length > max {
+			fail("must be at most %v characters", max)
+		}
+		if pattern, ok := node["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				fail("must match pattern %q", pattern)
+			}
+		}
+
+	case []interface{}:
+		count := float64(len(v))
+		if min, ok := node["minItems"].(float64); ok && count < min {
+			fail("must have at least %v items", min)
+		}
+		if max, ok := node["maxItems"].(float64); ok && count > max {
+			fail("must have at most %v items", max)
+		}
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateValue(fmt.Sprintf("%s[%d]", path, i), items, item, errs)
+			}
+		}
+
+	case map[string]interface{}:
+		validateObject(path, node, v, errs)
+	}
+}
+
+// validateObject checks the properties of an object value
+func validateObject(path string, node map[string]interface{}, object map[string]interface{}, errs *[]error) {
+	properties, _ := node["properties"].(map[string]interface{})
+
+	if required, ok := node["required"].([]interface{}); ok {
+		for _, item := range required {
+			if name, ok := item.(string); ok {
+				if _, present := object[name]; !present {
+					*errs = append(*errs, &ValidationError{Path: joinPath(path, name), Message: "is required"})
+				}
+			}
+		}
+	}
+
+	count := float64(len(object))
+	if min, ok := node["minProperties"].(float64); ok && count < min {
+		*errs = append(*errs, &ValidationError{Path: path, Message: fmt.Sprintf("must have at least %v properties", min)})
+	}
+	if max, ok := node["maxProperties"].(float64); ok && count > max {
+		*errs = append(*errs, &ValidationError{Path: path, Message: fmt.Sprintf("must have at most %v properties", max)})
+	}
+
+	for _, name := range sortedKeys(object) {
+		if property, ok := properties[name].(map[string]interface{}); ok {
+			validateValue(joinPath(path, name), property, object[name], errs)
+			continue
+		}
+
+		switch additional := node["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*errs = append(*errs, &ValidationError{Path: joinPath(path, name), Message: "unknown property"})
+			}
+		case map[string]interface{}:
+			validateValue(joinPath(path, name), additional, object[name], errs)
+		}
+	}
+}
+
+// joinPath appends a property name to a value path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// jsonTypeName returns the JSON Schema type name of a decoded JSON value
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// formatEnum lists enum values for an error message
+func formatEnum(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			parts[i] = fmt.Sprintf("%q", s)
+		} else {
+			parts[i] = fmt.Sprintf("%v", value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
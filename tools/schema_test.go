@@ -0,0 +1,245 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema map[string]interface{}
+		errAt  string // Expected error location, empty if valid
+	}{
+		{
+			name:   "minimal object",
+			schema: map[string]interface{}{"type": "object"},
+		},
+		{
+			name: "nested properties",
+			schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"tags": map[string]interface{}{
+						"type":     "array",
+						"items":    map[string]interface{}{"type": "string", "enum": []string{"a", "b"}},
+						"minItems": 1,
+					},
+					"count": map[string]interface{}{"type": []string{"integer", "null"}, "minimum": 0, "maximum": 10, "default": 5},
+				},
+				"required":             []string{"tags"},
+				"additionalProperties": false,
+			},
+		},
+		{
+			name:   "root must be object",
+			schema: map[string]interface{}{"type": "string"},
+			errAt:  "/type",
+		},
+		{
+			name: "unknown type",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"path": map[string]interface{}{"type": "text"}},
+			},
+			errAt: "/properties/path/type",
+		},
+		{
+			name: "required property not defined",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"path": map[string]interface{}{"type": "string"}},
+				"required":   []string{"path", "content"},
+			},
+			errAt: "/required/1",
+		},
+		{
+			name: "enum value of wrong type",
+			schema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"mode": map[string]interface{}{"type": "string", "enum": []interface{}{"read", 1}},
+				},
+			},
+			errAt: "/properties/mode/enum/1",
+		},
+		{
+			name: "empty enum",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"mode": map[string]interface{}{"enum": []string{}}},
+			},
+			errAt: "/properties/mode/enum",
+		},
+		{
+			name: "minimum above maximum",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"n": map[string]interface{}{"type": "integer", "minimum": 5, "maximum": 1}},
+			},
+			errAt: "/properties/n/minimum",
+		},
+		{
+			name: "invalid items",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"list": map[string]interface{}{"type": "array", "items": "string"}},
+			},
+			errAt: "/properties/list/items",
+		},
+		{
+			name: "invalid pattern",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"id": map[string]interface{}{"type": "string", "pattern": "("}},
+			},
+			errAt: "/properties/id/pattern",
+		},
+		{
+			name: "default of wrong type",
+			schema: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"n": map[string]interface{}{"type": "integer", "default": 1.5}},
+			},
+			errAt: "/properties/n/default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSchema(tt.schema)
+			if tt.errAt == "" {
+				if err != nil {
+					t.Errorf("Expected valid schema, got: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected error at %s", tt.errAt)
+			}
+			if !strings.HasPrefix(err.Error(), tt.errAt+":") {
+				t.Errorf("Expected error at %s, got: %v", tt.errAt, err)
+			}
+		})
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{"type": "string", "minLength": 1},
+			"mode": map[string]interface{}{"type": "string", "enum": []string{"read", "write"}},
+			"limit": map[string]interface{}{
+				"type":    "integer",
+				"minimum": 1,
+				"maximum": 100,
+			},
+			"tags": map[string]interface{}{
+				"type":     "array",
+				"items":    map[string]interface{}{"type": "string", "pattern": "^[a-z]+$"},
+				"maxItems": 2,
+			},
+			"env": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+		},
+		"required":             []string{"path"},
+		"additionalProperties": false,
+	}
+
+	tests := []struct {
+		name   string
+		params string
+		errs   []string // Expected error messages, empty if valid
+	}{
+		{name: "valid", params: `{"path": "a.txt", "mode": "read", "limit": 10, "tags": ["go"], "env": {"A": "1"}}`},
+		{name: "empty params", params: ``, errs: []string{"path: is required"}},
+		{name: "not an object", params: `[]`, errs: []string{"expected object, got array"}},
+		{name: "invalid JSON", params: `{"path":`, errs: []string{"invalid JSON"}},
+		{name: "wrong type", params: `{"path": 1}`, errs: []string{"path: expected string, got integer"}},
+		{name: "too short", params: `{"path": ""}`, errs: []string{"path: must be at least 1 characters"}},
+		{name: "enum", params: `{"path": "a", "mode": "delete"}`, errs: []string{`mode: must be one of "read", "write"`}},
+		{name: "not an integer", params: `{"path": "a", "limit": 1.5}`, errs: []string{"limit: expected integer, got number"}},
+		{name: "below minimum", params: `{"path": "a", "limit": 0}`, errs: []string{"limit: must be >= 1, got 0"}},
+		{name: "above maximum", params: `{"path": "a", "limit": 101}`, errs: []string{"limit: must be <= 100, got 101"}},
+		{name: "item type", params: `{"path": "a", "tags": ["ok", 2]}`, errs: []string{"tags[1]: expected string, got integer"}},
+		{name: "item pattern", params: `{"path": "a", "tags": ["Go"]}`, errs: []string{`tags[0]: must match pattern "^[a-z]+$"`}},
+		{name: "too many items", params: `{"path": "a", "tags": ["a", "b", "c"]}`, errs: []string{"tags: must have at most 2 items"}},
+		{name: "additional property schema", params: `{"path": "a", "env": {"A": 1}}`, errs: []string{"env.A: expected string, got integer"}},
+		{name: "unknown property", params: `{"path": "a", "recursive": true}`, errs: []string{"recursive: unknown property"}},
+		{
+			name:   "several errors",
+			params: `{"mode": "x", "extra": 1}`,
+			errs:   []string{"path: is required", "extra: unknown property", `mode: must be one of "read", "write"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateParams(schema, json.RawMessage(tt.params))
+			if len(tt.errs) == 0 {
+				if err != nil {
+					t.Errorf("Expected valid params, got: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected errors %v", tt.errs)
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("Expected a *ValidationError, got %T", err)
+			}
+
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tt.errs) {
+				t.Fatalf("Expected %d errors, got: %v", len(tt.errs), err)
+			}
+			for i, want := range tt.errs {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("Error %d: expected %q, got %q", i, want, lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateParams_BuiltinDrift(t *testing.T) {
+	tests := []struct {
+		tool   Tool
+		params string
+		errAt  string
+	}{
+		{NewShellTool(), `{"command": "echo", "timeout": 0}`, "timeout"},
+		{NewShellTool(), `{"command": "echo", "environment": {"A": 1}}`, "environment.A"},
+		{NewReadTool(), `{"path": "a.txt", "lines": 10}`, "lines"},
+		{NewFetchTool(), `{"url": "https://example.com", "auth": {"type": "digest"}}`, "auth.type"},
+		{NewDownloadTool(), `{"url": "https://example.com", "output_path": "a", "chunk_size": 0}`, "chunk_size"},
+	}
+
+	for _, tt := range tests {
+		err := ValidateParams(tt.tool.Schema(), json.RawMessage(tt.params))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.errAt+":") {
+			t.Errorf("%s: expected error at %s for %s, got %v", tt.tool.Name(), tt.errAt, tt.params, err)
+		}
+	}
+
+	// Documented parameters are accepted
+	valid := []struct {
+		tool   Tool
+		params string
+	}{
+		{NewFetchTool(), `{"url": "https://example.com", "auth": {"type": "bearer", "token": "t"}, "headers": {"Accept": "text/plain"}}`},
+		{NewDownloadTool(), `{"url": "https://example.com", "output_path": "a", "chunk_size": 4096}`},
+	}
+	for _, tt := range valid {
+		if err := ValidateParams(tt.tool.Schema(), json.RawMessage(tt.params)); err != nil {
+			t.Errorf("%s: expected %s to be valid, got %v", tt.tool.Name(), tt.params, err)
+		}
+	}
+}
@@ -8,10 +8,15 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strings"
+	"sync"
 )
 
 // SearchTool implements content-based file search
-type SearchTool struct{}
+type SearchTool struct {
+	workspace *Workspace
+}
 
 // SearchParams defines the parameters for the Search tool
 type SearchParams struct {
@@ -24,6 +29,8 @@ type SearchParams struct {
 	Context     int      `json:"context,omitempty"`    // Lines of context
 	MaxResults  int      `json:"max_results,omitempty"`
 	FilePattern string   `json:"file_pattern,omitempty"` // Glob pattern for files
+	Hidden      bool     `json:"hidden,omitempty"`       // Search names starting with a dot
+	NoIgnore    bool     `json:"no_ignore,omitempty"`    // Search paths excluded by .gitignore and .ignore
 }
 
 // SearchResult represents the result of a search operation
@@ -48,6 +55,12 @@ func NewSearchTool() *SearchTool {
 	return &SearchTool{}
 }
 
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *SearchTool) WithWorkspace(ws *Workspace) *SearchTool {
+	t.workspace = ws
+	return t
+}
+
 // Name returns the tool's name
 func (t *SearchTool) Name() string {
 	return "search"
@@ -58,6 +71,11 @@ func (t *SearchTool) Description() string {
 	return "Search for content in files using regular expressions with support for filtering, context lines, and recursive search"
 }
 
+// Risk returns the tool's risk class
+func (t *SearchTool) Risk() Risk {
+	return RiskReadOnly
+}
+
 // Schema returns the JSON schema for the tool's parameters
 func (t *SearchTool) Schema() map[string]interface{} {
 	return map[string]interface{}{
@@ -105,10 +123,19 @@ func (t *SearchTool) Schema() map[string]interface{} {
 			},
 			"file_pattern": map[string]interface{}{
 				"type":        "string",
-				"description": "Glob pattern for files to search (e.g., '*.go')",
+				"description": "Glob pattern for files to search; without a slash it matches file names at any depth (e.g., '*.go', 'cmd/**/*.go')",
+			},
+			"hidden": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Search files and directories whose names start with a dot",
+			},
+			"no_ignore": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Search files excluded by .gitignore and .ignore files",
 			},
 		},
-		"required": []string{"pattern", "path"},
+		"required":             []string{"pattern", "path"},
+		"additionalProperties": false,
 	}
 }
 
@@ -164,6 +191,10 @@ func (t *SearchTool) Execute(ctx context.Context, params json.RawMessage) (inter
 		return nil, fmt.Errorf("invalid regex pattern: %w", err)
 	}
 
+	if err := t.workspace.Check(p.Path); err != nil {
+		return nil, err
+	}
+
 	// Check if path exists
 	info, err := os.Stat(p.Path)
 	if err != nil {
@@ -174,7 +205,7 @@ func (t *SearchTool) Execute(ctx context.Context, params json.RawMessage) (inter
 	filesSearched := 0
 
 	if info.IsDir() {
-		matches, filesSearched, err = t.searchDirectory(p.Path, re, p)
+		matches, filesSearched, err = t.searchDirectory(ctx, p.Path, re, p)
 	} else {
 		var fileMatches []SearchMatch
 		fileMatches, err = t.searchFile(p.Path, re, p.Context)
@@ -200,61 +231,68 @@ func (t *SearchTool) Execute(ctx context.Context, params json.RawMessage) (inter
 	}, nil
 }
 
-// searchDirectory searches all files in a directory
-func (t *SearchTool) searchDirectory(dirPath string, re *regexp.Regexp, p SearchParams) ([]SearchMatch, int, error) {
-	var allMatches []SearchMatch
-	filesSearched := 0
-
-	walkFunc := func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip files with errors
+// searchDirectory searches the files in a directory, and those below it if
+// p.Recursive is set, in parallel. Matches are ordered by file and line.
+func (t *SearchTool) searchDirectory(ctx context.Context, dirPath string, re *regexp.Regexp, p SearchParams) ([]SearchMatch, int, error) {
+	opts := WalkOptions{
+		SkipBinary: true,
+		Hidden:     p.Hidden,
+		NoIgnore:   p.NoIgnore,
+	}
+	if !p.Recursive {
+		opts.MaxDepth = 1
+	}
+	if p.FilePattern != "" {
+		opts.Patterns = []string{p.FilePattern}
+		if !strings.Contains(p.FilePattern, "/") {
+			// A pattern without a directory matches base names at any depth
+			opts.Patterns = []string{"**/" + p.FilePattern}
 		}
+		opts.CaseSensitive = true
+	}
+	// Skip entries outside the workspace
+	if t.workspace != nil {
+		opts.Filter = t.workspace.Allows
+	}
 
-		if info.IsDir() {
-			if !p.Recursive && path != dirPath {
-				return filepath.SkipDir
-			}
-			return nil
-		}
+	var mu sync.Mutex
+	var allMatches []SearchMatch
+	filesSearched := 0
 
-		// Check file pattern
-		if p.FilePattern != "" {
-			matched, _ := filepath.Match(p.FilePattern, filepath.Base(path))
-			if !matched {
-				return nil
-			}
-		}
+	err := Walk(ctx, dirPath, opts, func(entry WalkEntry) error {
+		name := filepath.Base(entry.Path)
 
 		// Check include/exclude
-		if len(p.Include) > 0 && !matchesExtensions(filepath.Base(path), p.Include) {
+		if len(p.Include) > 0 && !matchesExtensions(name, p.Include) {
 			return nil
 		}
-		if len(p.Exclude) > 0 && matchesExtensions(filepath.Base(path), p.Exclude) {
+		if len(p.Exclude) > 0 && matchesExtensions(name, p.Exclude) {
 			return nil
 		}
 
 		// Search the file
-		matches, err := t.searchFile(path, re, p.Context)
+		matches, err := t.searchFile(entry.Path, re, p.Context)
 		if err != nil {
 			return nil // Skip files that can't be read
 		}
 
+		mu.Lock()
 		allMatches = append(allMatches, matches...)
 		filesSearched++
-
-		// Check max results
-		if p.MaxResults > 0 && len(allMatches) >= p.MaxResults {
-			return filepath.SkipAll
-		}
-
+		mu.Unlock()
 		return nil
-	}
-
-	err := filepath.Walk(dirPath, walkFunc)
-	if err != nil && err != filepath.SkipAll {
+	})
+	if err != nil {
 		return nil, 0, err
 	}
 
+	sort.Slice(allMatches, func(i, j int) bool {
+		if allMatches[i].File != allMatches[j].File {
+			return allMatches[i].File < allMatches[j].File
+		}
+		return allMatches[i].Line < allMatches[j].Line
+	})
+
 	// Trim to max results
 	if p.MaxResults > 0 && len(allMatches) > p.MaxResults {
 		allMatches = allMatches[:p.MaxResults]
@@ -48,6 +48,17 @@ func (t *ShellTool) Description() string {
 	return "Execute shell commands with timeout support, environment variables, and working directory specification"
 }
 
+// Risk reports that the tool is destructive, since commands can do anything
+func (t *ShellTool) Risk() Risk {
+	return RiskDestructive
+}
+
+// ChangedPaths returns nothing: a command can change any file, so the files
+// it touches cannot be known in advance
+func (t *ShellTool) ChangedPaths(params json.RawMessage) []string {
+	return nil
+}
+
 // Schema returns the JSON schema for the tool's parameters
 func (t *ShellTool) Schema() map[string]interface{} {
 	return map[string]interface{}{
@@ -71,6 +82,9 @@ func (t *ShellTool) Schema() map[string]interface{} {
 			"environment": map[string]interface{}{
 				"type":        "object",
 				"description": "Environment variables to set",
+				"additionalProperties": map[string]interface{}{
+					"type": "string",
+				},
 			},
 			"timeout": map[string]interface{}{
 				"type":        "integer",
@@ -84,7 +98,8 @@ func (t *ShellTool) Schema() map[string]interface{} {
 				"enum":        []string{"bash", "sh", "powershell"},
 			},
 		},
-		"required": []string{"command"},
+		"required":             []string{"command"},
+		"additionalProperties": false,
 	}
 }
 
@@ -179,6 +194,10 @@ func (t *ShellTool) Execute(ctx context.Context, params json.RawMessage) (interf
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
+	// Don't wait on children that keep the output pipes open after the
+	// command is killed
+	cmd.WaitDelay = time.Second
+
 	// Execute command and measure duration
 	startTime := time.Now()
 	err := cmd.Run()
@@ -191,6 +210,11 @@ func (t *ShellTool) Execute(ctx context.Context, params json.RawMessage) (interf
 		Duration: duration.Milliseconds(),
 	}
 
+	// Cancellation by the caller is an error rather than a timeout
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("command cancelled: %w", err)
+	}
+
 	// Check for timeout
 	if execCtx.Err() == context.DeadlineExceeded {
 		result.Timeout = true
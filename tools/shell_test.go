@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestShellTool_Name(t *testing.T) {
@@ -298,6 +299,39 @@ func TestShellTool_Execute_Timeout(t *testing.T) {
 	}
 }
 
+func TestShellTool_Execute_Cancelled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on Windows")
+	}
+
+	tool := NewShellTool()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	params := map[string]interface{}{
+		"command": "sleep 30",
+		"shell":   "sh",
+		"timeout": 60,
+	}
+	paramsJSON, _ := json.Marshal(params)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := tool.Execute(ctx, paramsJSON)
+	if err == nil {
+		t.Fatal("Expected error for cancelled command")
+	}
+	if !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("Expected cancelled error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected cancellation to stop the command promptly, took %v", elapsed)
+	}
+}
+
 func TestShellTool_Execute_StderrCapture(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("Skipping test on Windows")
@@ -87,6 +87,11 @@ func (t *TaskListTool) Description() string {
 	return "Create and manage hierarchical task lists with support for priorities, status tracking, and task dependencies"
 }
 
+// Risk reports that the tool only changes its in-memory task lists
+func (t *TaskListTool) Risk() Risk {
+	return RiskReadOnly
+}
+
 // Schema returns the JSON schema for the tool's parameters
 func (t *TaskListTool) Schema() map[string]interface{} {
 	return map[string]interface{}{
@@ -128,7 +133,8 @@ func (t *TaskListTool) Schema() map[string]interface{} {
 				"description": "ID of the parent task for hierarchical tasks",
 			},
 		},
-		"required": []string{"action"},
+		"required":             []string{"action"},
+		"additionalProperties": false,
 	}
 }
 
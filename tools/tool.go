@@ -3,6 +3,8 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
 )
 
 // Tool interface that all tools must implement
@@ -39,3 +41,16 @@ type FileInfo struct {
 	Permissions  string `json:"permissions"`
 	ModifiedTime int64  `json:"modified_time"`
 }
+
+// newFileInfo describes the file at path with info, which may have been read
+// from another path, such as the source of a copy
+func newFileInfo(path string, info os.FileInfo) FileInfo {
+	return FileInfo{
+		Path:         path,
+		Name:         filepath.Base(path),
+		Size:         info.Size(),
+		IsDir:        info.IsDir(),
+		Permissions:  info.Mode().String(),
+		ModifiedTime: info.ModTime().Unix(),
+	}
+}
@@ -0,0 +1,333 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// trashManifest is the file in a trash directory that lists its items
+const trashManifest = "manifest.json"
+
+// TrashItem describes something moved to the trash
+type TrashItem struct {
+	ID           string    `json:"id"`
+	OriginalPath string    `json:"original_path"`
+	DeletedAt    time.Time `json:"deleted_at"`
+	Size         int64     `json:"size"` // Total size of the files, in bytes
+	IsDir        bool      `json:"is_dir,omitempty"`
+}
+
+// Trash holds deleted files and directories so they can be restored. Items
+// are moved into <dir>/files/<id>, and a manifest records where each came
+// from, when it was deleted and its size.
+type Trash struct {
+	dir     string
+	maxSize int64
+	mu      sync.Mutex
+}
+
+// DefaultTrashDir returns the default trash directory, ~/.agar/trash
+func DefaultTrashDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, ".agar", "trash"), nil
+}
+
+// NewTrash creates a trash in dir, which is created when the first item is
+// added. An empty dir uses DefaultTrashDir.
+func NewTrash(dir string) (*Trash, error) {
+	if dir == "" {
+		defaultDir, err := DefaultTrashDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = defaultDir
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trash directory %s: %w", dir, err)
+	}
+	return &Trash{dir: abs}, nil
+}
+
+// WithMaxSize limits the total size of the trash. When an item pushes it
+// over the limit, the oldest items are removed for good until it fits again.
+// The newest item is always kept, even if it is larger than the limit on its
+// own. Zero means no limit.
+func (t *Trash) WithMaxSize(bytes int64) *Trash {
+	t.maxSize = bytes
+	return t
+}
+
+// Dir returns the trash directory
+func (t *Trash) Dir() string {
+	return t.dir
+}
+
+// Put moves path into the trash and returns the new item. Files are moved
+// with a rename when the trash is on the same file system, and copied
+// otherwise.
+func (t *Trash) Put(path string) (*TrashItem, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve path %s: %w", path, err)
+	}
+	_, inTrash := within(t.dir, abs)
+	_, holdsTrash := within(abs, t.dir)
+	if inTrash || holdsTrash {
+		return nil, fmt.Errorf("path %s overlaps the trash directory", path)
+	}
+
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access path: %w", err)
+	}
+	size, err := treeSize(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure %s: %w", path, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	items, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+
+	item := TrashItem{
+		ID:           newTrashID(),
+		OriginalPath: abs,
+		DeletedAt:    time.Now().UTC(),
+		Size:         size,
+		IsDir:        info.IsDir(),
+	}
+
+	if err := os.MkdirAll(filepath.Join(t.dir, "files"), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create trash directory: %w", err)
+	}
+	if err := movePath(context.Background(), abs, t.itemPath(item.ID)); err != nil {
+		return nil, fmt.Errorf("failed to move %s to the trash: %w", path, err)
+	}
+
+	items = append(items, item)
+	items = t.prune(items)
+	if err := t.save(items); err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// List returns the items in the trash, most recently deleted first
+func (t *Trash) List() ([]TrashItem, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	items, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items, nil
+}
+
+// Restore moves an item back to its original path, or to destination if it
+// is not empty, and removes it from the trash. It fails rather than
+// overwrite an existing path.
+func (t *Trash) Restore(id, destination string) (*TrashItem, string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	items, err := t.load()
+	if err != nil {
+		return nil, "", err
+	}
+
+	index := findTrashItem(items, id)
+	if index < 0 {
+		return nil, "", fmt.Errorf("no item %s in the trash", id)
+	}
+	item := items[index]
+
+	target := item.OriginalPath
+	if destination != "" {
+		if target, err = filepath.Abs(destination); err != nil {
+			return nil, "", fmt.Errorf("cannot resolve path %s: %w", destination, err)
+		}
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return nil, "", fmt.Errorf("%s already exists; set destination to restore it elsewhere", target)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := movePath(context.Background(), t.itemPath(id), target); err != nil {
+		return nil, "", fmt.Errorf("failed to restore %s: %w", target, err)
+	}
+
+	items = append(items[:index], items[index+1:]...)
+	if err := t.save(items); err != nil {
+		return nil, "", err
+	}
+	return &item, target, nil
+}
+
+// Empty removes the given items for good, or every item if no IDs are given.
+// It returns the removed items.
+func (t *Trash) Empty(ids ...string) ([]TrashItem, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	items, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed, kept []TrashItem
+	if len(ids) == 0 {
+		removed = items
+	} else {
+		remove := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			if findTrashItem(items, id) < 0 {
+				return nil, fmt.Errorf("no item %s in the trash", id)
+			}
+			remove[id] = true
+		}
+		for _, item := range items {
+			if remove[item.ID] {
+				removed = append(removed, item)
+			} else {
+				kept = append(kept, item)
+			}
+		}
+	}
+
+	for _, item := range removed {
+		if err := os.RemoveAll(t.itemPath(item.ID)); err != nil {
+			return nil, fmt.Errorf("failed to remove %s from the trash: %w", item.ID, err)
+		}
+	}
+	if err := t.save(kept); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// prune removes the oldest items until the trash fits in maxSize, keeping
+// the newest one. It returns the remaining items.
+func (t *Trash) prune(items []TrashItem) []TrashItem {
+	if t.maxSize <= 0 {
+		return items
+	}
+
+	var total int64
+	for _, item := range items {
+		total += item.Size
+	}
+
+	for len(items) > 1 && total > t.maxSize {
+		oldest := 0
+		for i, item := range items[:len(items)-1] {
+			if item.DeletedAt.Before(items[oldest].DeletedAt) {
+				oldest = i
+			}
+		}
+		if err := os.RemoveAll(t.itemPath(items[oldest].ID)); err != nil {
+			break
+		}
+		total -= items[oldest].Size
+		items = append(items[:oldest], items[oldest+1:]...)
+	}
+	return items
+}
+
+// itemPath returns where an item's files are kept
+func (t *Trash) itemPath(id string) string {
+	return filepath.Join(t.dir, "files", id)
+}
+
+// load reads the manifest; the caller must hold t.mu
+func (t *Trash) load() ([]TrashItem, error) {
+	data, err := os.ReadFile(filepath.Join(t.dir, trashManifest))
+	if os.IsNotExist(err) {
+		return []TrashItem{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash manifest: %w", err)
+	}
+
+	var items []TrashItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("invalid trash manifest: %w", err)
+	}
+	return items, nil
+}
+
+// save writes the manifest; the caller must hold t.mu
+func (t *Trash) save(items []TrashItem) error {
+	if items == nil {
+		items = []TrashItem{}
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trash manifest: %w", err)
+	}
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create trash directory: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(t.dir, trashManifest), data, 0600)
+}
+
+// findTrashItem returns the index of the item with the given ID, or -1
+func findTrashItem(items []TrashItem, id string) int {
+	for i, item := range items {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// newTrashID returns a sortable, unique item ID such as 20250102-150405-a1b2c3
+func newTrashID() string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return time.Now().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// treeSize returns the total size of the regular files at or under path
+func treeSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(walkPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
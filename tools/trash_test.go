@@ -0,0 +1,321 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestTrash creates a trash and a directory of files to delete
+func newTestTrash(t *testing.T) (*Trash, string) {
+	t.Helper()
+
+	base := t.TempDir()
+	dir := filepath.Join(base, "project")
+	writeFiles(t, dir, map[string]string{
+		"a.txt":         "aaaa",
+		"docs/b.md":     "bb",
+		"docs/sub/c.md": "c",
+	})
+
+	trash, err := NewTrash(filepath.Join(base, "trash"))
+	if err != nil {
+		t.Fatalf("NewTrash failed: %v", err)
+	}
+	return trash, dir
+}
+
+func TestTrash_PutAndRestore(t *testing.T) {
+	trash, dir := newTestTrash(t)
+	docs := filepath.Join(dir, "docs")
+
+	item, err := trash.Put(docs)
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if item.OriginalPath != docs || !item.IsDir || item.Size != 3 {
+		t.Errorf("Expected directory %s of 3 bytes, got %+v", docs, item)
+	}
+	if _, err := os.Stat(docs); !os.IsNotExist(err) {
+		t.Error("Expected the directory to be moved out")
+	}
+
+	items, err := trash.List()
+	if err != nil || len(items) != 1 || items[0].ID != item.ID {
+		t.Fatalf("Expected the item to be listed, got %+v, %v", items, err)
+	}
+
+	// Restoring never overwrites
+	os.MkdirAll(docs, 0755)
+	if _, _, err := trash.Restore(item.ID, ""); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected error for an existing path, got %v", err)
+	}
+	os.Remove(docs)
+
+	restored, path, err := trash.Restore(item.ID, "")
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored.ID != item.ID || path != docs {
+		t.Errorf("Expected %s restored to %s, got %s at %s", item.ID, docs, restored.ID, path)
+	}
+	if got := readFile(t, filepath.Join(docs, "sub", "c.md")); got != "c" {
+		t.Errorf("Expected the nested file to be restored, got %q", got)
+	}
+	if items, _ := trash.List(); len(items) != 0 {
+		t.Errorf("Expected an empty trash, got %+v", items)
+	}
+	if _, _, err := trash.Restore(item.ID, ""); err == nil {
+		t.Error("Expected error restoring an item twice")
+	}
+}
+
+func TestTrash_RestoreToDestination(t *testing.T) {
+	trash, dir := newTestTrash(t)
+	file := filepath.Join(dir, "a.txt")
+
+	item, err := trash.Put(file)
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	dest := filepath.Join(dir, "restored", "a.txt")
+	if _, path, err := trash.Restore(item.ID, dest); err != nil || path != dest {
+		t.Fatalf("Expected restore to %s, got %s, %v", dest, path, err)
+	}
+	if got := readFile(t, dest); got != "aaaa" {
+		t.Errorf("Expected restored content, got %q", got)
+	}
+}
+
+func TestTrash_RejectsTrashDirectory(t *testing.T) {
+	trash, _ := newTestTrash(t)
+
+	for _, path := range []string{trash.Dir(), filepath.Join(trash.Dir(), "files"), filepath.Dir(trash.Dir())} {
+		if _, err := trash.Put(path); err == nil || !strings.Contains(err.Error(), "overlaps the trash") {
+			t.Errorf("Expected error for %s, got %v", path, err)
+		}
+	}
+}
+
+func TestTrash_Empty(t *testing.T) {
+	trash, dir := newTestTrash(t)
+
+	first, _ := trash.Put(filepath.Join(dir, "a.txt"))
+	second, _ := trash.Put(filepath.Join(dir, "docs"))
+
+	if _, err := trash.Empty("missing"); err == nil {
+		t.Error("Expected error for an unknown item")
+	}
+
+	removed, err := trash.Empty(first.ID)
+	if err != nil || len(removed) != 1 || removed[0].ID != first.ID {
+		t.Fatalf("Expected %s to be removed, got %+v, %v", first.ID, removed, err)
+	}
+	if _, err := os.Stat(trash.itemPath(first.ID)); !os.IsNotExist(err) {
+		t.Error("Expected the item's files to be removed")
+	}
+
+	removed, err = trash.Empty()
+	if err != nil || len(removed) != 1 || removed[0].ID != second.ID {
+		t.Fatalf("Expected %s to be removed, got %+v, %v", second.ID, removed, err)
+	}
+	if items, _ := trash.List(); len(items) != 0 {
+		t.Errorf("Expected an empty trash, got %+v", items)
+	}
+}
+
+func TestTrash_MaxSize(t *testing.T) {
+	trash, dir := newTestTrash(t)
+	trash.WithMaxSize(5)
+
+	first, _ := trash.Put(filepath.Join(dir, "a.txt"))   // 4 bytes
+	second, err := trash.Put(filepath.Join(dir, "docs")) // 3 bytes
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	items, _ := trash.List()
+	if len(items) != 1 || items[0].ID != second.ID {
+		t.Fatalf("Expected only the newest item to be kept, got %+v", items)
+	}
+	if _, err := os.Stat(trash.itemPath(first.ID)); !os.IsNotExist(err) {
+		t.Error("Expected the oldest item's files to be removed")
+	}
+
+	// The newest item is kept even if it exceeds the limit alone
+	writeFiles(t, dir, map[string]string{"big.txt": "0123456789"})
+	big, err := trash.Put(filepath.Join(dir, "big.txt"))
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if items, _ := trash.List(); len(items) != 1 || items[0].ID != big.ID {
+		t.Errorf("Expected only the large item to be kept, got %+v", items)
+	}
+}
+
+func TestDeleteTool_Execute_Trash(t *testing.T) {
+	trash, dir := newTestTrash(t)
+	tool := NewDeleteTool().WithTrash(trash)
+	docs := filepath.Join(dir, "docs")
+
+	result, err := tool.Execute(context.Background(), json.RawMessage(fmt.Sprintf(`{"path": %q, "recursive": true}`, docs)))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	deleteResult := result.(*DeleteResult)
+	if !deleteResult.Deleted || deleteResult.TrashID == "" {
+		t.Fatalf("Expected the directory to be moved to the trash, got %+v", deleteResult)
+	}
+	if _, err := os.Stat(docs); !os.IsNotExist(err) {
+		t.Error("Expected the directory to be gone")
+	}
+
+	// trash: false deletes permanently
+	file := filepath.Join(dir, "a.txt")
+	result, err = tool.Execute(context.Background(), json.RawMessage(fmt.Sprintf(`{"path": %q, "trash": false}`, file)))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.(*DeleteResult).TrashID != "" {
+		t.Error("Expected a permanent delete")
+	}
+	if items, _ := trash.List(); len(items) != 1 {
+		t.Errorf("Expected one item in the trash, got %d", len(items))
+	}
+
+	restoreResult, err := NewTrashRestoreTool(trash).Execute(context.Background(),
+		json.RawMessage(fmt.Sprintf(`{"id": %q}`, deleteResult.TrashID)))
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restoreResult.(*TrashRestoreResult).Path != docs {
+		t.Errorf("Expected restore to %s, got %+v", docs, restoreResult)
+	}
+	if got := readFile(t, filepath.Join(docs, "b.md")); got != "bb" {
+		t.Errorf("Expected the file to be restored, got %q", got)
+	}
+}
+
+func TestDeleteTool_Validate_TrashNotConfigured(t *testing.T) {
+	if err := NewDeleteTool().Validate(json.RawMessage(`{"path": "a.txt", "trash": true}`)); err == nil {
+		t.Error("Expected error asking for the trash without one configured")
+	}
+	if err := NewDeleteTool().Validate(json.RawMessage(`{"path": "a.txt", "trash": false}`)); err != nil {
+		t.Errorf("Expected trash: false to be valid, got %v", err)
+	}
+}
+
+func TestTrashListTool_Execute(t *testing.T) {
+	trash, dir := newTestTrash(t)
+	trash.Put(filepath.Join(dir, "a.txt"))
+	trash.Put(filepath.Join(dir, "docs", "sub"))
+
+	tool := NewTrashListTool(trash)
+	if tool.Name() != "trash_list" {
+		t.Errorf("Expected name 'trash_list', got '%s'", tool.Name())
+	}
+
+	result, err := tool.Execute(context.Background(), json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	listResult := result.(*TrashListResult)
+	if listResult.Count != 2 || listResult.TotalSize != 5 {
+		t.Errorf("Expected 2 items of 5 bytes, got %d items of %d bytes", listResult.Count, listResult.TotalSize)
+	}
+
+	result, err = tool.Execute(context.Background(), json.RawMessage(fmt.Sprintf(`{"path": %q}`, filepath.Join(dir, "docs"))))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if items := result.(*TrashListResult).Items; len(items) != 1 || items[0].OriginalPath != filepath.Join(dir, "docs", "sub") {
+		t.Errorf("Expected only the docs item, got %+v", items)
+	}
+}
+
+func TestTrashRestoreTool_Validate(t *testing.T) {
+	tool := NewTrashRestoreTool(nil)
+
+	tests := []struct {
+		name    string
+		params  string
+		wantErr bool
+	}{
+		{"valid", `{"id": "20250102-150405-a1b2c3"}`, false},
+		{"with destination", `{"id": "x", "destination": "/tmp/x"}`, false},
+		{"missing id", `{}`, true},
+		{"invalid json", `{invalid}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tool.Validate(json.RawMessage(tt.params))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTrashRestoreTool_Workspace(t *testing.T) {
+	trash, dir := newTestTrash(t)
+	item, _ := trash.Put(filepath.Join(dir, "a.txt"))
+
+	ws, err := NewWorkspace(filepath.Join(dir, "docs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewTrashRestoreTool(trash).WithWorkspace(ws).Execute(context.Background(),
+		json.RawMessage(fmt.Sprintf(`{"id": %q}`, item.ID)))
+	if err == nil || !strings.Contains(err.Error(), "outside the workspace") {
+		t.Errorf("Expected restore outside the workspace to be rejected, got %v", err)
+	}
+}
+
+func TestTrashRestoreTool_ApprovalPolicy(t *testing.T) {
+	trash, dir := newTestTrash(t)
+	file := filepath.Join(dir, "a.txt")
+	item, _ := trash.Put(file)
+	params := json.RawMessage(fmt.Sprintf(`{"id": %q}`, item.ID))
+
+	// A deny rule covers the original path the item is restored to
+	registry := NewToolRegistry()
+	registry.Register(NewTrashRestoreTool(trash))
+	registry.Use(NewApprovalGate(ApprovalPolicy{Rules: []ApprovalRule{{Path: "**/a.txt", Mode: ApproveDeny}}}, nil).Middleware())
+
+	result := registry.Execute(context.Background(), "trash_restore", params)
+	if result.Success || !strings.Contains(result.Error, "denied by the approval policy") {
+		t.Errorf("Expected restore to a denied path to be rejected, got %+v", result)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error("Expected the file to stay in the trash")
+	}
+
+	// The approval request shows where the item goes
+	approver := &recordingApprover{decision: DecisionDeny}
+	NewApprovalGate(ApprovalPolicy{}, approver).Check(context.Background(), NewTrashRestoreTool(trash), params)
+	if len(approver.requests) != 1 || len(approver.requests[0].Paths) != 1 || approver.requests[0].Paths[0] != file {
+		t.Errorf("Expected approval request for %s, got %+v", file, approver.requests)
+	}
+}
+
+func TestTrashEmptyTool_Execute(t *testing.T) {
+	trash, dir := newTestTrash(t)
+	trash.Put(filepath.Join(dir, "a.txt"))
+	trash.Put(filepath.Join(dir, "docs"))
+
+	result, err := NewTrashEmptyTool(trash).Execute(context.Background(), json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	emptyResult := result.(*TrashEmptyResult)
+	if emptyResult.Count != 2 || emptyResult.FreedBytes != 7 {
+		t.Errorf("Expected 2 items and 7 bytes freed, got %+v", emptyResult)
+	}
+}
@@ -0,0 +1,320 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// TrashListTool lists the items in a trash
+type TrashListTool struct {
+	trash *Trash
+}
+
+// TrashListParams defines the parameters for the TrashList tool
+type TrashListParams struct {
+	Path string `json:"path,omitempty"` // Only list items deleted from this path or below it
+}
+
+// TrashListResult represents the items in the trash
+type TrashListResult struct {
+	Items     []TrashItem `json:"items"`
+	Count     int         `json:"count"`
+	TotalSize int64       `json:"total_size"`
+}
+
+// NewTrashListTool creates a new TrashList tool for trash
+func NewTrashListTool(trash *Trash) *TrashListTool {
+	return &TrashListTool{trash: trash}
+}
+
+// Name returns the tool's name
+func (t *TrashListTool) Name() string {
+	return "trash_list"
+}
+
+// Description returns the tool's description
+func (t *TrashListTool) Description() string {
+	return "List files and directories in the trash with their original path, deletion time, size and the ID used to restore them"
+}
+
+// Risk returns the tool's risk class
+func (t *TrashListTool) Risk() Risk {
+	return RiskReadOnly
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *TrashListTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Only list items deleted from this path or below it",
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// Validate checks if the parameters are valid
+func (t *TrashListTool) Validate(params json.RawMessage) error {
+	var p TrashListParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+	return nil
+}
+
+// Execute runs the tool with the given parameters
+func (t *TrashListTool) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p TrashListParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	items, err := t.trash.List()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Path != "" {
+		base, err := filepath.Abs(p.Path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve path %s: %w", p.Path, err)
+		}
+		matched := make([]TrashItem, 0, len(items))
+		for _, item := range items {
+			if _, ok := within(base, item.OriginalPath); ok {
+				matched = append(matched, item)
+			}
+		}
+		items = matched
+	}
+
+	result := &TrashListResult{Items: items, Count: len(items)}
+	for _, item := range items {
+		result.TotalSize += item.Size
+	}
+	return result, nil
+}
+
+// TrashRestoreTool moves an item out of the trash
+type TrashRestoreTool struct {
+	trash     *Trash
+	workspace *Workspace
+}
+
+// TrashRestoreParams defines the parameters for the TrashRestore tool
+type TrashRestoreParams struct {
+	ID          string `json:"id"`
+	Destination string `json:"destination,omitempty"` // Restore here instead of the original path
+}
+
+// TrashRestoreResult represents the result of a restore
+type TrashRestoreResult struct {
+	ID           string `json:"id"`
+	Path         string `json:"path"`
+	OriginalPath string `json:"original_path"`
+	Message      string `json:"message"`
+}
+
+// NewTrashRestoreTool creates a new TrashRestore tool for trash
+func NewTrashRestoreTool(trash *Trash) *TrashRestoreTool {
+	return &TrashRestoreTool{trash: trash}
+}
+
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *TrashRestoreTool) WithWorkspace(ws *Workspace) *TrashRestoreTool {
+	t.workspace = ws
+	return t
+}
+
+// Name returns the tool's name
+func (t *TrashRestoreTool) Name() string {
+	return "trash_restore"
+}
+
+// Description returns the tool's description
+func (t *TrashRestoreTool) Description() string {
+	return "Restore a file or directory from the trash to its original path, or to a new destination, without overwriting anything"
+}
+
+// Risk returns the tool's risk class
+func (t *TrashRestoreTool) Risk() Risk {
+	return RiskMutating
+}
+
+// ChangedPaths returns the path the item is restored to
+func (t *TrashRestoreTool) ChangedPaths(params json.RawMessage) []string {
+	var p TrashRestoreParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+	if p.Destination != "" {
+		return []string{p.Destination}
+	}
+
+	items, err := t.trash.List()
+	if err != nil {
+		return nil
+	}
+	if i := findTrashItem(items, p.ID); i >= 0 {
+		return []string{items[i].OriginalPath}
+	}
+	return nil
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *TrashRestoreTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{
+				"type":        "string",
+				"description": "ID of the trash item, as returned by delete or trash_list",
+			},
+			"destination": map[string]interface{}{
+				"type":        "string",
+				"description": "Path to restore to instead of the original path; must not exist",
+			},
+		},
+		"required":             []string{"id"},
+		"additionalProperties": false,
+	}
+}
+
+// Validate checks if the parameters are valid
+func (t *TrashRestoreTool) Validate(params json.RawMessage) error {
+	var p TrashRestoreParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	if p.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+
+	return nil
+}
+
+// Execute runs the tool with the given parameters
+func (t *TrashRestoreTool) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p TrashRestoreParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	for _, path := range t.ChangedPaths(params) {
+		if err := t.workspace.Check(path); err != nil {
+			return nil, err
+		}
+	}
+
+	item, path, err := t.trash.Restore(p.ID, p.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TrashRestoreResult{
+		ID:           item.ID,
+		Path:         path,
+		OriginalPath: item.OriginalPath,
+		Message:      fmt.Sprintf("Restored %s to %s", item.ID, path),
+	}, nil
+}
+
+// TrashEmptyTool permanently removes items from the trash
+type TrashEmptyTool struct {
+	trash *Trash
+}
+
+// TrashEmptyParams defines the parameters for the TrashEmpty tool
+type TrashEmptyParams struct {
+	IDs []string `json:"ids,omitempty"` // Items to remove; all items if empty
+}
+
+// TrashEmptyResult represents the result of emptying the trash
+type TrashEmptyResult struct {
+	Removed    []TrashItem `json:"removed"`
+	Count      int         `json:"count"`
+	FreedBytes int64       `json:"freed_bytes"`
+	Message    string      `json:"message"`
+}
+
+// NewTrashEmptyTool creates a new TrashEmpty tool for trash
+func NewTrashEmptyTool(trash *Trash) *TrashEmptyTool {
+	return &TrashEmptyTool{trash: trash}
+}
+
+// Name returns the tool's name
+func (t *TrashEmptyTool) Name() string {
+	return "trash_empty"
+}
+
+// Description returns the tool's description
+func (t *TrashEmptyTool) Description() string {
+	return "Permanently delete items from the trash, or everything in it when no IDs are given"
+}
+
+// Risk returns the tool's risk class
+func (t *TrashEmptyTool) Risk() Risk {
+	return RiskDestructive
+}
+
+// Schema returns the JSON schema for the tool's parameters
+func (t *TrashEmptyTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"ids": map[string]interface{}{
+				"type":        "array",
+				"description": "IDs of the items to delete permanently; omit to empty the whole trash",
+				"items": map[string]interface{}{
+					"type": "string",
+				},
+			},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// Validate checks if the parameters are valid
+func (t *TrashEmptyTool) Validate(params json.RawMessage) error {
+	var p TrashEmptyParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	for _, id := range p.IDs {
+		if id == "" {
+			return fmt.Errorf("ids must not be empty")
+		}
+	}
+
+	return nil
+}
+
+// Execute runs the tool with the given parameters
+func (t *TrashEmptyTool) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p TrashEmptyParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	removed, err := t.trash.Empty(p.IDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TrashEmptyResult{Removed: removed, Count: len(removed)}
+	if result.Removed == nil {
+		result.Removed = []TrashItem{}
+	}
+	for _, item := range removed {
+		result.FreedBytes += item.Size
+	}
+	result.Message = fmt.Sprintf("Permanently deleted %d item(s), %d bytes", result.Count, result.FreedBytes)
+	return result, nil
+}
@@ -0,0 +1,406 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TypedFunc is the function run by a TypedTool with decoded parameters
+type TypedFunc[P, R any] func(ctx context.Context, params P) (R, error)
+
+// TypedTool is a Tool whose schema, validation and decoding are derived from
+// a Go struct. Create one with NewTypedTool.
+type TypedTool[P, R any] struct {
+	name        string
+	description string
+	schema      map[string]interface{}
+	risk        Risk
+	fn          TypedFunc[P, R]
+}
+
+// NewTypedTool creates a tool that decodes its parameters into P and calls fn.
+// P must be a struct; its schema is generated from the struct fields:
+//
+//	type GreetParams struct {
+//	    Name     string `json:"name" desc:"Who to greet"`
+//	    Greeting string `json:"greeting,omitempty" desc:"Greeting to use" enum:"hello,hi" default:"hello"`
+//	    Times    int    `json:"times,omitempty" min:"1" max:"5" default:"1"`
+//	}
+//
+// Field tags:
+//   - json: the parameter name; fields without omitempty or a default are required
+//   - desc: the parameter description
+//   - enum: comma separated allowed values
+//   - min, max: bounds for numbers, string lengths or array sizes
+//   - default: value used when the parameter is omitted; JSON for non-string fields
+//
+// NewTypedTool panics if P is not a struct or a tag is invalid, since that is
+// a programming error.
+func NewTypedTool[P, R any](name, description string, fn TypedFunc[P, R]) *TypedTool[P, R] {
+	schema, err := StructSchema[P]()
+	if err != nil {
+		panic(fmt.Sprintf("tools: NewTypedTool %s: %v", name, err))
+	}
+
+	return &TypedTool[P, R]{
+		name:        name,
+		description: description,
+		schema:      schema,
+		fn:          fn,
+	}
+}
+
+// Name returns the tool name
+func (t *TypedTool[P, R]) Name() string {
+	return t.name
+}
+
+// Description returns the tool description
+func (t *TypedTool[P, R]) Description() string {
+	return t.description
+}
+
+// WithRisk sets the risk class reported by Risk and returns the tool
+func (t *TypedTool[P, R]) WithRisk(risk Risk) *TypedTool[P, R] {
+	t.risk = risk
+	return t
+}
+
+// Risk returns the risk class set with WithRisk, or RiskMutating
+func (t *TypedTool[P, R]) Risk() Risk {
+	if t.risk == "" {
+		return RiskMutating
+	}
+	return t.risk
+}
+
+// Schema returns the JSON schema generated from P
+func (t *TypedTool[P, R]) Schema() map[string]interface{} {
+	return t.schema
+}
+
+// Validate checks params against the generated schema
+func (t *TypedTool[P, R]) Validate(params json.RawMessage) error {
+	if err := ValidateParams(t.schema, params); err != nil {
+		return err
+	}
+	_, err := t.decode(params)
+	return err
+}
+
+// Execute decodes params, applying defaults, and calls the tool function
+func (t *TypedTool[P, R]) Execute(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	p, err := t.decode(params)
+	if err != nil {
+		return nil, err
+	}
+	return t.fn(ctx, p)
+}
+
+// decode unmarshals params into a P with defaults applied to omitted fields
+func (t *TypedTool[P, R]) decode(params json.RawMessage) (P, error) {
+	var p P
+	if err := applyDefaults(reflect.ValueOf(&p).Elem()); err != nil {
+		return p, err
+	}
+
+	if len(strings.TrimSpace(string(params))) == 0 {
+		return p, nil
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return p, fmt.Errorf("invalid parameters: %w", err)
+	}
+	return p, nil
+}
+
+// StructSchema generates the JSON schema for the struct type T, using the
+// field tags described in NewTypedTool
+func StructSchema[T any]() (map[string]interface{}, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("parameters must be a struct, got %s", t)
+	}
+	return typeSchema(t, map[reflect.Type]bool{})
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// typeSchema returns the schema for a Go type
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) (map[string]interface{}, error) {
+	t = derefType(t)
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}, nil
+	case t == rawMessageType:
+		return map[string]interface{}{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as base64 strings
+			return map[string]interface{}{"type": "string"}, nil
+		}
+		items, err := typeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map keys must be strings, got %s", t.Key())
+		}
+		values, err := typeSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+
+	case reflect.Struct:
+		if seen[t] {
+			return nil, fmt.Errorf("recursive type %s is not supported", t)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return structSchema(t, seen)
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// structSchema returns the object schema for a struct type
+func structSchema(t reflect.Type, seen map[reflect.Type]bool) (map[string]interface{}, error) {
+	properties := map[string]interface{}{}
+	required := []string{}
+
+	err := eachField(t, func(field reflect.StructField, name string, omitempty bool) error {
+		schema, err := typeSchema(field.Type, seen)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if err := applyFieldTags(field, schema); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+
+		properties[name] = schema
+		if !omitempty && field.Type.Kind() != reflect.Pointer && field.Tag.Get("default") == "" {
+			required = append(required, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// eachField calls fn for every field of a struct that encoding/json would
+// encode, flattening embedded structs
+func eachField(t reflect.Type, fn func(field reflect.StructField, name string, omitempty bool) error) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			if embedded := derefType(field.Type); embedded.Kind() == reflect.Struct {
+				if err := eachField(embedded, fn); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if err := fn(field, name, strings.Contains(","+options+",", ",omitempty,")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyFieldTags adds the desc, enum, min, max and default tags of a field
+// to its schema
+func applyFieldTags(field reflect.StructField, schema map[string]interface{}) error {
+	if desc := field.Tag.Get("desc"); desc != "" {
+		schema["description"] = desc
+	}
+
+	if enum := field.Tag.Get("enum"); enum != "" {
+		// The enum of a slice field applies to its items
+		target, elemType := schema, field.Type
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			target, elemType = items, derefType(field.Type).Elem()
+		}
+
+		values := []interface{}{}
+		for _, item := range strings.Split(enum, ",") {
+			value, err := parseTagValue(elemType, strings.TrimSpace(item))
+			if err != nil {
+				return fmt.Errorf("invalid enum value %q: %w", item, err)
+			}
+			values = append(values, value)
+		}
+		target["enum"] = values
+	}
+
+	minKey, maxKey := "minimum", "maximum"
+	switch schema["type"] {
+	case "string":
+		minKey, maxKey = "minLength", "maxLength"
+	case "array":
+		minKey, maxKey = "minItems", "maxItems"
+	case "object":
+		minKey, maxKey = "minProperties", "maxProperties"
+	}
+	for key, tag := range map[string]string{minKey: "min", maxKey: "max"} {
+		if value := field.Tag.Get(tag); value != "" {
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q: %w", tag, value, err)
+			}
+			schema[key] = n
+		}
+	}
+
+	if def := field.Tag.Get("default"); def != "" {
+		value, err := parseTagValue(field.Type, def)
+		if err != nil {
+			return fmt.Errorf("invalid default %q: %w", def, err)
+		}
+		schema["default"] = value
+	}
+
+	return nil
+}
+
+// parseTagValue decodes a tag value for a field of type t. Strings are used
+// as is; anything else is parsed as JSON.
+func parseTagValue(t reflect.Type, value string) (interface{}, error) {
+	t = derefType(t)
+	if t.Kind() == reflect.String {
+		return value, nil
+	}
+
+	target := reflect.New(t)
+	if err := json.Unmarshal([]byte(value), target.Interface()); err != nil {
+		return nil, err
+	}
+	return target.Elem().Interface(), nil
+}
+
+// applyDefaults sets the default tag values on a struct, including nested
+// structs, before parameters are decoded over it
+func applyDefaults(v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return eachFieldValue(v, func(field reflect.StructField, value reflect.Value) error {
+		if def := field.Tag.Get("default"); def != "" {
+			parsed, err := parseTagValue(field.Type, def)
+			if err != nil {
+				return fmt.Errorf("invalid default for %s: %w", field.Name, err)
+			}
+			target := value
+			if target.Kind() == reflect.Pointer {
+				target.Set(reflect.New(target.Type().Elem()))
+				target = target.Elem()
+			}
+			target.Set(reflect.ValueOf(parsed).Convert(target.Type()))
+			return nil
+		}
+		return applyDefaults(value)
+	})
+}
+
+// eachFieldValue calls fn with every encoded field of a struct value,
+// flattening embedded structs like eachField. Nil embedded struct pointers
+// are allocated so their fields can be set.
+func eachFieldValue(v reflect.Value, fn func(field reflect.StructField, value reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("json") == "-" {
+			continue
+		}
+
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); field.Anonymous && name == "" {
+			embedded := v.Field(i)
+			if embedded.Kind() == reflect.Pointer && embedded.Type().Elem().Kind() == reflect.Struct {
+				// encoding/json cannot set pointers to unexported structs either
+				if embedded.IsNil() && !embedded.CanSet() {
+					continue
+				}
+				if embedded.IsNil() {
+					embedded.Set(reflect.New(embedded.Type().Elem()))
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := eachFieldValue(embedded, fn); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if err := fn(field, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// derefType returns the type a pointer type points to, or t itself
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
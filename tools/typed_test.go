@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type greetOptions struct {
+	Punctuation string `json:"punctuation,omitempty" default:"!"`
+}
+
+type greetParams struct {
+	Name     string            `json:"name" desc:"Who to greet" min:"1"`
+	Greeting string            `json:"greeting,omitempty" desc:"Greeting to use" enum:"hello,hi" default:"hello"`
+	Times    int               `json:"times,omitempty" min:"1" max:"3" default:"1"`
+	Tags     []string          `json:"tags,omitempty" enum:"formal,casual" max:"2"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Options  greetOptions      `json:"options,omitempty"`
+	Note     *string           `json:"note"`
+	internal string
+}
+
+func newGreetTool() *TypedTool[greetParams, string] {
+	return NewTypedTool("greet", "Greet someone", func(ctx context.Context, p greetParams) (string, error) {
+		return strings.Repeat(p.Greeting+" "+p.Name+p.Options.Punctuation+" ", p.Times), nil
+	})
+}
+
+func TestStructSchema(t *testing.T) {
+	schema := newGreetTool().Schema()
+	if err := CheckSchema(schema); err != nil {
+		t.Fatalf("Generated schema is invalid: %v", err)
+	}
+
+	normalized, _ := normalizeSchema(schema)
+	got, _ := json.Marshal(normalized)
+	want := `{"additionalProperties":false,"properties":{` +
+		`"greeting":{"default":"hello","description":"Greeting to use","enum":["hello","hi"],"type":"string"},` +
+		`"labels":{"additionalProperties":{"type":"string"},"type":"object"},` +
+		`"name":{"description":"Who to greet","minLength":1,"type":"string"},` +
+		`"note":{"type":"string"},` +
+		`"options":{"additionalProperties":false,"properties":{"punctuation":{"default":"!","type":"string"}},"type":"object"},` +
+		`"tags":{"items":{"enum":["formal","casual"],"type":"string"},"maxItems":2,"type":"array"},` +
+		`"times":{"default":1,"maximum":3,"minimum":1,"type":"integer"}},` +
+		`"required":["name"],"type":"object"}`
+	if string(got) != want {
+		t.Errorf("Unexpected schema:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestTypedTool_ExecuteAppliesDefaults(t *testing.T) {
+	tool := newGreetTool()
+
+	params := json.RawMessage(`{"name": "Ada"}`)
+	if err := tool.Validate(params); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result != "hello Ada! " {
+		t.Errorf("Expected defaults to be applied, got %q", result)
+	}
+
+	result, _ = tool.Execute(context.Background(), json.RawMessage(`{"name": "Ada", "greeting": "hi", "times": 2, "options": {"punctuation": "."}}`))
+	if result != "hi Ada. hi Ada. " {
+		t.Errorf("Expected given values to override defaults, got %q", result)
+	}
+}
+
+func TestTypedTool_ExecuteAppliesEmbeddedPointerDefaults(t *testing.T) {
+	type Common struct {
+		Limit int    `json:"limit,omitempty" default:"10"`
+		Sort  string `json:"sort,omitempty" default:"name"`
+	}
+	type params struct {
+		*Common
+		Query string `json:"query"`
+	}
+
+	tool := NewTypedTool("search", "Search", func(ctx context.Context, p params) (string, error) {
+		return fmt.Sprintf("%s %d %s", p.Query, p.Limit, p.Sort), nil
+	})
+
+	result, err := tool.Execute(context.Background(), json.RawMessage(`{"query": "go"}`))
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result != "go 10 name" {
+		t.Errorf("Expected embedded defaults to be applied, got %q", result)
+	}
+
+	result, _ = tool.Execute(context.Background(), json.RawMessage(`{"query": "go", "limit": 3}`))
+	if result != "go 3 name" {
+		t.Errorf("Expected given values to override embedded defaults, got %q", result)
+	}
+}
+
+func TestTypedTool_Validate(t *testing.T) {
+	tool := newGreetTool()
+
+	tests := []struct {
+		params string
+		errAt  string
+	}{
+		{`{}`, "name: is required"},
+		{`{"name": "Ada", "greeting": "hey"}`, "greeting: must be one of"},
+		{`{"name": "Ada", "times": 4}`, "times: must be <= 3"},
+		{`{"name": "Ada", "tags": ["rude"]}`, "tags[0]: must be one of"},
+		{`{"name": "Ada", "extra": true}`, "extra: unknown property"},
+	}
+	for _, tt := range tests {
+		err := tool.Validate(json.RawMessage(tt.params))
+		if err == nil || !strings.HasPrefix(err.Error(), tt.errAt) {
+			t.Errorf("Validate(%s): expected %q, got %v", tt.params, tt.errAt, err)
+		}
+	}
+}
+
+func TestTypedTool_InRegistry(t *testing.T) {
+	registry := NewToolRegistry()
+	if err := registry.Register(newGreetTool()); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	result := registry.Execute(context.Background(), "greet", json.RawMessage(`{"name": "Bob"}`))
+	if !result.Success || result.Data != "hello Bob! " {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if _, err := registry.MCPTools(); err != nil {
+		t.Errorf("Expected typed tool to export, got %v", err)
+	}
+}
+
+func TestNewTypedTool_InvalidParams(t *testing.T) {
+	tests := map[string]func(){
+		"not a struct": func() {
+			NewTypedTool("bad", "", func(ctx context.Context, p string) (string, error) { return p, nil })
+		},
+		"invalid default": func() {
+			type params struct {
+				Count int `json:"count" default:"many"`
+			}
+			NewTypedTool("bad", "", func(ctx context.Context, p params) (int, error) { return p.Count, nil })
+		},
+		"unsupported type": func() {
+			type params struct {
+				Done chan bool `json:"done"`
+			}
+			NewTypedTool("bad", "", func(ctx context.Context, p params) (bool, error) { return true, nil })
+		},
+	}
+
+	for name, fn := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Expected NewTypedTool to panic")
+				}
+			}()
+			fn()
+		})
+	}
+}
+
+func TestStructSchema_Embedded(t *testing.T) {
+	type Base struct {
+		Path string `json:"path"`
+	}
+	type params struct {
+		Base
+		Force bool `json:"force,omitempty"`
+		Skip  bool `json:"-"`
+	}
+
+	schema, err := StructSchema[params]()
+	if err != nil {
+		t.Fatalf("StructSchema failed: %v", err)
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	if len(properties) != 2 || properties["path"] == nil || properties["force"] == nil {
+		t.Errorf("Expected embedded fields to be flattened, got %v", properties)
+	}
+	if !reflect.DeepEqual(schema["required"], []string{"path"}) {
+		t.Errorf("Expected path to be required, got %v", schema["required"])
+	}
+}
@@ -0,0 +1,501 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ignoreFiles are read in every directory during a walk, in order, so that
+// rules in .ignore take precedence over those in .gitignore
+var ignoreFiles = []string{".gitignore", ".ignore"}
+
+// binarySniffSize is how much of a file is checked for NUL bytes to decide
+// whether it is binary, as git does
+const binarySniffSize = 8000
+
+// WalkOptions control Walk
+type WalkOptions struct {
+	Patterns       []string          // MatchPath patterns for paths relative to the root; empty matches everything
+	CaseSensitive  bool              // Match patterns case-sensitively
+	MaxDepth       int               // Deepest level to visit, where the root's entries are level 1; 0 means unlimited
+	Hidden         bool              // Visit files and directories whose names start with a dot
+	NoIgnore       bool              // Visit paths excluded by .gitignore and .ignore files
+	FollowSymlinks bool              // Descend into symlinked directories and report link targets
+	SkipBinary     bool              // Skip files that contain NUL bytes
+	IncludeDirs    bool              // Report matching directories as well as files
+	Workers        int               // Goroutines reading directories; defaults to GOMAXPROCS
+	Filter         func(string) bool // If set, paths it rejects are skipped, and directories are not entered
+}
+
+// WalkEntry is a file or directory found by Walk
+type WalkEntry struct {
+	Path string      // The root joined with Rel
+	Rel  string      // Slash-separated path relative to the root
+	Info os.FileInfo // From Lstat, or Stat for followed symlinks
+}
+
+// Walk visits the files below root that match opts, calling fn for each.
+// Directories are read by a pool of workers and fn is called from them, so
+// it must be safe for concurrent use and entries arrive in no particular
+// order. Returning filepath.SkipAll from fn stops the walk without an error;
+// any other error stops it and is returned.
+//
+// Patterns use MatchPath syntax. Directories that cannot contain a match are
+// not read. Unless NoIgnore is set, .gitignore and .ignore files are honored
+// in every directory below root, and in the directories between root and the
+// enclosing git repository's top level; .git directories are always skipped.
+// Hidden names are skipped unless Hidden is set or a pattern names a hidden
+// path explicitly, such as "**/.env". Symlinked directories are followed only
+// with FollowSymlinks, and a link back to one of its own parents is skipped.
+// Unreadable entries are skipped.
+func Walk(ctx context.Context, root string, opts WalkOptions, fn func(WalkEntry) error) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &fs.PathError{Op: "walk", Path: root, Err: errors.New("not a directory")}
+	}
+
+	w := &walker{opts: opts, fn: fn}
+	for _, pattern := range opts.Patterns {
+		compiled := compilePattern(pattern, !opts.CaseSensitive)
+		w.patterns = append(w.patterns, compiled)
+		if strings.HasPrefix(pattern, ".") || strings.Contains(pattern, "/.") {
+			w.opts.Hidden = true
+		}
+	}
+	if !opts.NoIgnore {
+		if abs, err := filepath.Abs(root); err == nil {
+			w.base = abs
+			w.ignore = parentIgnores(abs)
+		}
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w.cancel = cancel
+
+	w.queue.cond = sync.NewCond(&w.queue.mu)
+	w.queue.push(walkDir{path: root, ignore: w.ignore, parents: &dirChain{info: info}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				dir, ok := w.queue.pop()
+				if !ok {
+					return
+				}
+				if err := ctx.Err(); err != nil {
+					w.fail(err)
+				} else {
+					w.readDir(dir)
+				}
+				w.queue.done()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if errors.Is(w.err, filepath.SkipAll) {
+		return nil
+	}
+	return w.err
+}
+
+// walker holds the state shared by the workers of one Walk
+type walker struct {
+	opts     WalkOptions
+	fn       func(WalkEntry) error
+	patterns []pathPattern
+	base     string       // Absolute root, used to match ignore rules
+	ignore   *ignoreChain // Rules from the root's parents
+	queue    walkQueue
+	cancel   context.CancelFunc
+
+	mu  sync.Mutex
+	err error
+}
+
+// walkDir is a directory waiting to be read
+type walkDir struct {
+	path    string
+	rel     string
+	depth   int
+	ignore  *ignoreChain
+	parents *dirChain
+}
+
+// dirChain lists a directory and its parents, to detect symlink loops
+type dirChain struct {
+	info   os.FileInfo
+	parent *dirChain
+}
+
+// contains reports whether info is the same directory as one in the chain
+func (c *dirChain) contains(info os.FileInfo) bool {
+	for ; c != nil; c = c.parent {
+		if os.SameFile(c.info, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// fail records the first error and stops the walk
+func (w *walker) fail(err error) {
+	w.mu.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.mu.Unlock()
+	w.cancel()
+	w.queue.stop()
+}
+
+// readDir reports the entries of dir and queues its subdirectories
+func (w *walker) readDir(dir walkDir) {
+	entries, err := os.ReadDir(dir.path)
+	if err != nil {
+		return // Skip unreadable directories
+	}
+
+	ignore := dir.ignore
+	if !w.opts.NoIgnore {
+		ignore = loadIgnores(dir.path, ignore)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == ".git" || (strings.HasPrefix(name, ".") && !w.opts.Hidden) {
+			continue
+		}
+
+		path := filepath.Join(dir.path, name)
+		rel := name
+		if dir.rel != "" {
+			rel = dir.rel + "/" + name
+		}
+		if w.opts.Filter != nil && !w.opts.Filter(path) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if w.opts.FollowSymlinks && info.Mode()&os.ModeSymlink != 0 {
+			if info, err = os.Stat(path); err != nil {
+				continue // Skip broken links
+			}
+		}
+
+		isDir := info.IsDir()
+		if ignore != nil && ignore.ignores(filepath.Join(w.base, filepath.FromSlash(rel)), isDir) {
+			continue
+		}
+
+		if isDir {
+			if dir.parents.contains(info) {
+				continue // A symlink back to a parent
+			}
+			depth := dir.depth + 1
+			if (w.opts.MaxDepth == 0 || depth < w.opts.MaxDepth) && w.matchesBelow(rel) {
+				w.queue.push(walkDir{
+					path:    path,
+					rel:     rel,
+					depth:   depth,
+					ignore:  ignore,
+					parents: &dirChain{info: info, parent: dir.parents},
+				})
+			}
+			if !w.opts.IncludeDirs {
+				continue
+			}
+		}
+
+		if !w.matches(rel) {
+			continue
+		}
+		if !isDir && w.opts.SkipBinary && isBinaryFile(path) {
+			continue
+		}
+
+		if err := w.fn(WalkEntry{Path: path, Rel: rel, Info: info}); err != nil {
+			w.fail(err)
+			return
+		}
+	}
+}
+
+// matches reports whether rel matches one of the patterns
+func (w *walker) matches(rel string) bool {
+	if len(w.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range w.patterns {
+		if pattern.match(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesBelow reports whether a path below the directory rel could match
+func (w *walker) matchesBelow(rel string) bool {
+	if len(w.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range w.patterns {
+		if pattern.matchesBelow(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkQueue holds the directories waiting to be read. Workers take the most
+// recently queued directory, so the walk goes depth first and the queue stays
+// short.
+type walkQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	dirs    []walkDir
+	pending int  // Directories queued or being read
+	stopped bool // Set once every directory is read, or the walk fails
+}
+
+// push queues dir
+func (q *walkQueue) push(dir walkDir) {
+	q.mu.Lock()
+	q.dirs = append(q.dirs, dir)
+	q.pending++
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+// pop waits for a directory to read. It returns false once the walk is over.
+func (q *walkQueue) pop() (walkDir, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.dirs) == 0 && !q.stopped {
+		q.cond.Wait()
+	}
+	if q.stopped {
+		return walkDir{}, false
+	}
+	dir := q.dirs[len(q.dirs)-1]
+	q.dirs = q.dirs[:len(q.dirs)-1]
+	return dir, true
+}
+
+// done marks a popped directory as read, ending the walk after the last one
+func (q *walkQueue) done() {
+	q.mu.Lock()
+	q.pending--
+	if q.pending == 0 {
+		q.stopped = true
+	}
+	q.mu.Unlock()
+	if q.stopped {
+		q.cond.Broadcast()
+	}
+}
+
+// stop ends the walk early
+func (q *walkQueue) stop() {
+	q.mu.Lock()
+	q.stopped = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// ignoreRule is one pattern from a .gitignore or .ignore file
+type ignoreRule struct {
+	pattern []string // Path segments; unanchored patterns start with "**"
+	negate  bool     // The rule starts with "!" and re-includes paths
+	dirOnly bool     // The rule ends with "/" and only matches directories
+}
+
+// ignoreChain holds the ignore rules of a directory and its parents
+type ignoreChain struct {
+	dir    string // Absolute directory the rules are relative to
+	rules  []ignoreRule
+	parent *ignoreChain
+}
+
+// ignores reports whether the absolute path is excluded. Rules in deeper
+// directories, and later rules in the same directory, take precedence.
+func (c *ignoreChain) ignores(path string, isDir bool) bool {
+	ignored, _ := c.match(path, isDir)
+	return ignored
+}
+
+// match evaluates the rules for path, returning whether any rule matched
+func (c *ignoreChain) match(path string, isDir bool) (ignored, matched bool) {
+	if c == nil {
+		return false, false
+	}
+	ignored, matched = c.parent.match(path, isDir)
+
+	rel, ok := within(c.dir, path)
+	if !ok || rel == "." {
+		return ignored, matched
+	}
+	segments := splitPath(filepath.ToSlash(rel))
+	for _, rule := range c.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(rule.pattern, segments) {
+			ignored, matched = !rule.negate, true
+		}
+	}
+	return ignored, matched
+}
+
+// loadIgnores returns parent extended with the ignore files in dir, or
+// parent itself if dir has none
+func loadIgnores(dir string, parent *ignoreChain) *ignoreChain {
+	var rules []ignoreRule
+	for _, name := range ignoreFiles {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		rules = append(rules, parseIgnore(file)...)
+		file.Close()
+	}
+	if len(rules) == 0 {
+		return parent
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return parent
+	}
+	return &ignoreChain{dir: abs, rules: rules, parent: parent}
+}
+
+// parentIgnores loads the ignore files in the parents of root, up to the
+// top level of the git repository containing it. Outside a repository, or
+// at its top level, there are none.
+func parentIgnores(root string) *ignoreChain {
+	var dirs []string
+	for dir := root; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil // Not in a repository
+		}
+		dir = parent
+		dirs = append(dirs, dir)
+	}
+
+	var chain *ignoreChain
+	for i := len(dirs) - 1; i >= 0; i-- {
+		chain = loadIgnores(dirs[i], chain)
+	}
+	return chain
+}
+
+// parseIgnore reads rules in .gitignore syntax
+func parseIgnore(r io.Reader) []ignoreRule {
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// A slash anywhere but the end anchors the pattern to the directory
+		// of the ignore file; otherwise it matches at any depth
+		if strings.Contains(line, "/") {
+			rule.pattern = splitPath(strings.TrimPrefix(line, "/"))
+		} else {
+			rule.pattern = []string{"**", line}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// hasGlob reports whether pattern uses glob syntax
+func hasGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[{")
+}
+
+// splitGlob splits pattern into the directory before its first segment with
+// glob syntax, and the rest of the pattern relative to that directory. A
+// pattern without glob syntax is returned as base with an empty rest.
+func splitGlob(pattern string) (base, rest string) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for i, segment := range segments {
+		if !hasGlob(segment) {
+			continue
+		}
+		base = strings.Join(segments[:i], "/")
+		switch {
+		case i == 0:
+			base = "."
+		case base == "":
+			base = "/"
+		}
+		return filepath.FromSlash(base), strings.Join(segments[i:], "/")
+	}
+	return pattern, ""
+}
+
+// isBinaryFile reports whether the start of the file at path contains a NUL
+// byte. Files that cannot be read are not binary.
+func isBinaryFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	buf := make([]byte, binarySniffSize)
+	n, _ := io.ReadFull(file, buf)
+	return looksBinary(buf[:n])
+}
+
+// looksBinary reports whether data contains a NUL byte
+func looksBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0
+}
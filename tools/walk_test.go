@@ -0,0 +1,429 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// walkRels walks root and returns the sorted relative paths it reports
+func walkRels(t *testing.T, root string, opts WalkOptions) []string {
+	t.Helper()
+
+	var mu sync.Mutex
+	var rels []string
+	err := Walk(context.Background(), root, opts, func(entry WalkEntry) error {
+		mu.Lock()
+		rels = append(rels, entry.Rel)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	sort.Strings(rels)
+	return rels
+}
+
+func TestWalk_Patterns(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go":            "",
+		"README.md":          "",
+		"cmd/agar/main.go":   "",
+		"cmd/agar/notes.txt": "",
+		"docs/guide.md":      "",
+		"docs/img/logo.png":  "",
+	})
+
+	tests := []struct {
+		patterns []string
+		want     string
+	}{
+		{nil, "README.md cmd/agar/main.go cmd/agar/notes.txt docs/guide.md docs/img/logo.png main.go"},
+		{[]string{"*.go"}, "main.go"},
+		{[]string{"**/*.go"}, "cmd/agar/main.go main.go"},
+		{[]string{"**/*.{go,md}"}, "README.md cmd/agar/main.go docs/guide.md main.go"},
+		{[]string{"docs/**"}, "docs/guide.md docs/img/logo.png"},
+		{[]string{"cmd/*/main.go", "*.md"}, "README.md cmd/agar/main.go"},
+		{[]string{"**/readme.MD"}, "README.md"},
+	}
+
+	for _, tt := range tests {
+		got := strings.Join(walkRels(t, dir, WalkOptions{Patterns: tt.patterns}), " ")
+		if got != tt.want {
+			t.Errorf("Patterns %v: expected %q, got %q", tt.patterns, tt.want, got)
+		}
+	}
+
+	got := walkRels(t, dir, WalkOptions{Patterns: []string{"**/readme.MD"}, CaseSensitive: true})
+	if len(got) != 0 {
+		t.Errorf("Expected no case-sensitive matches, got %v", got)
+	}
+}
+
+func TestWalk_Gitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".gitignore":                  "# build output\n*.log\n/build/\nnode_modules/\n!keep.log\n",
+		"app.go":                      "",
+		"debug.log":                   "",
+		"keep.log":                    "",
+		"build/out.bin":               "",
+		"node_modules/lib/index.js":   "",
+		"src/build/gen.go":            "",
+		"src/trace.log":               "",
+		"src/.gitignore":              "gen.go\n",
+		"vendor/.ignore":              "*\n!*.go\n",
+		"vendor/lib.go":               "",
+		"vendor/lib.c":                "",
+		"web/node_modules/x/index.js": "",
+	})
+
+	got := strings.Join(walkRels(t, dir, WalkOptions{}), " ")
+	want := "app.go keep.log vendor/lib.go"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	got = strings.Join(walkRels(t, dir, WalkOptions{NoIgnore: true, Patterns: []string{"**/*.log"}}), " ")
+	want = "debug.log keep.log src/trace.log"
+	if got != want {
+		t.Errorf("Expected ignored files with NoIgnore, got %q", got)
+	}
+}
+
+func TestWalk_ParentGitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".git/HEAD":       "ref: refs/heads/main\n",
+		".gitignore":      "*.tmp\nsrc/generated/\n",
+		"src/a.go":        "",
+		"src/a.tmp":       "",
+		"src/generated/b": "",
+	})
+
+	got := strings.Join(walkRels(t, filepath.Join(dir, "src"), WalkOptions{}), " ")
+	if got != "a.go" {
+		t.Errorf("Expected the repository's .gitignore to apply below it, got %q", got)
+	}
+}
+
+func TestWalk_Hidden(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.txt":         "",
+		".env":          "",
+		".config/b.txt": "",
+		".git/config":   "",
+	})
+
+	if got := strings.Join(walkRels(t, dir, WalkOptions{}), " "); got != "a.txt" {
+		t.Errorf("Expected hidden files to be skipped, got %q", got)
+	}
+	if got := strings.Join(walkRels(t, dir, WalkOptions{Hidden: true}), " "); got != ".config/b.txt .env a.txt" {
+		t.Errorf("Expected hidden files but not .git, got %q", got)
+	}
+	if got := strings.Join(walkRels(t, dir, WalkOptions{Patterns: []string{"**/.env"}}), " "); got != ".env" {
+		t.Errorf("Expected a pattern naming a hidden file to match it, got %q", got)
+	}
+}
+
+func TestWalk_SkipBinary(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"text.txt":  "hello\n",
+		"image.bin": "PNG\x00\x01\x02",
+	})
+
+	if got := strings.Join(walkRels(t, dir, WalkOptions{SkipBinary: true}), " "); got != "text.txt" {
+		t.Errorf("Expected binary files to be skipped, got %q", got)
+	}
+	if got := walkRels(t, dir, WalkOptions{}); len(got) != 2 {
+		t.Errorf("Expected binary files to be reported by default, got %v", got)
+	}
+}
+
+func TestWalk_MaxDepthAndDirs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.txt":     "",
+		"sub/b.txt": "",
+	})
+
+	got := strings.Join(walkRels(t, dir, WalkOptions{MaxDepth: 1, IncludeDirs: true}), " ")
+	if got != "a.txt sub" {
+		t.Errorf("Expected only the first level, got %q", got)
+	}
+}
+
+func TestWalk_SymlinkLoop(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"sub/a.txt": ""})
+	if err := os.Symlink("..", filepath.Join(dir, "sub", "up")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink("sub", filepath.Join(dir, "alias")); err != nil {
+		t.Fatalf("Symlink failed: %v", err)
+	}
+
+	got := strings.Join(walkRels(t, dir, WalkOptions{FollowSymlinks: true}), " ")
+	if got != "alias/a.txt sub/a.txt" {
+		t.Errorf("Expected the loop to be skipped and the alias followed, got %q", got)
+	}
+
+	got = strings.Join(walkRels(t, dir, WalkOptions{}), " ")
+	if got != "alias sub/a.txt sub/up" {
+		t.Errorf("Expected links to be reported without following them, got %q", got)
+	}
+}
+
+func TestWalk_Filter(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.txt":          "",
+		"secret/b.txt":   "",
+		"public/c.txt":   "",
+		"public/key.pem": "",
+	})
+
+	opts := WalkOptions{Filter: func(path string) bool {
+		return filepath.Base(path) != "secret" && filepath.Ext(path) != ".pem"
+	}}
+	if got := strings.Join(walkRels(t, dir, opts), " "); got != "a.txt public/c.txt" {
+		t.Errorf("Expected filtered paths to be skipped, got %q", got)
+	}
+}
+
+func TestWalk_Stop(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{}
+	for i := 0; i < 50; i++ {
+		files[fmt.Sprintf("d%d/f.txt", i)] = ""
+	}
+	writeFiles(t, dir, files)
+
+	var mu sync.Mutex
+	count := 0
+	err := Walk(context.Background(), dir, WalkOptions{}, func(entry WalkEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		count++
+		if count == 5 {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected SkipAll to stop the walk without an error, got %v", err)
+	}
+	if count >= 50 {
+		t.Errorf("Expected the walk to stop early, visited %d files", count)
+	}
+
+	boom := errors.New("boom")
+	err = Walk(context.Background(), dir, WalkOptions{}, func(entry WalkEntry) error {
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("Expected the callback error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Walk(ctx, dir, WalkOptions{}, func(WalkEntry) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled walk to fail, got %v", err)
+	}
+}
+
+func TestWalk_NotDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.txt": ""})
+
+	if err := Walk(context.Background(), filepath.Join(dir, "a.txt"), WalkOptions{}, func(WalkEntry) error { return nil }); err == nil {
+		t.Error("Expected an error walking a file")
+	}
+	if err := Walk(context.Background(), filepath.Join(dir, "missing"), WalkOptions{}, func(WalkEntry) error { return nil }); !os.IsNotExist(err) {
+		t.Errorf("Expected a not exist error, got %v", err)
+	}
+}
+
+func TestParseIgnore(t *testing.T) {
+	rules := parseIgnore(strings.NewReader("# comment\n\n*.log  \n!important.log\n/dist/\ndocs/*.pdf\n\\#hash\n"))
+
+	want := []ignoreRule{
+		{pattern: []string{"**", "*.log"}},
+		{pattern: []string{"**", "important.log"}, negate: true},
+		{pattern: []string{"dist"}, dirOnly: true},
+		{pattern: []string{"docs", "*.pdf"}},
+		{pattern: []string{"**", "#hash"}},
+	}
+	if fmt.Sprint(rules) != fmt.Sprint(want) {
+		t.Errorf("Expected rules %v, got %v", want, rules)
+	}
+}
+
+func TestSplitGlob(t *testing.T) {
+	tests := []struct {
+		pattern, base, rest string
+	}{
+		{"*.go", ".", "*.go"},
+		{"src/**/*.go", "src", "**/*.go"},
+		{"/tmp/x/*.txt", filepath.FromSlash("/tmp/x"), "*.txt"},
+		{"/*.txt", filepath.FromSlash("/"), "*.txt"},
+		{"a/{b,c}/d", "a", "{b,c}/d"},
+		{"plain/file.txt", "plain/file.txt", ""},
+	}
+
+	for _, tt := range tests {
+		base, rest := splitGlob(tt.pattern)
+		if base != tt.base || rest != tt.rest {
+			t.Errorf("splitGlob(%q) = %q, %q, want %q, %q", tt.pattern, base, rest, tt.base, tt.rest)
+		}
+	}
+}
+
+func TestTools_RespectGitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".gitignore":                "node_modules/\n",
+		"main.go":                   "needle\n",
+		"node_modules/pkg/index.js": "needle\n",
+		"data.bin":                  "needle\x00\n",
+	})
+	ctx := context.Background()
+
+	globResult, err := NewGlobTool().Execute(ctx, json.RawMessage(fmt.Sprintf(`{"patterns": ["**/*"], "path": %q}`, dir)))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	if count := globResult.(*GlobResult).Count; count != 2 {
+		t.Errorf("Expected glob to find main.go and data.bin, got %+v", globResult.(*GlobResult).Matches)
+	}
+
+	listResult, err := NewListTool().Execute(ctx, json.RawMessage(fmt.Sprintf(`{"path": %q, "recursive": true, "hidden": false, "no_ignore": false}`, dir)))
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if count := listResult.(*ListResult).Count; count != 2 {
+		t.Errorf("Expected filtered list to find main.go and data.bin, got %+v", listResult.(*ListResult).Files)
+	}
+
+	// List shows ignored and hidden paths unless asked not to
+	listResult, err = NewListTool().Execute(ctx, json.RawMessage(fmt.Sprintf(`{"path": %q, "recursive": true}`, dir)))
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if count := listResult.(*ListResult).Count; count != 6 {
+		t.Errorf("Expected list to include .gitignore and node_modules, got %+v", listResult.(*ListResult).Files)
+	}
+
+	searchResult, err := NewSearchTool().Execute(ctx, json.RawMessage(fmt.Sprintf(`{"pattern": "needle", "path": %q, "recursive": true}`, dir)))
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if matches := searchResult.(*SearchResult).Matches; len(matches) != 1 || filepath.Base(matches[0].File) != "main.go" {
+		t.Errorf("Expected search to match main.go only, got %+v", matches)
+	}
+
+	grepResult, err := NewGrepTool().Execute(ctx, json.RawMessage(fmt.Sprintf(`{"pattern": "needle", "files": [%q]}`, filepath.Join(dir, "**"))))
+	if err != nil {
+		t.Fatalf("Grep failed: %v", err)
+	}
+	if matches := grepResult.(*GrepResult).Matches; len(matches) != 1 || filepath.Base(matches[0].File) != "main.go" {
+		t.Errorf("Expected grep to match main.go only, got %+v", matches)
+	}
+}
+
+// benchmarkTree creates a tree of 100 directories of 100 files, with an
+// ignored node_modules directory of the same size, once per benchmark
+func benchmarkTree(b *testing.B) string {
+	b.Helper()
+
+	dir := b.TempDir()
+	files := map[string]string{".gitignore": "node_modules/\n"}
+	for d := 0; d < 100; d++ {
+		for f := 0; f < 100; f++ {
+			content := fmt.Sprintf("package p%d\n\nfunc F%d() {}\n", d, f)
+			files[fmt.Sprintf("src/pkg%d/file%d.go", d, f)] = content
+			files[fmt.Sprintf("node_modules/mod%d/file%d.js", d, f)] = content
+		}
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func BenchmarkWalk(b *testing.B) {
+	dir := benchmarkTree(b)
+
+	for _, workers := range []int{1, 0} {
+		name := fmt.Sprintf("workers=%d", workers)
+		if workers == 0 {
+			name = "workers=default"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				err := Walk(context.Background(), dir, WalkOptions{Patterns: []string{"**/*.go"}, Workers: workers}, func(WalkEntry) error {
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGlobTool(b *testing.B) {
+	dir := benchmarkTree(b)
+	tool := NewGlobTool()
+	params := json.RawMessage(fmt.Sprintf(`{"patterns": ["**/*.go"], "path": %q}`, dir))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tool.Execute(context.Background(), params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSearchTool(b *testing.B) {
+	dir := benchmarkTree(b)
+	tool := NewSearchTool()
+	params := json.RawMessage(fmt.Sprintf(`{"pattern": "func F4\\d\\(", "path": %q, "recursive": true}`, dir))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tool.Execute(context.Background(), params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGrepTool(b *testing.B) {
+	dir := benchmarkTree(b)
+	tool := NewGrepTool()
+	params := json.RawMessage(fmt.Sprintf(`{"pattern": "func F4\\d\\(", "files": [%q]}`, filepath.Join(dir, "src", "**", "*.go")))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tool.Execute(context.Background(), params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinkDepth bounds how many symlinks are followed when resolving a path
+const maxSymlinkDepth = 40
+
+// DefaultDenyPatterns are deny patterns suitable for most repositories
+var DefaultDenyPatterns = []string{".git/**", "**/.env"}
+
+// Workspace confines filesystem tools to one or more root directories. Paths
+// must lie inside a root both as written and after resolving symlinks, and
+// must not match a deny pattern. A nil *Workspace allows every path.
+type Workspace struct {
+	roots []workspaceRoot
+	deny  []string
+}
+
+// workspaceRoot is an allowed root directory
+type workspaceRoot struct {
+	path string // Absolute path as configured
+	real string // Absolute path with symlinks resolved
+}
+
+// NewWorkspace creates a workspace with the given root directories. Each root
+// must exist and be a directory.
+func NewWorkspace(roots ...string) (*Workspace, error) {
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("workspace needs at least one root")
+	}
+
+	w := &Workspace{}
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace root %s: %w", root, err)
+		}
+		real, err := filepath.EvalSymlinks(abs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace root %s: %w", root, err)
+		}
+		info, err := os.Stat(real)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace root %s: %w", root, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("invalid workspace root %s: not a directory", root)
+		}
+		w.roots = append(w.roots, workspaceRoot{path: abs, real: real})
+	}
+	return w, nil
+}
+
+// Deny adds MatchPath patterns for paths the tools may not touch, such as
+// ".git/**" or "**/.env". Patterns are matched against paths relative to
+// their workspace root.
+func (w *Workspace) Deny(patterns ...string) *Workspace {
+	w.deny = append(w.deny, patterns...)
+	return w
+}
+
+// Roots returns the absolute root directories
+func (w *Workspace) Roots() []string {
+	if w == nil {
+		return nil
+	}
+	roots := make([]string, len(w.roots))
+	for i, root := range w.roots {
+		roots[i] = root.path
+	}
+	return roots
+}
+
+// Check returns an error naming p if it is outside the workspace, escapes it
+// through a symlink, or matches a deny pattern. Relative paths are resolved
+// against the working directory. p does not need to exist.
+func (w *Workspace) Check(p string) error {
+	if w == nil {
+		return nil
+	}
+	if err := w.checkName(p); err != nil {
+		return err
+	}
+
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return fmt.Errorf("cannot resolve path %s: %w", p, err)
+	}
+	real, err := realPath(abs, 0)
+	if err != nil {
+		return fmt.Errorf("cannot resolve path %s: %w", p, err)
+	}
+	realRel, ok := w.relative(real)
+	if !ok {
+		return fmt.Errorf("path %s escapes the workspace through a symlink to %s", p, real)
+	}
+	if pattern, denied := w.denied(realRel); denied {
+		return fmt.Errorf("path %s is denied by the workspace pattern %q", p, pattern)
+	}
+
+	return nil
+}
+
+// Allows reports whether Check accepts p
+func (w *Workspace) Allows(p string) bool {
+	return w.Check(p) == nil
+}
+
+// IsRoot reports whether p is one of the workspace roots
+func (w *Workspace) IsRoot(p string) bool {
+	if w == nil {
+		return false
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return false
+	}
+	for _, root := range w.roots {
+		if abs == root.path || abs == root.real {
+			return true
+		}
+	}
+	return false
+}
+
+// checkName checks p as written, without resolving symlinks
+func (w *Workspace) checkName(p string) error {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return fmt.Errorf("cannot resolve path %s: %w", p, err)
+	}
+
+	rel, ok := w.relative(abs)
+	if !ok {
+		return fmt.Errorf("path %s is outside the workspace", p)
+	}
+	if pattern, denied := w.denied(rel); denied {
+		return fmt.Errorf("path %s is denied by the workspace pattern %q", p, pattern)
+	}
+	return nil
+}
+
+// relative returns abs relative to the root containing it
+func (w *Workspace) relative(abs string) (string, bool) {
+	for _, root := range w.roots {
+		for _, base := range []string{root.path, root.real} {
+			if rel, ok := within(base, abs); ok {
+				return rel, true
+			}
+		}
+	}
+	return "", false
+}
+
+// denied returns the first deny pattern matching a root-relative path
+func (w *Workspace) denied(rel string) (string, bool) {
+	if rel == "." {
+		return "", false
+	}
+	for _, pattern := range w.deny {
+		if MatchPath(pattern, rel) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// within returns target relative to base if target is base or below it
+func within(base, target string) (string, bool) {
+	rel, err := filepath.Rel(base, target)
+	if err != nil {
+		return "", false
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// realPath resolves the symlinks in an absolute path. Components that do not
+// exist yet are kept as they are, and dangling symlinks are followed to where
+// they point, so a path about to be created resolves to where it would be
+// written.
+func realPath(abs string, depth int) (string, error) {
+	if depth > maxSymlinkDepth {
+		return "", fmt.Errorf("too many levels of symbolic links")
+	}
+
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		return real, nil
+	}
+
+	info, err := os.Lstat(abs)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(abs)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(abs), target)
+		}
+		return realPath(target, depth+1)
+	}
+
+	parent := filepath.Dir(abs)
+	if parent == abs {
+		return abs, nil
+	}
+	realParent, err := realPath(parent, depth)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(realParent, filepath.Base(abs)), nil
+}
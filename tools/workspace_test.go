@@ -0,0 +1,272 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestWorkspace creates a workspace root holding a few files, a .git
+// directory, an .env file and symlinks pointing inside and outside it
+func newTestWorkspace(t *testing.T) (root, outside string, ws *Workspace) {
+	t.Helper()
+
+	base := t.TempDir()
+	root = filepath.Join(base, "repo")
+	outside = filepath.Join(base, "outside")
+
+	for _, dir := range []string{root, outside, filepath.Join(root, "src"), filepath.Join(root, ".git")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(root, "src", "main.go"): "package main // secret\n",
+		filepath.Join(root, ".env"):           "TOKEN=secret\n",
+		filepath.Join(root, "src", ".env"):    "TOKEN=secret\n",
+		filepath.Join(root, ".git", "config"): "secret\n",
+		filepath.Join(outside, "passwd.txt"):  "secret\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		filepath.Join(root, "escape.txt"): filepath.Join(outside, "passwd.txt"),
+		filepath.Join(root, "escapedir"):  outside,
+		filepath.Join(root, "inside.txt"): filepath.Join(root, "src", "main.go"),
+		filepath.Join(root, "dangling"):   filepath.Join(outside, "new.txt"),
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	ws, err := NewWorkspace(root)
+	if err != nil {
+		t.Fatalf("NewWorkspace failed: %v", err)
+	}
+	ws.Deny(DefaultDenyPatterns...)
+	return root, outside, ws
+}
+
+func TestNewWorkspace(t *testing.T) {
+	if _, err := NewWorkspace(); err == nil {
+		t.Error("Expected error for no roots")
+	}
+
+	dir := t.TempDir()
+	if _, err := NewWorkspace(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected error for a missing root")
+	}
+
+	file := filepath.Join(dir, "file.txt")
+	os.WriteFile(file, []byte("x"), 0644)
+	if _, err := NewWorkspace(file); err == nil {
+		t.Error("Expected error for a file root")
+	}
+
+	ws, err := NewWorkspace(dir)
+	if err != nil {
+		t.Fatalf("NewWorkspace failed: %v", err)
+	}
+	if roots := ws.Roots(); len(roots) != 1 || roots[0] != dir {
+		t.Errorf("Expected roots [%s], got %v", dir, roots)
+	}
+}
+
+func TestWorkspace_Check(t *testing.T) {
+	root, outside, ws := newTestWorkspace(t)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{"root", root, ""},
+		{"file", filepath.Join(root, "src", "main.go"), ""},
+		{"new file", filepath.Join(root, "src", "new", "file.go"), ""},
+		{"symlink inside", filepath.Join(root, "inside.txt"), ""},
+		{"outside", filepath.Join(outside, "passwd.txt"), "is outside the workspace"},
+		{"traversal", filepath.Join(root, "src", "..", "..", "outside", "passwd.txt"), "is outside the workspace"},
+		{"git directory", filepath.Join(root, ".git"), `denied by the workspace pattern ".git/**"`},
+		{"git file", filepath.Join(root, ".git", "config"), `denied by the workspace pattern ".git/**"`},
+		{"env", filepath.Join(root, ".env"), `denied by the workspace pattern "**/.env"`},
+		{"nested env", filepath.Join(root, "src", ".env"), `denied by the workspace pattern "**/.env"`},
+		{"symlinked file", filepath.Join(root, "escape.txt"), "escapes the workspace through a symlink"},
+		{"through symlinked dir", filepath.Join(root, "escapedir", "passwd.txt"), "escapes the workspace through a symlink"},
+		{"new file in symlinked dir", filepath.Join(root, "escapedir", "new.txt"), "escapes the workspace through a symlink"},
+		{"dangling symlink", filepath.Join(root, "dangling"), "escapes the workspace through a symlink"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ws.Check(tt.path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected %s to be allowed, got %v", tt.path, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected %s to be rejected", tt.path)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), tt.path) {
+				t.Errorf("Expected error naming %s and containing %q, got %q", tt.path, tt.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestWorkspace_Nil(t *testing.T) {
+	var ws *Workspace
+	if err := ws.Check("/etc/passwd"); err != nil {
+		t.Errorf("Expected nil workspace to allow everything, got %v", err)
+	}
+	if ws.IsRoot("/") {
+		t.Error("Expected nil workspace to have no roots")
+	}
+}
+
+func TestWorkspace_SymlinkLoop(t *testing.T) {
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a"), filepath.Join(root, "b")
+	if err := os.Symlink(b, a); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	os.Symlink(a, b)
+
+	ws, _ := NewWorkspace(root)
+	if err := ws.Check(a); err == nil || !strings.Contains(err.Error(), a) {
+		t.Errorf("Expected error naming %s for a symlink loop, got %v", a, err)
+	}
+}
+
+func TestWorkspace_ToolsRejectPaths(t *testing.T) {
+	root, outside, ws := newTestWorkspace(t)
+	ctx := context.Background()
+	secret := filepath.Join(outside, "passwd.txt")
+	env := filepath.Join(root, ".env")
+	escape := filepath.Join(root, "escape.txt")
+
+	tests := []struct {
+		name   string
+		tool   Tool
+		params string
+		path   string
+	}{
+		{"read outside", NewReadTool().WithWorkspace(ws), `{"path": %q}`, secret},
+		{"read env", NewReadTool().WithWorkspace(ws), `{"path": %q}`, env},
+		{"read symlink", NewReadTool().WithWorkspace(ws), `{"path": %q}`, escape},
+		{"write outside", NewWriteTool().WithWorkspace(ws), `{"path": %q, "content": "x"}`, filepath.Join(outside, "new.txt")},
+		{"write through symlink", NewWriteTool().WithWorkspace(ws), `{"path": %q, "content": "x"}`, filepath.Join(root, "dangling")},
+		{"edit outside", NewEditTool().WithWorkspace(ws), `{"path": %q, "old_string": "secret", "new_string": "x"}`, secret},
+		{"patch outside", NewPatchTool().WithWorkspace(ws), `{"patch": "--- /dev/null\n+++ %s\n@@ -0,0 +1 @@\n+x\n"}`, filepath.Join(outside, "patched.txt")},
+		{"batch_edit outside", NewBatchEditTool().WithWorkspace(ws), `{"operations": [{"op": "write", "path": %q, "content": "x"}]}`, filepath.Join(outside, "batch.txt")},
+		{"delete outside", NewDeleteTool().WithWorkspace(ws), `{"path": %q}`, secret},
+		{"delete git", NewDeleteTool().WithWorkspace(ws), `{"path": %q, "recursive": true}`, filepath.Join(root, ".git")},
+		{"delete root", NewDeleteTool().WithWorkspace(ws), `{"path": %q, "recursive": true}`, root},
+		{"delete tree with env", NewDeleteTool().WithWorkspace(ws), `{"path": %q, "recursive": true}`, filepath.Join(root, "src", ".env")},
+		{"move outside", NewMoveTool().WithWorkspace(ws), `{"source": %q, "destination": "moved.txt"}`, secret},
+		{"move env", NewMoveTool().WithWorkspace(ws), `{"source": %q, "destination": "moved.txt"}`, env},
+		{"copy outside", NewCopyTool().WithWorkspace(ws), `{"source": %q, "destination": "copied.txt"}`, secret},
+		{"mkdir outside", NewMkdirTool().WithWorkspace(ws), `{"path": %q}`, filepath.Join(outside, "dir")},
+		{"list outside", NewListTool().WithWorkspace(ws), `{"path": %q}`, outside},
+		{"glob outside", NewGlobTool().WithWorkspace(ws), `{"patterns": ["*"], "path": %q}`, outside},
+		{"search outside", NewSearchTool().WithWorkspace(ws), `{"pattern": "secret", "path": %q}`, outside},
+		{"grep outside", NewGrepTool().WithWorkspace(ws), `{"pattern": "secret", "files": [%q]}`, secret},
+		{"fetch save outside", NewFetchTool().WithWorkspace(ws), `{"url": "https://example.com/", "save_path": %q}`, filepath.Join(outside, "new.txt")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := json.RawMessage(fmt.Sprintf(tt.params, tt.path))
+			_, err := tt.tool.Execute(ctx, params)
+			if err == nil {
+				t.Fatalf("Expected %s to be rejected", tt.path)
+			}
+			if !strings.Contains(err.Error(), tt.path) {
+				t.Errorf("Expected error to name %s, got %q", tt.path, err.Error())
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); !os.IsNotExist(err) {
+		t.Error("Expected no file to be written outside the workspace")
+	}
+	if _, err := os.Stat(filepath.Join(root, "src", ".env")); err != nil {
+		t.Error("Expected the denied file to survive")
+	}
+}
+
+func TestWorkspace_DeleteTreeWithDeniedEntry(t *testing.T) {
+	root, _, ws := newTestWorkspace(t)
+
+	_, err := NewDeleteTool().WithWorkspace(ws).Execute(context.Background(),
+		json.RawMessage(fmt.Sprintf(`{"path": %q, "recursive": true}`, filepath.Join(root, "src"))))
+	if err == nil || !strings.Contains(err.Error(), filepath.Join(root, "src", ".env")) {
+		t.Fatalf("Expected error naming the denied file, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "src", "main.go")); err != nil {
+		t.Error("Expected nothing to be deleted")
+	}
+}
+
+func TestWorkspace_ToolsSkipDeniedEntries(t *testing.T) {
+	root, _, ws := newTestWorkspace(t)
+	ctx := context.Background()
+
+	result, err := NewListTool().WithWorkspace(ws).Execute(ctx,
+		json.RawMessage(fmt.Sprintf(`{"path": %q, "recursive": true}`, root)))
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var names []string
+	for _, file := range result.(*ListResult).Files {
+		names = append(names, file.Name)
+	}
+	want := "inside.txt main.go src"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("Expected list %q, got %q", want, got)
+	}
+
+	globResult, err := NewGlobTool().WithWorkspace(ws).Execute(ctx,
+		json.RawMessage(fmt.Sprintf(`{"patterns": ["**/*", "../outside/*"], "path": %q}`, root)))
+	if err != nil {
+		t.Fatalf("Glob failed: %v", err)
+	}
+	for _, match := range globResult.(*GlobResult).Matches {
+		if err := ws.Check(match.Path); err != nil {
+			t.Errorf("Glob returned a path outside the workspace: %v", err)
+		}
+	}
+
+	searchResult, err := NewSearchTool().WithWorkspace(ws).Execute(ctx,
+		json.RawMessage(fmt.Sprintf(`{"pattern": "secret", "path": %q, "recursive": true}`, root)))
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	for _, match := range searchResult.(*SearchResult).Matches {
+		if !strings.HasSuffix(match.File, "main.go") && !strings.HasSuffix(match.File, "inside.txt") {
+			t.Errorf("Search matched a file outside the workspace: %s", match.File)
+		}
+	}
+
+	grepResult, err := NewGrepTool().WithWorkspace(ws).Execute(ctx,
+		json.RawMessage(fmt.Sprintf(`{"pattern": "secret", "files": [%q]}`, filepath.Join(root, "**"))))
+	if err != nil {
+		t.Fatalf("Grep failed: %v", err)
+	}
+	for _, match := range grepResult.(*GrepResult).Matches {
+		if !strings.HasSuffix(match.File, "main.go") && !strings.HasSuffix(match.File, "inside.txt") {
+			t.Errorf("Grep matched a file outside the workspace: %s", match.File)
+		}
+	}
+}
@@ -10,7 +10,9 @@ import (
 )
 
 // WriteTool implements file writing functionality
-type WriteTool struct{}
+type WriteTool struct {
+	workspace *Workspace
+}
 
 // WriteParams defines the parameters for the Write tool
 type WriteParams struct {
@@ -33,6 +35,12 @@ func NewWriteTool() *WriteTool {
 	return &WriteTool{}
 }
 
+// WithWorkspace confines the tool to ws; paths outside it are rejected
+func (t *WriteTool) WithWorkspace(ws *Workspace) *WriteTool {
+	t.workspace = ws
+	return t
+}
+
 // Name returns the tool's name
 func (t *WriteTool) Name() string {
 	return "write"
@@ -43,6 +51,23 @@ func (t *WriteTool) Description() string {
 	return "Write content to files with support for text and binary formats, append mode, automatic directory creation, and backup functionality"
 }
 
+// Risk returns the tool's risk class
+func (t *WriteTool) Risk() Risk {
+	return RiskMutating
+}
+
+// ChangedPaths returns the file the call writes, and its backup if requested
+func (t *WriteTool) ChangedPaths(params json.RawMessage) []string {
+	var p WriteParams
+	if err := json.Unmarshal(params, &p); err != nil || p.Path == "" {
+		return nil
+	}
+	if p.Backup && p.Mode != "append" {
+		return []string{p.Path, p.Path + ".backup"}
+	}
+	return []string{p.Path}
+}
+
 // Schema returns the JSON schema for the tool's parameters
 func (t *WriteTool) Schema() map[string]interface{} {
 	return map[string]interface{}{
@@ -71,7 +96,8 @@ func (t *WriteTool) Schema() map[string]interface{} {
 				"description": "Create a backup of existing file before overwriting",
 			},
 		},
-		"required": []string{"path", "content"},
+		"required":             []string{"path", "content"},
+		"additionalProperties": false,
 	}
 }
 
@@ -114,6 +140,10 @@ func (t *WriteTool) Execute(ctx context.Context, params json.RawMessage) (interf
 		p.Encoding = "utf-8"
 	}
 
+	if err := t.workspace.Check(p.Path); err != nil {
+		return nil, err
+	}
+
 	// Ensure directory exists
 	dir := filepath.Dir(p.Path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -158,16 +188,8 @@ func (t *WriteTool) Execute(ctx context.Context, params json.RawMessage) (interf
 		}
 		bytesWritten = int64(n)
 	} else {
-		// Use a temporary file for atomic writes
-		tmpFile := p.Path + ".tmp"
-		if err := os.WriteFile(tmpFile, data, 0644); err != nil {
-			return nil, fmt.Errorf("failed to write to temporary file: %w", err)
-		}
-
-		// Rename temp file to target (atomic operation on most systems)
-		if err := os.Rename(tmpFile, p.Path); err != nil {
-			os.Remove(tmpFile) // Clean up temp file on error
-			return nil, fmt.Errorf("failed to rename temporary file: %w", err)
+		if err := writeFileAtomic(p.Path, data, 0644); err != nil {
+			return nil, err
 		}
 
 		bytesWritten = int64(len(data))
@@ -182,6 +204,40 @@ func (t *WriteTool) Execute(ctx context.Context, params json.RawMessage) (interf
 	return result, nil
 }
 
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path, so readers never see a partially written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpFile, err := stageFile(path, data, perm)
+	if err != nil {
+		return err
+	}
+	return commitFile(tmpFile, path)
+}
+
+// stageFile writes data to the temporary file used to replace path and
+// returns its name
+func stageFile(path string, data []byte, perm os.FileMode) (string, error) {
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, perm); err != nil {
+		return "", fmt.Errorf("failed to write to temporary file: %w", err)
+	}
+	if err := os.Chmod(tmpFile, perm); err != nil {
+		os.Remove(tmpFile)
+		return "", fmt.Errorf("failed to set file mode: %w", err)
+	}
+	return tmpFile, nil
+}
+
+// commitFile renames a staged temporary file over path
+func commitFile(tmpFile, path string) error {
+	// Rename temp file to target (atomic operation on most systems)
+	if err := os.Rename(tmpFile, path); err != nil {
+		os.Remove(tmpFile) // Clean up temp file on error
+		return fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+	return nil
+}
+
 // copyFile copies a file from src to dst
 func copyFile(src, dst string) error {
 	data, err := os.ReadFile(src)
@@ -19,6 +19,9 @@ type Application struct {
 	footer         FooterModel
 	layout         *Layout
 	content        []string
+	blockStart     int    // Start of the block being rewritten in place, -1 when none
+	blockText      string // Text of the open block
+	streaming      bool   // Whether the open block is receiving streamed text
 	width          int
 	height         int
 	scrollOffset   int
@@ -27,6 +30,8 @@ type Application struct {
 	metadata       map[string]interface{}
 	shouldExit     bool
 	ctx            context.Context
+	turnCancel     context.CancelFunc // Cancels the in-flight turn, nil when idle
+	approvals      []*pendingApproval // Tool calls waiting for approval, shown in order
 }
 
 // ApplicationConfig holds configuration for creating a new Application
@@ -82,6 +87,7 @@ func NewApplication(config ApplicationConfig) *Application {
 		footer:         footer,
 		layout:         layout,
 		content:        make([]string, 0),
+		blockStart:     -1,
 		width:          80,
 		height:         24,
 		scrollOffset:   0,
@@ -128,8 +134,18 @@ func (a *Application) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, nil
 
 	case tea.KeyMsg:
+		// A pending approval takes all key presses until it is answered
+		if a.ApprovalPending() {
+			a.handleApprovalKey(msg)
+			return a, nil
+		}
+
 		switch msg.String() {
-		case "ctrl+c":
+		case "ctrl+c", "esc":
+			// The first press cancels an in-flight turn, the next one quits
+			if a.CancelTurn() {
+				return a, nil
+			}
 			return a, tea.Quit
 		}
 
@@ -145,6 +161,14 @@ func (a *Application) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, tea.Quit
 		}
 		return a, nil
+
+	case StreamStartMsg, StreamUpdateMsg, StreamDoneMsg:
+		a.handleStreamMsg(msg)
+		return a, nil
+
+	case ApprovalRequestMsg:
+		a.handleApprovalMsg(msg)
+		return a, nil
 	}
 
 	return a, nil
@@ -157,6 +181,14 @@ func (a *Application) View() string {
 	// Calculate available height for content panel
 	contentHeight := a.height - 1 // -1 for footer
 
+	// A pending approval prompt is shown between the panel and the footer
+	approval := a.ApprovalView()
+	approvalLines := 0
+	if approval != "" {
+		approvalLines = strings.Count(approval, "\n") + 2
+		contentHeight -= approvalLines
+	}
+
 	// Update panel size
 	a.panel.SetSize(a.width, contentHeight)
 
@@ -172,13 +204,18 @@ func (a *Application) View() string {
 
 	// Calculate how many lines we need to fill before the footer
 	// We want: panelLines + fillLines + 1 (footer) = a.height
-	fillLines := a.height - panelLines - 1
+	fillLines := a.height - panelLines - 1 - approvalLines
 
 	// Add fill lines to push footer to bottom
 	if fillLines > 0 {
 		b.WriteString(strings.Repeat("\n", fillLines))
 	}
 
+	if approval != "" {
+		b.WriteString("\n\n")
+		b.WriteString(approval)
+	}
+
 	// Add newline before footer (this is the separator)
 	b.WriteString("\n")
 
@@ -204,12 +241,14 @@ func (a *Application) AddLines(lines []string) {
 func (a *Application) Clear() {
 	a.content = make([]string, 0)
 	a.scrollOffset = 0
+	a.EndBlock()
 	a.updatePanelContent()
 }
 
 // SetContent replaces all content with the provided lines
 func (a *Application) SetContent(lines []string) {
 	a.content = lines
+	a.EndBlock()
 	a.updatePanelContent()
 }
 
@@ -293,6 +332,50 @@ func (a *Application) getVisibleLines(height int) []string {
 	return a.content[start:end]
 }
 
+// StartTurn begins a cancellable turn, such as an agent call, and returns
+// its context. Any turn still in flight is cancelled first.
+func (a *Application) StartTurn() context.Context {
+	if a.turnCancel != nil {
+		a.turnCancel()
+	}
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.turnCancel = cancel
+	return ctx
+}
+
+// EndTurn marks the current turn as finished and releases its context
+func (a *Application) EndTurn() {
+	if a.turnCancel != nil {
+		a.turnCancel()
+		a.turnCancel = nil
+	}
+}
+
+// TurnActive reports whether a turn is in flight
+func (a *Application) TurnActive() bool {
+	return a.turnCancel != nil
+}
+
+// CancelTurn cancels the in-flight turn, completes any streamed block and adds
+// a cancelled marker to the content. It returns false if no turn was active.
+func (a *Application) CancelTurn() bool {
+	if a.turnCancel == nil {
+		return false
+	}
+
+	a.EndTurn()
+	a.denyApprovals()
+
+	if a.streaming {
+		a.handleStreamMsg(StreamDoneMsg{})
+	}
+	a.AddLine(HelpStyle.Render("⊘ Cancelled"))
+	a.AddLine("")
+
+	return true
+}
+
 // ApplicationState interface implementation
 
 // GetMode returns the current application mode
@@ -0,0 +1,151 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/geoffjay/agar/tools"
+)
+
+// approvalParamsWidth is how much of a call's parameters the prompt shows
+const approvalParamsWidth = 300
+
+// ApprovalRequestMsg asks the application to show a tool call for approval.
+// It is sent by an Approver; the answer goes back on its reply channel.
+type ApprovalRequestMsg struct {
+	Request tools.ApprovalRequest
+	reply   chan tools.ApprovalDecision
+}
+
+// Approver is a tools.Approver that asks the user through a running
+// Application, typically with program.Send:
+//
+//	gate := tools.NewApprovalGate(policy, tui.NewApprover(program.Send))
+//	registry.Use(gate.Middleware())
+type Approver struct {
+	send func(tea.Msg)
+}
+
+// NewApprover creates an Approver that delivers requests with send
+func NewApprover(send func(tea.Msg)) *Approver {
+	return &Approver{send: send}
+}
+
+// Approve shows the request and waits for the user's answer or for ctx to
+// be cancelled
+func (a *Approver) Approve(ctx context.Context, req tools.ApprovalRequest) (tools.ApprovalDecision, error) {
+	reply := make(chan tools.ApprovalDecision, 1)
+	a.send(ApprovalRequestMsg{Request: req, reply: reply})
+
+	select {
+	case decision := <-reply:
+		return decision, nil
+	case <-ctx.Done():
+		return tools.DecisionDeny, ctx.Err()
+	}
+}
+
+// pendingApproval is an approval request shown to the user
+type pendingApproval struct {
+	request tools.ApprovalRequest
+	reply   chan tools.ApprovalDecision
+	model   YesNoModel
+}
+
+// newPendingApproval builds the prompt for an approval request
+func newPendingApproval(msg ApprovalRequestMsg) *pendingApproval {
+	req := msg.Request
+	prompt := fmt.Sprintf("Allow %s (%s)?", req.Tool, strings.ReplaceAll(string(req.Risk), "_", "-"))
+
+	var help strings.Builder
+	if len(req.Paths) > 0 {
+		fmt.Fprintf(&help, "Paths: %s\n", strings.Join(req.Paths, ", "))
+	}
+	params := strings.Join(strings.Fields(string(req.Params)), " ")
+	if len(params) > approvalParamsWidth {
+		params = params[:approvalParamsWidth] + "..."
+	}
+	help.WriteString(params)
+
+	return &pendingApproval{
+		request: req,
+		reply:   msg.reply,
+		model: NewYesNoInput(prompt, help.String()).
+			WithSessionOption("Yes, allow " + req.Tool + " for this session").
+			WithDefaultNo(), // A stray Enter must not run the call
+	}
+}
+
+// ApprovalPending reports whether a tool call is waiting for approval. Host
+// models should forward key presses to the application while it is.
+func (a *Application) ApprovalPending() bool {
+	return len(a.approvals) > 0
+}
+
+// handleApprovalMsg queues an approval request; requests are shown one at a time
+func (a *Application) handleApprovalMsg(msg ApprovalRequestMsg) {
+	a.approvals = append(a.approvals, newPendingApproval(msg))
+}
+
+// handleApprovalKey routes a key press to the current approval prompt and
+// answers the request once the user has chosen. Esc and ctrl+c deny the call.
+func (a *Application) handleApprovalKey(msg tea.KeyMsg) {
+	pending := a.approvals[0]
+
+	switch msg.String() {
+	case "ctrl+c", "esc":
+		a.answerApproval(tools.DecisionDeny)
+		return
+	}
+
+	updated, _ := pending.model.Update(msg)
+	pending.model = updated.(YesNoModel)
+	if !pending.model.IsDone() {
+		return
+	}
+
+	switch {
+	case pending.model.ForSession():
+		a.answerApproval(tools.DecisionAllowSession)
+	case pending.model.GetAnswer():
+		a.answerApproval(tools.DecisionAllowOnce)
+	default:
+		a.answerApproval(tools.DecisionDeny)
+	}
+}
+
+// answerApproval replies to the current approval request and notes the
+// decision in the content
+func (a *Application) answerApproval(decision tools.ApprovalDecision) {
+	pending := a.approvals[0]
+	a.approvals = a.approvals[1:]
+	pending.reply <- decision
+
+	switch decision {
+	case tools.DecisionAllowSession:
+		a.AddLine(SuccessStyle.Render("✓ Allowed " + pending.request.Tool + " for this session"))
+	case tools.DecisionAllowOnce:
+		a.AddLine(SuccessStyle.Render("✓ Allowed " + pending.request.Tool))
+	default:
+		a.AddLine(ErrorStyle.Render("✗ Denied " + pending.request.Tool))
+	}
+}
+
+// denyApprovals denies every pending approval request, for example when the
+// turn is cancelled
+func (a *Application) denyApprovals() {
+	for len(a.approvals) > 0 {
+		a.answerApproval(tools.DecisionDeny)
+	}
+}
+
+// ApprovalView renders the current approval prompt, or "" if none is
+// pending. Host models that lay out their own input show it in its place.
+func (a *Application) ApprovalView() string {
+	if len(a.approvals) == 0 {
+		return ""
+	}
+	return a.approvals[0].model.View()
+}
@@ -9,11 +9,13 @@ import (
 
 // YesNoModel represents a yes/no input component
 type YesNoModel struct {
-	prompt   string
-	helpText string
-	selected bool // true = yes, false = no
-	focused  int  // 0 = yes, 1 = no
-	done     bool
+	prompt       string
+	helpText     string
+	sessionLabel string // Label of the optional "yes for this session" choice
+	selected     bool   // true = yes, false = no
+	session      bool   // Whether "yes for this session" was chosen
+	focused      int    // 0 = yes, then the session choice if enabled, then no
+	done         bool
 }
 
 // NewYesNoInput creates a new yes/no input component
@@ -27,6 +29,34 @@ func NewYesNoInput(prompt, helpText string) YesNoModel {
 	}
 }
 
+// WithSessionOption adds a third choice between yes and no, such as "Yes,
+// allow for this session". It is chosen with its label or the "a" key and
+// reported by ForSession.
+func (m YesNoModel) WithSessionOption(label string) YesNoModel {
+	onNo := m.focused == m.noIndex()
+	m.sessionLabel = label
+	if onNo {
+		m.focused = m.noIndex()
+	}
+	return m
+}
+
+// WithDefaultNo focuses "No" instead of "Yes", so pressing Enter without
+// choosing declines
+func (m YesNoModel) WithDefaultNo() YesNoModel {
+	m.selected = false
+	m.focused = m.noIndex()
+	return m
+}
+
+// noIndex returns the focus index of the "no" choice
+func (m YesNoModel) noIndex() int {
+	if m.sessionLabel != "" {
+		return 2
+	}
+	return 1
+}
+
 // Init initializes the component
 func (m YesNoModel) Init() tea.Cmd {
 	return nil
@@ -45,15 +75,28 @@ func (m YesNoModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case "enter", " ":
-			m.selected = (m.focused == 0)
+			m.selected = (m.focused != m.noIndex())
+			m.session = m.sessionLabel != "" && m.focused == 1
 			m.done = true
 			return m, tea.Quit
 
 		case "up", "k", "left", "h":
-			m.focused = 0
+			if m.focused > 0 {
+				m.focused--
+			}
 
 		case "down", "j", "right", "l":
-			m.focused = 1
+			if m.focused < m.noIndex() {
+				m.focused++
+			}
+
+		case "a", "A":
+			if m.sessionLabel != "" {
+				m.selected = true
+				m.session = true
+				m.done = true
+				return m, tea.Quit
+			}
 
 		case "y", "Y":
 			m.selected = true
@@ -93,36 +136,32 @@ func (m YesNoModel) View() string {
 	b.WriteString("\n\n")
 
 	// Options
-	yesStyle := lipgloss.NewStyle()
-	noStyle := lipgloss.NewStyle()
-
-	if m.focused == 0 {
-		yesStyle = yesStyle.Foreground(lipgloss.Color("212")).Bold(true)
+	choices := []string{"Yes"}
+	if m.sessionLabel != "" {
+		choices = append(choices, m.sessionLabel)
 	}
-	if m.focused == 1 {
-		noStyle = noStyle.Foreground(lipgloss.Color("212")).Bold(true)
-	}
-
-	// Yes option
-	if m.focused == 0 {
-		b.WriteString(yesStyle.Render("[x] Yes"))
-	} else {
-		b.WriteString(yesStyle.Render("[ ] Yes"))
+	choices = append(choices, "No")
+
+	for i, choice := range choices {
+		style := lipgloss.NewStyle()
+		if m.focused == i {
+			style = style.Foreground(lipgloss.Color("212")).Bold(true)
+			b.WriteString(style.Render("[x] " + choice))
+		} else {
+			b.WriteString(style.Render("[ ] " + choice))
+		}
+		b.WriteString("\n")
 	}
 	b.WriteString("\n")
 
-	// No option
-	if m.focused == 1 {
-		b.WriteString(noStyle.Render("[x] No"))
-	} else {
-		b.WriteString(noStyle.Render("[ ] No"))
-	}
-	b.WriteString("\n\n")
-
 	b.WriteString(HelpStyle.Render("───────────────────────────────────"))
 	b.WriteString("\n\n")
 
-	b.WriteString(HelpStyle.Render("↑/↓ or h/j/k/l to select • Enter/Space to confirm • y/n for quick answer • Esc to cancel"))
+	if m.sessionLabel != "" {
+		b.WriteString(HelpStyle.Render("↑/↓ or h/j/k/l to select • Enter/Space to confirm • y/a/n for quick answer • Esc to cancel"))
+	} else {
+		b.WriteString(HelpStyle.Render("↑/↓ or h/j/k/l to select • Enter/Space to confirm • y/n for quick answer • Esc to cancel"))
+	}
 
 	return b.String()
 }
@@ -132,6 +171,12 @@ func (m YesNoModel) GetAnswer() bool {
 	return m.selected
 }
 
+// ForSession reports whether the session choice added by WithSessionOption
+// was selected. GetAnswer is also true in that case.
+func (m YesNoModel) ForSession() bool {
+	return m.session
+}
+
 // GetAnswerString returns the answer as a string
 func (m YesNoModel) GetAnswerString() string {
 	if m.selected {
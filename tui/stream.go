@@ -0,0 +1,97 @@
+package tui
+
+import "strings"
+
+// StreamCursor is appended to the last line of a block while it is streaming
+const StreamCursor = "▌"
+
+// StreamStartMsg opens a new streamed block at the end of the content.
+// Placeholder, if set, is shown until the first update arrives.
+type StreamStartMsg struct {
+	Placeholder string
+}
+
+// StreamUpdateMsg replaces the streamed block with the text received so far
+type StreamUpdateMsg struct {
+	Text string
+}
+
+// StreamDoneMsg completes the streamed block. If Text is set it replaces the
+// block content, otherwise the last update is kept. The cursor is removed, as
+// is the placeholder if no text was received.
+type StreamDoneMsg struct {
+	Text string
+}
+
+// BeginBlock starts a new block at the end of the content. Until EndBlock is
+// called the block can be rewritten in place with SetBlock.
+func (a *Application) BeginBlock() {
+	a.blockStart = len(a.content)
+	a.blockText = ""
+}
+
+// SetBlock replaces the lines of the open block with text. A block is
+// started first if none is open.
+func (a *Application) SetBlock(text string) {
+	if a.blockStart < 0 || a.blockStart > len(a.content) {
+		a.BeginBlock()
+	}
+	a.blockText = text
+
+	lines := strings.Split(text, "\n")
+	if a.streaming {
+		lines[len(lines)-1] += StreamCursor
+	}
+
+	a.content = append(a.content[:a.blockStart], lines...)
+	a.updatePanelContent()
+}
+
+// EndBlock closes the open block, leaving its content in place
+func (a *Application) EndBlock() {
+	a.blockStart = -1
+	a.blockText = ""
+	a.streaming = false
+}
+
+// IsStreaming reports whether a streamed block is in progress
+func (a *Application) IsStreaming() bool {
+	return a.streaming
+}
+
+// handleStreamMsg applies a stream message to the open block
+func (a *Application) handleStreamMsg(msg interface{}) {
+	switch msg := msg.(type) {
+	case StreamStartMsg:
+		a.BeginBlock()
+		a.streaming = true
+		if msg.Placeholder != "" {
+			// Placeholders are shown without the cursor
+			a.streaming = false
+			a.SetBlock(msg.Placeholder)
+			a.blockText = ""
+			a.streaming = true
+		}
+
+	case StreamUpdateMsg:
+		a.streaming = true
+		a.SetBlock(msg.Text)
+
+	case StreamDoneMsg:
+		text := msg.Text
+		if text == "" {
+			text = a.blockText
+		}
+		a.streaming = false
+		if a.blockStart >= 0 && a.blockStart <= len(a.content) {
+			if text == "" {
+				// Nothing arrived, so drop the placeholder
+				a.content = a.content[:a.blockStart]
+				a.updatePanelContent()
+			} else {
+				a.SetBlock(text)
+			}
+		}
+		a.EndBlock()
+	}
+}